@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"billing-app/config"
+	"billing-app/pkg/database"
+	"billing-app/pkg/migrate"
+)
+
+const migrateUsage = `usage: main migrate <command>
+
+commands:
+  status        list migrations and whether each is applied
+  up            apply all pending migrations
+  down          roll back the latest applied migration
+  to <version>  migrate up or down to the version (0 rolls back everything)`
+
+func newMigrator() *migrate.Migrator {
+	migrator, err := migrate.New(database.DB, database.Migrations)
+	if err != nil {
+		log.Fatalf("Invalid migrations: %v", err)
+	}
+	return migrator
+}
+
+// migrateOnStart applies pending migrations when DB_MIGRATE_ON_START is set,
+// and AutoMigrates the models in dev when DB_AUTO_MIGRATE is set
+func migrateOnStart() {
+	migrator := newMigrator()
+
+	if config.AppConfig.Database.MigrateOnStart {
+		log.Println("Running migrations...")
+		done, err := migrator.Up()
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		log.Printf("Migrations completed successfully (%d applied).", len(done))
+	} else if pending, err := migrator.Pending(); err != nil {
+		log.Printf("Failed to check migrations: %v", err)
+	} else if pending > 0 {
+		log.Printf("Warning: %d migrations are pending; run \"migrate up\"", pending)
+	}
+
+	if config.AppConfig.Database.AutoMigrate {
+		if config.AppConfig.Server.Env != "dev" {
+			log.Println("Warning: DB_AUTO_MIGRATE is ignored outside SERVER_ENV=dev")
+			return
+		}
+		log.Println("Auto-migrating models (dev)...")
+		if err := database.AutoMigrate(); err != nil {
+			log.Fatalf("Auto-migration failed: %v", err)
+		}
+	}
+}
+
+// runMigrate implements the migrate subcommand
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+	migrator := newMigrator()
+
+	var (
+		done []migrate.Migration
+		err  error
+	)
+	switch args[0] {
+	case "status":
+		printMigrationStatus(migrator)
+		return
+	case "up":
+		done, err = migrator.Up()
+	case "down":
+		done, err = migrator.Down()
+	case "to":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			os.Exit(2)
+		}
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			log.Fatalf("Invalid version %q", args[1])
+		}
+		done, err = migrator.To(version)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	for _, m := range done {
+		log.Printf("Migrated %d %s", m.Version, m.Name)
+	}
+	if err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+	if len(done) == 0 {
+		log.Println("Nothing to migrate.")
+	}
+}
+
+func printMigrationStatus(migrator *migrate.Migrator) {
+	statuses, err := migrator.Status()
+	if err != nil {
+		log.Fatalf("Failed to read migration status: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, s := range statuses {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		if s.Unknown {
+			applied += " (not in this build)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+	}
+	w.Flush()
+}
@@ -3,15 +3,20 @@ package config
 import (
 	"billing-app/internal/models"
 	"log"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Defaults DefaultsConfig
-	Site     models.SiteInfo
+	Server    ServerConfig
+	Database  DatabaseConfig
+	Defaults  DefaultsConfig
+	Billing   BillingConfig
+	Sequences SequenceConfig
+	Invoice   InvoiceConfig
+	Inventory InventoryConfig
+	Site      models.SiteInfo
 }
 
 type ServerConfig struct {
@@ -19,6 +24,10 @@ type ServerConfig struct {
 	Env                string
 	JWTSecret          string `mapstructure:"jwt_secret"`
 	JWTExpirationHours int    `mapstructure:"jwt_expiration_hours"`
+
+	ReadTimeout     time.Duration // Whole request, body included (catalogue uploads)
+	WriteTimeout    time.Duration // Until the response is written (PDF invoices, exports)
+	ShutdownTimeout time.Duration // How long SIGTERM waits for open requests
 }
 
 type DatabaseConfig struct {
@@ -27,8 +36,12 @@ type DatabaseConfig struct {
 	Port     string
 	User     string
 	Password string
-	Name     string
+	Name     string // For sqlite, the database file path
 	URL      string
+	TLS      bool
+
+	MigrateOnStart bool // Apply pending migrations when the server starts
+	AutoMigrate    bool // Also sync tables with the models at start; honoured only when SERVER_ENV=dev
 }
 
 type DefaultsConfig struct {
@@ -43,6 +56,50 @@ type DefaultsConfig struct {
 	CompanyPhone    string `mapstructure:"company_phone"`
 }
 
+type BillingConfig struct {
+	GSTPercent     float64 `mapstructure:"gst_percent"` // Fallback rate for products without a GST slab
+	GSTIN          string  `mapstructure:"gstin"`
+	StoreState     string  `mapstructure:"store_state"`     // Intra-state sales (CGST+SGST) are to this state
+	RoundTo        float64 `mapstructure:"round_to"`        // 0 disables rounding of net payable
+	PriceTolerance float64 `mapstructure:"price_tolerance"` // Allowed drift between client and server totals
+	RequireShift   bool    `mapstructure:"require_shift"`   // Billers must open a till shift before billing
+}
+
+// SequenceConfig sets the document number format and reset policy (DAILY, FINANCIAL_YEAR, NEVER) per series
+type SequenceConfig struct {
+	BillNoFormat       string `mapstructure:"bill_no_format"`
+	BillNoReset        string `mapstructure:"bill_no_reset"`
+	OrderNoFormat      string `mapstructure:"order_no_format"`
+	OrderNoReset       string `mapstructure:"order_no_reset"`
+	CreditNoteNoFormat string `mapstructure:"credit_note_no_format"`
+	CreditNoteNoReset  string `mapstructure:"credit_note_no_reset"`
+	PONoFormat         string `mapstructure:"po_no_format"`
+	PONoReset          string `mapstructure:"po_no_reset"`
+	StockTakeNoFormat  string `mapstructure:"stock_take_no_format"`
+	StockTakeNoReset   string `mapstructure:"stock_take_no_reset"`
+	TransferNoFormat   string `mapstructure:"transfer_no_format"`
+	TransferNoReset    string `mapstructure:"transfer_no_reset"`
+}
+
+type InvoiceConfig struct {
+	TemplateDir string `mapstructure:"template_dir"` // May hold a thermal.tmpl overriding the receipt layout; PDF invoices are not templated
+	FontPath    string `mapstructure:"font_path"`    // UTF-8 TTF font for PDF invoices (Tamil, rupee sign)
+	Footer      string `mapstructure:"footer"`
+	UPIID       string `mapstructure:"upi_id"` // Printed as a UPI payment QR when set
+	PaperWidth  int    `mapstructure:"paper_width"`
+}
+
+type InventoryConfig struct {
+	BarcodePrefix  string `mapstructure:"barcode_prefix"`   // GS1 in-store range (20-29) for generated EAN-13s
+	NearExpiryDays int    `mapstructure:"near_expiry_days"` // Window for the near-expiry report
+
+	// Reorder suggestions
+	SalesWindowDays int `mapstructure:"sales_window_days"` // Sales history used for the average daily sales
+	LeadTimeDays    int `mapstructure:"lead_time_days"`    // For suppliers without their own lead time
+	SafetyDays      int `mapstructure:"safety_days"`       // Safety stock, in days of average sales
+	CoverDays       int `mapstructure:"cover_days"`        // Days of sales an order should last beyond the lead time
+}
+
 var AppConfig *Config
 
 func LoadConfig() {
@@ -62,6 +119,21 @@ func LoadConfig() {
 	viper.BindEnv("SERVER_PORT", "PORT") // Fallback to PORT if SERVER_PORT is missing
 	viper.BindEnv("DATABASE_URL")
 
+	viper.SetDefault("SERVER_READ_TIMEOUT", "30s")
+	viper.SetDefault("SERVER_WRITE_TIMEOUT", "60s")
+	viper.SetDefault("SERVER_SHUTDOWN_TIMEOUT", "30s")
+	viper.SetDefault("DB_DRIVER", "mysql")
+	viper.SetDefault("DB_TLS", true)
+	viper.SetDefault("DB_MIGRATE_ON_START", true)
+	viper.SetDefault("PRICE_TOLERANCE", 0.05)
+	viper.SetDefault("INVOICE_PAPER_WIDTH", 80)
+	viper.SetDefault("BARCODE_PREFIX", "20")
+	viper.SetDefault("NEAR_EXPIRY_DAYS", 30)
+	viper.SetDefault("REORDER_SALES_WINDOW_DAYS", 30)
+	viper.SetDefault("REORDER_LEAD_TIME_DAYS", 7)
+	viper.SetDefault("REORDER_SAFETY_DAYS", 3)
+	viper.SetDefault("REORDER_COVER_DAYS", 14)
+
 	// Manually map configuration to struct
 	AppConfig = &Config{
 		Server: ServerConfig{
@@ -69,6 +141,10 @@ func LoadConfig() {
 			Env:                viper.GetString("SERVER_ENV"),
 			JWTSecret:          viper.GetString("JWT_SECRET"),
 			JWTExpirationHours: viper.GetInt("JWT_EXPIRATION_HOURS"),
+
+			ReadTimeout:     viper.GetDuration("SERVER_READ_TIMEOUT"),
+			WriteTimeout:    viper.GetDuration("SERVER_WRITE_TIMEOUT"),
+			ShutdownTimeout: viper.GetDuration("SERVER_SHUTDOWN_TIMEOUT"),
 		},
 		Database: DatabaseConfig{
 			Driver:   viper.GetString("DB_DRIVER"),
@@ -78,6 +154,10 @@ func LoadConfig() {
 			Password: viper.GetString("DB_PASSWORD"),
 			Name:     viper.GetString("DB_NAME"),
 			URL:      viper.GetString("DATABASE_URL"),
+			TLS:      viper.GetBool("DB_TLS"),
+
+			MigrateOnStart: viper.GetBool("DB_MIGRATE_ON_START"),
+			AutoMigrate:    viper.GetBool("DB_AUTO_MIGRATE"),
 		},
 		Defaults: DefaultsConfig{
 			AdminPassword:   viper.GetString("ADMIN_PASSWORD"),
@@ -90,6 +170,43 @@ func LoadConfig() {
 			CompanyAddress:  viper.GetString("COMPANY_ADDRESS"),
 			CompanyPhone:    viper.GetString("COMPANY_PHONE"),
 		},
+		Billing: BillingConfig{
+			GSTPercent:     viper.GetFloat64("GST_PERCENT"),
+			GSTIN:          viper.GetString("GSTIN"),
+			StoreState:     viper.GetString("STORE_STATE"),
+			RoundTo:        viper.GetFloat64("ROUND_TO"),
+			PriceTolerance: viper.GetFloat64("PRICE_TOLERANCE"),
+			RequireShift:   viper.GetBool("REQUIRE_SHIFT"),
+		},
+		Invoice: InvoiceConfig{
+			TemplateDir: viper.GetString("INVOICE_TEMPLATE_DIR"),
+			FontPath:    viper.GetString("INVOICE_FONT_PATH"),
+			Footer:      viper.GetString("INVOICE_FOOTER"),
+			UPIID:       viper.GetString("INVOICE_UPI_ID"),
+			PaperWidth:  viper.GetInt("INVOICE_PAPER_WIDTH"),
+		},
+		Inventory: InventoryConfig{
+			BarcodePrefix:   viper.GetString("BARCODE_PREFIX"),
+			NearExpiryDays:  viper.GetInt("NEAR_EXPIRY_DAYS"),
+			SalesWindowDays: viper.GetInt("REORDER_SALES_WINDOW_DAYS"),
+			LeadTimeDays:    viper.GetInt("REORDER_LEAD_TIME_DAYS"),
+			SafetyDays:      viper.GetInt("REORDER_SAFETY_DAYS"),
+			CoverDays:       viper.GetInt("REORDER_COVER_DAYS"),
+		},
+		Sequences: SequenceConfig{
+			BillNoFormat:       viper.GetString("BILL_NO_FORMAT"),
+			BillNoReset:        viper.GetString("BILL_NO_RESET"),
+			OrderNoFormat:      viper.GetString("ORDER_NO_FORMAT"),
+			OrderNoReset:       viper.GetString("ORDER_NO_RESET"),
+			CreditNoteNoFormat: viper.GetString("CREDIT_NOTE_NO_FORMAT"),
+			CreditNoteNoReset:  viper.GetString("CREDIT_NOTE_NO_RESET"),
+			PONoFormat:         viper.GetString("PO_NO_FORMAT"),
+			PONoReset:          viper.GetString("PO_NO_RESET"),
+			StockTakeNoFormat:  viper.GetString("STOCK_TAKE_NO_FORMAT"),
+			StockTakeNoReset:   viper.GetString("STOCK_TAKE_NO_RESET"),
+			TransferNoFormat:   viper.GetString("TRANSFER_NO_FORMAT"),
+			TransferNoReset:    viper.GetString("TRANSFER_NO_RESET"),
+		},
 	}
 
 	// Load TOML Config for Site Info
@@ -124,4 +241,5 @@ func LoadConfig() {
 		return "NOT SET"
 	}())
 	log.Printf("- Company Name: %s", AppConfig.Defaults.CompanyName)
+	log.Printf("- GST Percent: %.2f", AppConfig.Billing.GSTPercent)
 }
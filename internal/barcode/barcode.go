@@ -0,0 +1,132 @@
+// Package barcode validates GS1 retail barcodes (EAN-13, UPC-A, EAN-8), generates
+// in-store codes and renders printable label sheets.
+package barcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrCheckDigit = errors.New("barcode check digit is invalid")
+	ErrEmpty      = errors.New("barcode is empty")
+)
+
+// CheckDigit computes the GS1 mod-10 check digit for a payload without its check digit
+func CheckDigit(payload string) (int, error) {
+	if !isDigits(payload) {
+		return 0, fmt.Errorf("barcode %q must be numeric", payload)
+	}
+	sum := 0
+	// Weights alternate 3,1,3... starting from the digit next to the check digit
+	for i := len(payload) - 1; i >= 0; i-- {
+		d := int(payload[i] - '0')
+		if (len(payload)-1-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return (10 - sum%10) % 10, nil
+}
+
+// IsGTIN reports whether the code has the shape of an EAN-8, UPC-A or EAN-13
+func IsGTIN(code string) bool {
+	switch len(code) {
+	case 8, 12, 13:
+		return isDigits(code)
+	}
+	return false
+}
+
+// Validate checks the check digit of EAN-8, UPC-A and EAN-13 codes. Other
+// formats (e.g. supplier Code 128 labels) are accepted as they are.
+func Validate(code string) error {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ErrEmpty
+	}
+	if !IsGTIN(code) {
+		return nil
+	}
+	want, _ := CheckDigit(code[:len(code)-1])
+	if int(code[len(code)-1]-'0') != want {
+		return ErrCheckDigit
+	}
+	return nil
+}
+
+// Internal builds an EAN-13 for a product without a manufacturer barcode, in the
+// GS1 restricted-circulation range given by prefix (20-29 for in-store use)
+func Internal(prefix string, productID uint) (string, error) {
+	if !isDigits(prefix) || len(prefix) == 0 || len(prefix) > 4 {
+		return "", fmt.Errorf("barcode prefix %q must be 1-4 digits", prefix)
+	}
+	width := 12 - len(prefix)
+	payload := fmt.Sprintf("%s%0*d", prefix, width, productID)
+	if len(payload) != 12 {
+		return "", fmt.Errorf("product ID %d does not fit an internal barcode", productID)
+	}
+	check, _ := CheckDigit(payload)
+	return fmt.Sprintf("%s%d", payload, check), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Module patterns per digit; G is the mirrored R pattern
+var (
+	lCodes = [10]string{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}
+	gCodes = [10]string{"0100111", "0110011", "0011011", "0100001", "0011101", "0111001", "0000101", "0010001", "0001001", "0010111"}
+	rCodes = [10]string{"1110010", "1100110", "1101100", "1000010", "1011100", "1001110", "1010000", "1000100", "1001000", "1110100"}
+
+	// EAN-13 encodes its first digit in the L/G parity of the left half
+	parity = [10]string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL"}
+)
+
+// Modules returns the bar pattern ('1' bar, '0' space) for a valid EAN-13, UPC-A
+// or EAN-8 code. UPC-A is drawn as EAN-13 with a leading zero.
+func Modules(code string) (string, error) {
+	if err := Validate(code); err != nil {
+		return "", err
+	}
+	if !IsGTIN(code) {
+		return "", fmt.Errorf("barcode %q is not EAN-13, UPC-A or EAN-8", code)
+	}
+	if len(code) == 12 {
+		code = "0" + code
+	}
+
+	var b strings.Builder
+	b.WriteString("101")
+	if len(code) == 8 {
+		for i := 0; i < 4; i++ {
+			b.WriteString(lCodes[code[i]-'0'])
+		}
+		b.WriteString("01010")
+		for i := 4; i < 8; i++ {
+			b.WriteString(rCodes[code[i]-'0'])
+		}
+	} else {
+		p := parity[code[0]-'0']
+		for i := 1; i <= 6; i++ {
+			if p[i-1] == 'L' {
+				b.WriteString(lCodes[code[i]-'0'])
+			} else {
+				b.WriteString(gCodes[code[i]-'0'])
+			}
+		}
+		b.WriteString("01010")
+		for i := 7; i < 13; i++ {
+			b.WriteString(rCodes[code[i]-'0'])
+		}
+	}
+	b.WriteString("101")
+	return b.String(), nil
+}
@@ -0,0 +1,131 @@
+package barcode_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"billing-app/internal/barcode"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want error
+	}{
+		{"EAN-13", "4006381333931", nil},
+		{"EAN-13 with check digit 7", "5901234123457", nil},
+		{"ISBN as EAN-13", "9780306406157", nil},
+		{"EAN-13 with check digit 9", "4012345000009", nil},
+		{"UPC-A", "036000291452", nil},
+		{"EAN-8", "96385074", nil},
+		{"EAN-8 with check digit 0", "12345670", nil},
+		{"EAN-13 wrong check digit", "4006381333932", barcode.ErrCheckDigit},
+		{"EAN-8 wrong check digit", "96385075", barcode.ErrCheckDigit},
+		{"UPC-A wrong check digit", "036000291453", barcode.ErrCheckDigit},
+		{"transposed digits", "4006383133931", barcode.ErrCheckDigit},
+		{"surrounding spaces", " 4006381333931 ", nil},
+		{"empty", "   ", barcode.ErrEmpty},
+		{"Code 128 label", "SUP-00042", nil},
+		{"other length digits", "123456789", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := barcode.Validate(tt.code); !errors.Is(err, tt.want) {
+				t.Errorf("Validate(%q) = %v, want %v", tt.code, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    int
+		wantErr bool
+	}{
+		{"400638133393", 1, false},
+		{"590123412345", 7, false},
+		{"9638507", 4, false},
+		{"03600029145", 2, false},
+		{"401234500000", 9, false},
+		{"1234567", 0, false},
+		{"40063813339A", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := barcode.CheckDigit(tt.payload)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("CheckDigit(%q) = %d, %v; want %d, error %v", tt.payload, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestInternal(t *testing.T) {
+	tests := []struct {
+		prefix    string
+		productID uint
+		want      string
+		wantErr   bool
+	}{
+		{"20", 1, "2000000000015", false},
+		{"20", 42, "2000000000428", false},
+		{"29", 123456789, "2901234567896", false},
+		{"2", 99999999999, "2999999999991", false},
+		{"2999", 123456789, "", true}, // Nine digits do not fit after a four digit prefix
+		{"", 1, "", true},
+		{"20A", 1, "", true},
+		{"20000", 1, "", true},
+	}
+	for _, tt := range tests {
+		got, err := barcode.Internal(tt.prefix, tt.productID)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Internal(%q, %d) = %q, %v; want %q, error %v", tt.prefix, tt.productID, got, err, tt.want, tt.wantErr)
+			continue
+		}
+		if err == nil {
+			if err := barcode.Validate(got); err != nil {
+				t.Errorf("Internal(%q, %d) = %q, which does not validate: %v", tt.prefix, tt.productID, got, err)
+			}
+		}
+	}
+}
+
+func TestModules(t *testing.T) {
+	tests := []struct {
+		code    string
+		width   int
+		wantErr bool
+	}{
+		{"4006381333931", 95, false},
+		{"036000291452", 95, false}, // Drawn as EAN-13 with a leading zero
+		{"96385074", 67, false},
+		{"4006381333932", 0, true},
+		{"SUP-00042", 0, true},
+	}
+	for _, tt := range tests {
+		modules, err := barcode.Modules(tt.code)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Modules(%q): %v, want error %v", tt.code, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if len(modules) != tt.width {
+			t.Errorf("Modules(%q) is %d modules wide, want %d", tt.code, len(modules), tt.width)
+		}
+		middle := (tt.width - 5) / 2
+		if !strings.HasPrefix(modules, "101") || !strings.HasSuffix(modules, "101") || modules[middle:middle+5] != "01010" {
+			t.Errorf("Modules(%q) = %s, missing its guard bars", tt.code, modules)
+		}
+	}
+
+	// The first digit of an EAN-13 only shows in the parity of the left half
+	plain, _ := barcode.Modules("036000291452")
+	padded, _ := barcode.Modules("0036000291452")
+	if plain != padded {
+		t.Error("UPC-A and its EAN-13 form draw differently")
+	}
+}
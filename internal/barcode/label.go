@@ -0,0 +1,76 @@
+package barcode
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Label is one sticker on a label sheet
+type Label struct {
+	Name  string
+	Price float64
+	Code  string
+}
+
+// A4 sheet of 3 x 8 labels, 70 x 37 mm each (the common 24-up sticker stock)
+const (
+	labelCols   = 3
+	labelRows   = 8
+	labelWidth  = 70.0
+	labelHeight = 37.0
+	sheetTop    = 0.5
+	moduleWidth = 0.33 // mm per bar module, close to the EAN nominal size
+	barHeight   = 15.0
+)
+
+// RenderLabels writes an A4 PDF of labels with the product name, price and bars.
+// Codes that are not EAN/UPC are printed as text only.
+func RenderLabels(labels []Label, w io.Writer) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	perPage := labelCols * labelRows
+	for i, l := range labels {
+		if i%perPage == 0 {
+			pdf.AddPage()
+		}
+		slot := i % perPage
+		x := float64(slot%labelCols) * labelWidth
+		y := sheetTop + float64(slot/labelCols)*labelHeight
+
+		pdf.SetFont("Helvetica", "B", 8)
+		pdf.SetXY(x+3, y+2)
+		pdf.CellFormat(labelWidth-6, 4, tr(truncate(l.Name, 40)), "", 0, "C", false, 0, "")
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetXY(x+3, y+6)
+		pdf.CellFormat(labelWidth-6, 4, fmt.Sprintf("MRP Rs. %.2f", l.Price), "", 0, "C", false, 0, "")
+
+		if modules, err := Modules(l.Code); err == nil {
+			barX := x + (labelWidth-float64(len(modules))*moduleWidth)/2
+			for j, m := range modules {
+				if m == '1' {
+					pdf.Rect(barX+float64(j)*moduleWidth, y+11, moduleWidth, barHeight, "F")
+				}
+			}
+		}
+		pdf.SetFont("Courier", "", 9)
+		pdf.SetXY(x+3, y+27)
+		pdf.CellFormat(labelWidth-6, 4, l.Code, "", 0, "C", false, 0, "")
+	}
+	if len(labels) == 0 {
+		pdf.AddPage()
+	}
+	return pdf.Output(w)
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-3]) + "..."
+}
@@ -1,53 +1,31 @@
 package handler
 
 import (
-	"fmt"
 	"net/http"
 
-	"billing-app/config"
-	"billing-app/internal/models"
-	"billing-app/internal/utils"
-	"billing-app/pkg/database"
+	"billing-app/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
-type AdminHandler struct{}
+type AdminHandler struct {
+	users service.UserService
+}
 
-type CreateEmployeeRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
-	RoleID   uint   `json:"role_id" binding:"required"`
-	Mobile   string `json:"mobile"`
+func NewAdminHandler(users service.UserService) *AdminHandler {
+	return &AdminHandler{users: users}
 }
 
 func (h *AdminHandler) CreateEmployee(c *gin.Context) {
-	var req CreateEmployeeRequest
+	var req service.CreateEmployeeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	hashedPassword, err := utils.HashPassword(req.Password)
+	user, err := h.users.CreateEmployee(req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
-		return
-	}
-
-	// Generate Employee ID
-	empID := generateEmployeeID(req.RoleID)
-
-	user := models.User{
-		Username:     req.Username,
-		PasswordHash: hashedPassword,
-		RoleID:       req.RoleID,
-		EmployeeID:   empID,
-		Mobile:       req.Mobile,
-		IsActive:     true,
-	}
-
-	if err := database.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		respondError(c, err, "Failed to create user")
 		return
 	}
 
@@ -55,8 +33,8 @@ func (h *AdminHandler) CreateEmployee(c *gin.Context) {
 }
 
 func (h *AdminHandler) ListEmployees(c *gin.Context) {
-	var users []models.User
-	if err := database.DB.Preload("Role").Find(&users).Error; err != nil {
+	users, err := h.users.ListEmployees()
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
 		return
 	}
@@ -64,7 +42,6 @@ func (h *AdminHandler) ListEmployees(c *gin.Context) {
 }
 
 func (h *AdminHandler) UpdateEmployeeRole(c *gin.Context) {
-	id := c.Param("id")
 	var req struct {
 		RoleID uint `json:"role_id" binding:"required"`
 	}
@@ -73,7 +50,7 @@ func (h *AdminHandler) UpdateEmployeeRole(c *gin.Context) {
 		return
 	}
 
-	if err := database.DB.Model(&models.User{}).Where("id = ?", id).Update("role_id", req.RoleID).Error; err != nil {
+	if err := h.users.UpdateRole(idParam(c, "id"), req.RoleID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
 		return
 	}
@@ -81,7 +58,6 @@ func (h *AdminHandler) UpdateEmployeeRole(c *gin.Context) {
 }
 
 func (h *AdminHandler) UpdateEmployeeStatus(c *gin.Context) {
-	id := c.Param("id")
 	var req struct {
 		IsActive       bool   `json:"is_active"`
 		InactiveReason string `json:"inactive_reason"`
@@ -91,30 +67,38 @@ func (h *AdminHandler) UpdateEmployeeStatus(c *gin.Context) {
 		return
 	}
 
-	if err := database.DB.Model(&models.User{}).Where("id = ?", id).Updates(models.User{IsActive: req.IsActive, InactiveReason: req.InactiveReason}).Error; err != nil {
+	if err := h.users.UpdateStatus(idParam(c, "id"), req.IsActive, req.InactiveReason); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update status"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Status updated successfully"})
 }
 
-func (h *AdminHandler) UpdateEmployee(c *gin.Context) {
-	id := c.Param("id")
+// UpdateEmployeeStore assigns the store an employee bills and receives stock at
+func (h *AdminHandler) UpdateEmployeeStore(c *gin.Context) {
 	var req struct {
-		Username string `json:"username" binding:"required"`
-		Mobile   string `json:"mobile"`
-		RoleID   uint   `json:"role_id"`
+		StoreID *uint `json:"store_id"` // Null moves the employee to the default store
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+
+	if err := h.users.UpdateStore(idParam(c, "id"), req.StoreID); err != nil {
+		respondError(c, err, "Failed to update store")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Store updated successfully"})
+}
+
+func (h *AdminHandler) UpdateEmployee(c *gin.Context) {
+	var req service.UpdateEmployeeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := database.DB.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
-		"username": req.Username,
-		"mobile":   req.Mobile,
-		"role_id":  req.RoleID,
-	}).Error; err != nil {
+	if err := h.users.UpdateEmployee(idParam(c, "id"), req); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update employee"})
 		return
 	}
@@ -122,7 +106,6 @@ func (h *AdminHandler) UpdateEmployee(c *gin.Context) {
 }
 
 func (h *AdminHandler) ResetEmployeePassword(c *gin.Context) {
-	id := c.Param("id")
 	var req struct {
 		Password string `json:"password" binding:"required"`
 	}
@@ -131,17 +114,16 @@ func (h *AdminHandler) ResetEmployeePassword(c *gin.Context) {
 		return
 	}
 
-	hashedPassword, _ := utils.HashPassword(req.Password)
-	if err := database.DB.Model(&models.User{}).Where("id = ?", id).Update("password_hash", hashedPassword).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+	if err := h.users.SetPassword(idParam(c, "id"), req.Password); err != nil {
+		respondError(c, err, "Failed to reset password")
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
 }
 
 func (h *AdminHandler) GetLoginHistory(c *gin.Context) {
-	var history []models.LoginHistory
-	if err := database.DB.Preload("User").Preload("User.Role").Order("login_time desc").Limit(100).Find(&history).Error; err != nil {
+	history, err := h.users.LoginHistory(100)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch login history"})
 		return
 	}
@@ -149,56 +131,6 @@ func (h *AdminHandler) GetLoginHistory(c *gin.Context) {
 }
 
 func (h *AdminHandler) GetDashboardStats(c *gin.Context) {
-	var totalEmployees int64
-	var activeUsers int64
-
-	database.DB.Model(&models.User{}).Count(&totalEmployees)
-	database.DB.Model(&models.User{}).Where("is_active = ?", true).Count(&activeUsers)
-
-	// Role Distribution
-	type RoleCount struct {
-		Name  string
-		Count int
-	}
-	var roleCounts []RoleCount
-	database.DB.Model(&models.User{}).Select("roles.name, count(users.id) as count").Joins("left join roles on roles.id = users.role_id").Group("roles.name").Scan(&roleCounts)
-
-	c.JSON(http.StatusOK, gin.H{
-		"total_employees":   totalEmployees,
-		"active_users":      activeUsers,
-		"role_distribution": roleCounts,
-	})
-}
-
-func generateEmployeeID(roleID uint) string {
-	var prefix string
-	switch roleID {
-	case 1:
-		prefix = config.AppConfig.Defaults.AdminEmployeeID // Fallback/Config logic needs review if this is a full ID
-		if len(prefix) > 3 {
-			prefix = prefix[:3]
-		} else {
-			prefix = "ADM"
-		}
-	case 2:
-		prefix = config.AppConfig.Defaults.ManagerPrefix
-	case 3:
-		prefix = config.AppConfig.Defaults.InventoryPrefix
-	case 4:
-		prefix = config.AppConfig.Defaults.BillerPrefix
-	default:
-		prefix = "EMP"
-	}
-
-	var lastUser models.User
-	// Find last user with this prefix to increment.
-	// Note: This is a simple implementation. In high concurrency, use a sequence or localized lock.
-	if err := database.DB.Where("employee_id LIKE ?", prefix+"%").Order("id desc").First(&lastUser).Error; err != nil {
-		return fmt.Sprintf("%s001", prefix)
-	}
-
-	// Extract number
-	var lastID int
-	fmt.Sscanf(lastUser.EmployeeID, prefix+"%d", &lastID)
-	return fmt.Sprintf("%s%03d", prefix, lastID+1)
+	stats, _ := h.users.Stats()
+	c.JSON(http.StatusOK, stats)
 }
@@ -3,9 +3,7 @@ package handler
 import (
 	"net/http"
 
-	"billing-app/internal/models"
-	"billing-app/internal/utils"
-	"billing-app/pkg/database"
+	"billing-app/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,7 +13,13 @@ type LoginRequest struct {
 	Password   string `json:"password" binding:"required"`
 }
 
-type AuthHandler struct{}
+type AuthHandler struct {
+	users service.UserService
+}
+
+func NewAuthHandler(users service.UserService) *AuthHandler {
+	return &AuthHandler{users: users}
+}
 
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
@@ -24,36 +28,12 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	if err := database.DB.Preload("Role").Where("employee_id = ?", req.EmployeeID).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-		return
-	}
-
-	if !user.IsActive {
-		c.JSON(http.StatusForbidden, gin.H{"error": "User is inactive"})
-		return
-	}
-
-	if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-		return
-	}
-
-	token, err := utils.GenerateToken(user.ID, user.Role.Name)
+	user, token, err := h.users.Login(req.EmployeeID, req.Password, c.ClientIP())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		respondError(c, err, "Failed to sign in")
 		return
 	}
 
-	// Update Login History (Async in production, but sync here for simplicity)
-	go func(uid uint, ip string) {
-		database.DB.Create(&models.LoginHistory{
-			UserID:    uid,
-			IPAddress: ip,
-		})
-	}(user.ID, c.ClientIP())
-
 	c.JSON(http.StatusOK, gin.H{
 		"token":    token,
 		"id":       user.ID,
@@ -72,14 +52,8 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	hashedPassword, err := utils.HashPassword(req.Password)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
-		return
-	}
-
-	if err := database.DB.Model(&models.User{}).Where("id = ?", userID).Update("password_hash", hashedPassword).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+	if err := h.users.SetPassword(userID, req.Password); err != nil {
+		respondError(c, err, "Failed to update password")
 		return
 	}
 
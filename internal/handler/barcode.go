@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"billing-app/internal/barcode"
+	"billing-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LookupBarcode resolves a scanned code for the billing counter. The response
+// carries the quantity one scan adds (pack_qty) so outer packs bill correctly.
+func (h *InventoryHandler) LookupBarcode(c *gin.Context) {
+	code := strings.TrimSpace(c.Param("code"))
+	product, packQty, err := h.catalog.LookupBarcode(code)
+	if err != nil {
+		respondError(c, err, "Failed to look up barcode")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":     code,
+		"pack_qty": packQty,
+		"product":  product,
+	})
+}
+
+func (h *InventoryHandler) AddBarcode(c *gin.Context) {
+	var req service.AddBarcodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	extra, err := h.catalog.AddBarcode(idParam(c, "id"), req)
+	if err != nil {
+		respondError(c, err, "Failed to add barcode")
+		return
+	}
+	c.JSON(http.StatusCreated, extra)
+}
+
+func (h *InventoryHandler) RemoveBarcode(c *gin.Context) {
+	if err := h.catalog.RemoveBarcode(idParam(c, "id"), idParam(c, "barcodeId")); err != nil {
+		respondError(c, err, "Failed to remove barcode")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Barcode removed"})
+}
+
+// GenerateBarcodes assigns in-store EAN-13 codes to products that have none
+func (h *InventoryHandler) GenerateBarcodes(c *gin.Context) {
+	var req service.GenerateBarcodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	assigned, err := h.catalog.GenerateBarcodes(req)
+	if err != nil {
+		respondError(c, err, "Failed to assign barcodes")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"assigned": assigned})
+}
+
+// PrintLabels renders an A4 sheet of barcode labels for the requested products
+func (h *InventoryHandler) PrintLabels(c *gin.Context) {
+	var req service.LabelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	labels, err := h.catalog.Labels(req)
+	if err != nil {
+		respondError(c, err, "Failed to fetch products")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := barcode.RenderLabels(labels, &buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render labels"})
+		return
+	}
+	c.Header("Content-Disposition", "inline; filename=labels.pdf")
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"billing-app/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *InventoryHandler) ListBatches(c *gin.Context) {
+	storeID, _ := storeIDQuery(c)
+	batches, err := h.inventory.Batches(idParam(c, "id"), storeID, c.Query("all") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch batches"})
+		return
+	}
+	c.JSON(http.StatusOK, batches)
+}
+
+// GetExpiryAlerts lists batches in stock expiring within ?days (NEAR_EXPIRY_DAYS by default)
+func (h *InventoryHandler) GetExpiryAlerts(c *gin.Context) {
+	days := config.AppConfig.Inventory.NearExpiryDays
+	if d, err := strconv.Atoi(c.Query("days")); err == nil && d > 0 {
+		days = d
+	}
+
+	storeID, _ := storeIDQuery(c)
+	alerts, err := h.inventory.ExpiryAlerts(days, storeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch expiry alerts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"days": days, "batches": alerts})
+}
+
+// GetExpiredStock lists expired batches still in stock. Billing will not sell
+// them; write them off with an EXPIRED adjustment against the batch.
+func (h *InventoryHandler) GetExpiredStock(c *gin.Context) {
+	storeID, _ := storeIDQuery(c)
+	alerts, err := h.inventory.ExpiredStock(storeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch expired stock"})
+		return
+	}
+
+	var units int
+	var value float64
+	for _, a := range alerts {
+		units += a.QuantityRemaining
+		value += a.StockValue
+	}
+	c.JSON(http.StatusOK, gin.H{"batches": alerts, "total_units": units, "total_value": value})
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+
+	"billing-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *InventoryHandler) ListBrands(c *gin.Context) {
+	brands, err := h.catalog.ListBrands()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch brands"})
+		return
+	}
+	c.JSON(http.StatusOK, brands)
+}
+
+func (h *InventoryHandler) CreateBrand(c *gin.Context) {
+	var req service.BrandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	brand, err := h.catalog.CreateBrand(req)
+	if err != nil {
+		respondError(c, err, "Failed to create brand")
+		return
+	}
+	c.JSON(http.StatusCreated, brand)
+}
+
+// UpdateBrand renames a brand. Renaming onto another brand's name is a merge.
+func (h *InventoryHandler) UpdateBrand(c *gin.Context) {
+	var req service.BrandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	brand, err := h.catalog.UpdateBrand(idParam(c, "id"), req)
+	if err != nil {
+		respondError(c, err, "Failed to update brand")
+		return
+	}
+	c.JSON(http.StatusOK, brand)
+}
+
+// DeleteBrand removes a brand no product uses, including deleted products
+func (h *InventoryHandler) DeleteBrand(c *gin.Context) {
+	if err := h.catalog.DeleteBrand(idParam(c, "id")); err != nil {
+		respondError(c, err, "Failed to delete brand")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Brand deleted successfully"})
+}
+
+// MergeBrand moves every product of a brand to another brand and deletes it,
+// e.g. to fold spelling variants created by FirstOrCreate into one brand
+func (h *InventoryHandler) MergeBrand(c *gin.Context) {
+	var req service.MergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	moved, err := h.catalog.MergeBrand(idParam(c, "id"), req.IntoID)
+	if err != nil {
+		respondError(c, err, "Failed to merge brands")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Brands merged", "products_moved": moved})
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"billing-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// readSheet reads all rows of a .csv file or the first sheet of a .xlsx file
+func readSheet(r io.Reader, filename string) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		return reader.ReadAll()
+	case ".xlsx":
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return f.GetRows(f.GetSheetName(0))
+	}
+	return nil, errors.New("file must be .csv or .xlsx")
+}
+
+// ImportProducts creates or updates products from an uploaded CSV/XLSX file.
+// Rows match existing products by id, then SKU, then barcode; opening_stock
+// only applies to new products and goes to ?store_id or the user's store.
+// The whole file is applied in one transaction, and nothing is saved if any
+// row fails or ?dry_run=true.
+func (h *InventoryHandler) ImportProducts(c *gin.Context) {
+	upload, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload the catalogue as form field 'file'"})
+		return
+	}
+	file, err := upload.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	rows, err := readSheet(file, upload.Filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts := service.ImportOptions{DryRun: c.Query("dry_run") == "true"}
+	if id, ok := storeIDQuery(c); ok {
+		opts.StoreID = &id
+	}
+
+	summary, err := h.catalog.ImportProducts(rows, opts, actor(c))
+	if err != nil {
+		respondError(c, err, "Failed to import products")
+		return
+	}
+	if len(summary.Errors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, summary)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// ExportProducts downloads the catalogue as ?format=csv (default) or xlsx in
+// the import layout. opening_stock carries current stock, which re-import
+// leaves alone for existing products.
+func (h *InventoryHandler) ExportProducts(c *gin.Context) {
+	rows, err := h.catalog.ExportRows()
+	if err != nil {
+		respondError(c, err, "Failed to fetch products")
+		return
+	}
+
+	filename := fmt.Sprintf("products-%s", time.Now().Format("20060102"))
+	if c.Query("format") == "xlsx" {
+		f := excelize.NewFile()
+		defer f.Close()
+		sheet := f.GetSheetName(0)
+		for i, row := range rows {
+			cells := make([]interface{}, len(row))
+			for j, v := range row {
+				cells[j] = v // Strings keep barcodes and SKUs from turning into numbers
+			}
+			cell, _ := excelize.CoordinatesToCellName(1, i+1)
+			if err := f.SetSheetRow(sheet, cell, &cells); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build export"})
+				return
+			}
+		}
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.xlsx", filename))
+		f.Write(c.Writer)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", filename))
+	w := csv.NewWriter(c.Writer)
+	w.WriteAll(rows)
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"net/http"
+
+	"billing-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *InventoryHandler) CreateCategory(c *gin.Context) {
+	var req service.CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.catalog.CreateCategory(req)
+	if err != nil {
+		respondError(c, err, "Failed to create category")
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// ListCategories returns all categories in tree order, or nested under their
+// parents with ?tree=true
+func (h *InventoryHandler) ListCategories(c *gin.Context) {
+	categories, err := h.catalog.ListCategories()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+	if c.Query("tree") == "true" {
+		c.JSON(http.StatusOK, service.CategoryTree(categories, nil))
+		return
+	}
+	c.JSON(http.StatusOK, categories)
+}
+
+// UpdateCategory edits a category and can move it, with its subcategories,
+// under another parent
+func (h *InventoryHandler) UpdateCategory(c *gin.Context) {
+	var req service.UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.catalog.UpdateCategory(idParam(c, "id"), req)
+	if err != nil {
+		respondError(c, err, "Failed to update category")
+		return
+	}
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory removes an unused category. One with products, subcategories
+// or stock takes has to be merged into another instead.
+func (h *InventoryHandler) DeleteCategory(c *gin.Context) {
+	if err := h.catalog.DeleteCategory(idParam(c, "id")); err != nil {
+		respondError(c, err, "Failed to delete category")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
+}
+
+// MergeCategory moves a category's products, stock takes and subcategories
+// into another category and deletes it
+func (h *InventoryHandler) MergeCategory(c *gin.Context) {
+	var req service.MergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	merge, err := h.catalog.MergeCategory(idParam(c, "id"), req.IntoID)
+	if err != nil {
+		respondError(c, err, "Failed to merge categories")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Categories merged", "products_moved": merge.ProductsMoved, "subcategories_moved": merge.SubcategoriesMoved})
+}
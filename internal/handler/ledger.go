@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"billing-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListStockMovements returns a product's ledger, optionally limited to from/to (YYYY-MM-DD) and store_id
+func (h *InventoryHandler) ListStockMovements(c *gin.Context) {
+	filter := service.MovementFilter{ProductID: idParam(c, "id")}
+	filter.StoreID, _ = storeIDQuery(c)
+
+	if from := c.Query("from"); from != "" {
+		start, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+			return
+		}
+		filter.From = start
+	}
+	if to := c.Query("to"); to != "" {
+		end, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+			return
+		}
+		filter.To = end.AddDate(0, 0, 1)
+	}
+
+	movements, err := h.inventory.Movements(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stock movements"})
+		return
+	}
+	c.JSON(http.StatusOK, movements)
+}
+
+// GetStockAsOf reconstructs a product's stock at the end of the as_of date (default today) from the ledger,
+// at store_id or across all stores
+func (h *InventoryHandler) GetStockAsOf(c *gin.Context) {
+	asOf := time.Now()
+	if s := c.Query("as_of"); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "as_of must be YYYY-MM-DD"})
+			return
+		}
+		asOf = t
+	}
+	end := time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, asOf.Location()).AddDate(0, 0, 1)
+
+	storeID, _ := storeIDQuery(c)
+	level, err := h.inventory.StockAsOf(idParam(c, "id"), storeID, end)
+	if err != nil {
+		respondError(c, err, "Failed to fetch stock")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"product_id": level.Product.ID,
+		"store_id":   storeID,
+		"name":       level.Product.Name,
+		"as_of":      end.AddDate(0, 0, -1).Format("2006-01-02"),
+		"stock":      level.Stock,
+		"movements":  level.Movements,
+	})
+}
+
+// CheckStockConsistency compares the ledger, batch and store stock sums with CurrentStock for every product
+func (h *InventoryHandler) CheckStockConsistency(c *gin.Context) {
+	check, err := h.inventory.CheckConsistency()
+	if err != nil {
+		respondError(c, err, "Failed to fetch products")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"products_checked": check.ProductsChecked,
+		"consistent":       len(check.Discrepancies) == 0,
+		"discrepancies":    check.Discrepancies,
+	})
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"billing-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pageParams reads ?page (from 1) and ?limit, capping limit at 100
+func pageParams(c *gin.Context, defaultLimit int) (page, limit int) {
+	page, limit = 1, defaultLimit
+	if c.Query("page") != "" {
+		fmt.Sscanf(c.Query("page"), "%d", &page)
+	}
+	if c.Query("limit") != "" {
+		fmt.Sscanf(c.Query("limit"), "%d", &limit)
+	}
+	return max(page, 1), min(max(limit, 1), 100)
+}
+
+// productFilter reads the product list filters:
+//
+//	q                     text in name, SKU, barcode (a variant's too, or an exact pack barcode) or description
+//	brand_id              one brand
+//	category_id           a category and its subcategories
+//	min_price, max_price  unit price range
+//	in_stock=true         products, or parents with a variant, that have stock
+//	sort                  name, price, stock (- for descending), newest or oldest
+func productFilter(c *gin.Context) (service.ProductFilter, error) {
+	filter := service.ProductFilter{
+		Q:       c.Query("q"),
+		InStock: c.Query("in_stock") == "true",
+		Sort:    c.DefaultQuery("sort", "name"),
+	}
+	for param, field := range map[string]**uint{"brand_id": &filter.BrandID, "category_id": &filter.CategoryID} {
+		if v := c.Query(param); v != "" {
+			id, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be a number", param)
+			}
+			uid := uint(id)
+			*field = &uid
+		}
+	}
+	for param, field := range map[string]**float64{"min_price": &filter.MinPrice, "max_price": &filter.MaxPrice} {
+		if v := c.Query(param); v != "" {
+			price, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be a number", param)
+			}
+			*field = &price
+		}
+	}
+	return filter, nil
+}
+
+// listProducts serves the staff and public catalogue: active top-level
+// products with their variants, filtered, sorted and paginated in the same
+// envelope as ListBills
+func listProducts(c *gin.Context, catalog service.CatalogService) {
+	filter, err := productFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page, limit := pageParams(c, 20)
+	filter.Limit, filter.Offset = limit, (page-1)*limit
+
+	products, total, err := catalog.ListProducts(filter)
+	if err != nil {
+		respondError(c, err, "Failed to fetch products")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  products,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
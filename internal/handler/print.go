@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"billing-app/config"
+	"billing-app/internal/invoice"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrintBill renders a bill as an A4 PDF (format=pdf, default) or as raw
+// ESC/POS bytes for a thermal printer (format=escpos&width=58|80)
+func (h *BillingHandler) PrintBill(c *gin.Context) {
+	bill, err := h.billing.GetBill(idParam(c, "id"))
+	if err != nil {
+		respondError(c, err, "Failed to fetch bill")
+		return
+	}
+
+	doc := invoice.NewDocument(bill)
+	var buf bytes.Buffer
+
+	switch c.DefaultQuery("format", "pdf") {
+	case "pdf":
+		if err := invoice.RenderPDF(doc, &buf); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render invoice"})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%s.pdf", bill.BillNo))
+		c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+
+	case "escpos":
+		width := config.AppConfig.Invoice.PaperWidth
+		if w := c.Query("width"); w != "" {
+			width, _ = strconv.Atoi(w)
+		}
+		if err := invoice.RenderESCPOS(doc, width, &buf); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.bin", bill.BillNo))
+		c.Data(http.StatusOK, "application/octet-stream", buf.Bytes())
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be pdf or escpos"})
+	}
+}
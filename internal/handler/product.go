@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"billing-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *InventoryHandler) GetProduct(c *gin.Context) {
+	product, err := h.catalog.GetProduct(idParam(c, "id"))
+	if err != nil {
+		respondError(c, err, "Failed to fetch product")
+		return
+	}
+	c.JSON(http.StatusOK, product)
+}
+
+// UpdateProduct serves both PUT and PATCH. Price changes and (de)activation are
+// restricted to managers; a price change closes the current price history row.
+// Brand, category, supplier, tax and active changes on a parent carry down to its variants.
+func (h *InventoryHandler) UpdateProduct(c *gin.Context) {
+	var req service.UpdateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	product, err := h.catalog.UpdateProduct(idParam(c, "id"), req, actor(c))
+	if err != nil {
+		respondError(c, err, "Failed to update product")
+		return
+	}
+	c.JSON(http.StatusOK, product)
+}
+
+// DeleteProduct soft deletes a product. Bills and the stock ledger keep
+// referring to it, so the row itself is never removed. Deleting a parent
+// deletes its variants.
+func (h *InventoryHandler) DeleteProduct(c *gin.Context) {
+	if err := h.catalog.DeleteProduct(idParam(c, "id")); err != nil {
+		respondError(c, err, "Failed to delete product")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
+}
+
+// GetPriceHistory lists a product's prices, newest first. With ?at=<RFC3339 or
+// YYYY-MM-DD> it returns only the price that applied at that moment.
+func (h *InventoryHandler) GetPriceHistory(c *gin.Context) {
+	productID := idParam(c, "id")
+
+	if at := c.Query("at"); at != "" {
+		t, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			if t, err = time.ParseInLocation("2006-01-02", at, time.Local); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'at', use RFC3339 or YYYY-MM-DD"})
+				return
+			}
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+
+		history, err := h.catalog.PriceAt(productID, t)
+		if err != nil {
+			respondError(c, err, "Failed to fetch price history")
+			return
+		}
+		c.JSON(http.StatusOK, history)
+		return
+	}
+
+	history, err := h.catalog.PriceHistory(productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch price history"})
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
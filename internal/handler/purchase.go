@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"billing-app/internal/models"
+	"billing-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PurchaseHandler struct {
+	purchases service.PurchaseService
+}
+
+func NewPurchaseHandler(purchases service.PurchaseService) *PurchaseHandler {
+	return &PurchaseHandler{purchases: purchases}
+}
+
+func (h *PurchaseHandler) CreateSupplier(c *gin.Context) {
+	var req service.SupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	supplier, err := h.purchases.CreateSupplier(req)
+	if err != nil {
+		respondError(c, err, "Failed to create supplier")
+		return
+	}
+	c.JSON(http.StatusCreated, supplier)
+}
+
+func (h *PurchaseHandler) ListSuppliers(c *gin.Context) {
+	suppliers, err := h.purchases.ListSuppliers(c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suppliers"})
+		return
+	}
+	c.JSON(http.StatusOK, suppliers)
+}
+
+func (h *PurchaseHandler) UpdateSupplier(c *gin.Context) {
+	var req service.SupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.purchases.UpdateSupplier(idParam(c, "id"), req); err != nil {
+		respondError(c, err, "Failed to update supplier")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Supplier updated successfully"})
+}
+
+// CreatePurchaseOrder saves a DRAFT purchase order
+func (h *PurchaseHandler) CreatePurchaseOrder(c *gin.Context) {
+	var req service.PurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	po, err := h.purchases.CreatePurchaseOrder(req, actor(c))
+	if err != nil {
+		respondError(c, err, "Failed to create purchase order")
+		return
+	}
+	c.JSON(http.StatusCreated, po)
+}
+
+// UpdatePurchaseOrder replaces the lines of a DRAFT purchase order
+func (h *PurchaseHandler) UpdatePurchaseOrder(c *gin.Context) {
+	var req service.PurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.purchases.UpdatePurchaseOrder(idParam(c, "id"), req, actor(c)); err != nil {
+		respondError(c, err, "Failed to update purchase order")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Purchase order updated successfully"})
+}
+
+func (h *PurchaseHandler) ListPurchaseOrders(c *gin.Context) {
+	supplierID, _ := strconv.ParseUint(c.Query("supplier_id"), 10, 64)
+	orders, err := h.purchases.ListPurchaseOrders(c.Query("status"), uint(supplierID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch purchase orders"})
+		return
+	}
+	c.JSON(http.StatusOK, orders)
+}
+
+func (h *PurchaseHandler) GetPurchaseOrder(c *gin.Context) {
+	po, receipts, err := h.purchases.GetPurchaseOrder(idParam(c, "id"))
+	if err != nil {
+		respondError(c, err, "Failed to fetch purchase order")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"purchase_order": po, "receipts": receipts})
+}
+
+// PlacePurchaseOrder marks a draft as sent to the supplier
+func (h *PurchaseHandler) PlacePurchaseOrder(c *gin.Context) {
+	if err := h.purchases.PlacePurchaseOrder(idParam(c, "id")); err != nil {
+		respondError(c, err, "Failed to update purchase order")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Purchase order " + models.POStatusOrdered})
+}
+
+func (h *PurchaseHandler) CancelPurchaseOrder(c *gin.Context) {
+	if err := h.purchases.CancelPurchaseOrder(idParam(c, "id")); err != nil {
+		respondError(c, err, "Failed to update purchase order")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Purchase order " + models.POStatusCancelled})
+}
+
+// ReceiveGoods books a goods receipt against an ORDERED or PARTIALLY_RECEIVED purchase order.
+// Each received line adds stock and a StockEntry linked to the PO and supplier.
+func (h *PurchaseHandler) ReceiveGoods(c *gin.Context) {
+	var req service.ReceiveGoodsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := h.purchases.ReceiveGoods(idParam(c, "id"), req, c.GetUint("userID"))
+	if err != nil {
+		respondError(c, err, "Failed to receive goods")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Goods received successfully", "status": status})
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+
+	"billing-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetReorderSuggestions lists what ?store_id (default the user's store) should
+// reorder, grouped per supplier or, with ?group_by=brand, per supplier and brand
+func (h *PurchaseHandler) GetReorderSuggestions(c *gin.Context) {
+	var storeID *uint
+	if id, ok := storeIDQuery(c); ok {
+		storeID = &id
+	}
+
+	report, err := h.purchases.ReorderSuggestions(storeID, c.Query("group_by") == "brand", actor(c))
+	if err != nil {
+		respondError(c, err, "Failed to calculate reorder suggestions")
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// CreateReorderDrafts turns reviewed suggestions into DRAFT purchase orders,
+// one per supplier (and brand with group_by=brand). They are confirmed with
+// the usual purchase order endpoints.
+func (h *PurchaseHandler) CreateReorderDrafts(c *gin.Context) {
+	var req service.CreateReorderDraftsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orders, skipped, err := h.purchases.CreateReorderDrafts(req, actor(c))
+	if err != nil {
+		respondError(c, err, "Failed to create purchase order")
+		return
+	}
+	if len(orders) == 0 {
+		c.JSON(http.StatusOK, gin.H{"message": "Nothing to reorder", "purchase_orders": orders, "skipped": skipped})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"purchase_orders": orders, "skipped": skipped})
+}
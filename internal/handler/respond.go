@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"billing-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errorStatus = map[service.Kind]int{
+	service.KindInvalid:       http.StatusBadRequest,
+	service.KindNotFound:      http.StatusNotFound,
+	service.KindConflict:      http.StatusConflict,
+	service.KindUnprocessable: http.StatusUnprocessableEntity,
+	service.KindUnauthorized:  http.StatusUnauthorized,
+	service.KindForbidden:     http.StatusForbidden,
+	service.KindFailed:        http.StatusInternalServerError,
+}
+
+// respondError writes a service error with its status and details; anything
+// else is a 500 with the fallback message
+func respondError(c *gin.Context, err error, fallback string) {
+	var e *service.Error
+	if !errors.As(err, &e) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+		return
+	}
+	body := gin.H{"error": e.Message}
+	for k, v := range e.Details {
+		body[k] = v
+	}
+	status, ok := errorStatus[e.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	c.JSON(status, body)
+}
+
+// idParam reads a numeric path parameter; 0 means it was missing or malformed
+// and matches no record
+func idParam(c *gin.Context, name string) uint {
+	id, _ := strconv.ParseUint(c.Param(name), 10, 64)
+	return uint(id)
+}
+
+// actor is the signed-in user, as set by AuthMiddleware
+func actor(c *gin.Context) service.Actor {
+	return service.Actor{UserID: c.GetUint("userID"), Role: c.GetString("role")}
+}
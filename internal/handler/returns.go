@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+
+	"billing-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CancelBill voids a bill in full, restoring all outstanding quantities to stock
+func (h *BillingHandler) CancelBill(c *gin.Context) {
+	var req service.CancelBillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	note, err := h.billing.CancelBill(idParam(c, "id"), req, c.GetUint("userID"))
+	if err != nil {
+		respondError(c, err, "Failed to issue credit note")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bill cancelled successfully", "credit_note": note})
+}
+
+// ReturnBillItems takes back part of a bill; the bill stays PAID with a reduced revenue
+func (h *BillingHandler) ReturnBillItems(c *gin.Context) {
+	var req service.ReturnBillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	note, err := h.billing.ReturnItems(idParam(c, "id"), req, c.GetUint("userID"))
+	if err != nil {
+		respondError(c, err, "Failed to issue credit note")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Return recorded successfully", "credit_note": note})
+}
+
+func (h *BillingHandler) ListCreditNotes(c *gin.Context) {
+	notes, err := h.billing.CreditNotes(idParam(c, "id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch credit notes"})
+		return
+	}
+	c.JSON(http.StatusOK, notes)
+}
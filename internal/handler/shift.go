@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"billing-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ShiftHandler struct {
+	shifts service.ShiftService
+}
+
+func NewShiftHandler(shifts service.ShiftService) *ShiftHandler {
+	return &ShiftHandler{shifts: shifts}
+}
+
+func (h *ShiftHandler) OpenShift(c *gin.Context) {
+	var req struct {
+		OpeningFloat float64 `json:"opening_float" binding:"gte=0"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	shift, err := h.shifts.OpenShift(c.GetUint("userID"), req.OpeningFloat)
+	if err != nil {
+		respondError(c, err, "Failed to open shift")
+		return
+	}
+	c.JSON(http.StatusCreated, shift)
+}
+
+func (h *ShiftHandler) GetCurrentShift(c *gin.Context) {
+	shift, report, err := h.shifts.CurrentShift(c.GetUint("userID"))
+	if err != nil {
+		respondError(c, err, "Failed to fetch shift")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"shift": shift, "report": report})
+}
+
+func (h *ShiftHandler) AddCashMovement(c *gin.Context) {
+	var req service.CashMovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	movement, err := h.shifts.AddCashMovement(c.GetUint("userID"), req)
+	if err != nil {
+		respondError(c, err, "Failed to record cash movement")
+		return
+	}
+	c.JSON(http.StatusCreated, movement)
+}
+
+// CloseShift takes the counted drawer cash and freezes the Z report
+func (h *ShiftHandler) CloseShift(c *gin.Context) {
+	var req service.CloseShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.shifts.CloseShift(c.GetUint("userID"), req)
+	if err != nil {
+		respondError(c, err, "Failed to close shift")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Shift closed", "report": report})
+}
+
+func (h *ShiftHandler) ListShifts(c *gin.Context) {
+	userID, _ := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	shifts, err := h.shifts.ListShifts(c.Query("status"), uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shifts"})
+		return
+	}
+	c.JSON(http.StatusOK, shifts)
+}
+
+func (h *ShiftHandler) GetShift(c *gin.Context) {
+	shift, report, err := h.shifts.GetShift(idParam(c, "id"))
+	if err != nil {
+		respondError(c, err, "Failed to fetch shift")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"shift": shift, "report": report})
+}
+
+// ApproveShift signs off a closed shift's variance
+func (h *ShiftHandler) ApproveShift(c *gin.Context) {
+	var req struct {
+		Note string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.shifts.ApproveShift(idParam(c, "id"), c.GetUint("userID"), req.Note); err != nil {
+		respondError(c, err, "Failed to approve shift")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Shift approved"})
+}
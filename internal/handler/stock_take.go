@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"net/http"
+
+	"billing-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StockTakeHandler struct {
+	stockTakes service.StockTakeService
+	inventory  service.InventoryService
+}
+
+func NewStockTakeHandler(stockTakes service.StockTakeService, inventory service.InventoryService) *StockTakeHandler {
+	return &StockTakeHandler{stockTakes: stockTakes, inventory: inventory}
+}
+
+// AdjustStock posts a one-off correction (damage, theft, count error) to the ledger
+func (h *StockTakeHandler) AdjustStock(c *gin.Context) {
+	var req service.AdjustStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	movement, err := h.inventory.AdjustStock(req, actor(c))
+	if err != nil {
+		respondError(c, err, "Failed to post stock adjustment")
+		return
+	}
+
+	c.JSON(http.StatusOK, movement)
+}
+
+// CreateStockTake opens a count sheet with every active product in scope
+func (h *StockTakeHandler) CreateStockTake(c *gin.Context) {
+	var req service.CreateStockTakeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	take, err := h.stockTakes.CreateStockTake(req, actor(c))
+	if err != nil {
+		respondError(c, err, "Failed to create stock take")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": take.ID, "take_no": take.TakeNo, "items": len(take.Items)})
+}
+
+func (h *StockTakeHandler) ListStockTakes(c *gin.Context) {
+	storeID, _ := storeIDQuery(c)
+	takes, err := h.stockTakes.ListStockTakes(storeID, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stock takes"})
+		return
+	}
+	c.JSON(http.StatusOK, takes)
+}
+
+// GetStockTake returns the sheet with variances against the current stock for review
+func (h *StockTakeHandler) GetStockTake(c *gin.Context) {
+	sheet, err := h.stockTakes.GetStockTake(idParam(c, "id"))
+	if err != nil {
+		respondError(c, err, "Failed to fetch stock take")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stock_take": sheet.Take,
+		"lines":      sheet.Lines,
+		"summary": gin.H{
+			"products":      len(sheet.Lines),
+			"counted":       sheet.Counted,
+			"with_variance": sheet.WithVariance,
+		},
+	})
+}
+
+// RecordCounts enters counted quantities on an OPEN sheet, by product ID or barcode scan
+func (h *StockTakeHandler) RecordCounts(c *gin.Context) {
+	var req service.RecordCountsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.stockTakes.RecordCounts(idParam(c, "id"), req, c.GetUint("userID")); err != nil {
+		respondError(c, err, "Failed to record count")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Counts recorded"})
+}
+
+// SubmitStockTake closes counting and hands the sheet to a manager for review
+func (h *StockTakeHandler) SubmitStockTake(c *gin.Context) {
+	if err := h.stockTakes.SubmitStockTake(idParam(c, "id")); err != nil {
+		respondError(c, err, "Failed to submit stock take")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Stock take submitted for approval"})
+}
+
+func (h *StockTakeHandler) CancelStockTake(c *gin.Context) {
+	if err := h.stockTakes.CancelStockTake(idParam(c, "id")); err != nil {
+		respondError(c, err, "Failed to cancel stock take")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Stock take cancelled"})
+}
+
+// ApproveStockTake posts the variance of every counted product as an
+// adjustment with its reason code. Uncounted lines are left alone.
+func (h *StockTakeHandler) ApproveStockTake(c *gin.Context) {
+	var req service.ApproveStockTakeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adjusted, err := h.stockTakes.ApproveStockTake(idParam(c, "id"), req, c.GetUint("userID"))
+	if err != nil {
+		respondError(c, err, "Failed to approve stock take")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Stock take approved", "products_adjusted": adjusted})
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"billing-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StoreHandler struct {
+	stores service.StoreService
+}
+
+func NewStoreHandler(stores service.StoreService) *StoreHandler {
+	return &StoreHandler{stores: stores}
+}
+
+func storeIDQuery(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Query("store_id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (h *StoreHandler) CreateStore(c *gin.Context) {
+	var req service.StoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	store, err := h.stores.CreateStore(req)
+	if err != nil {
+		respondError(c, err, "Failed to create store")
+		return
+	}
+
+	c.JSON(http.StatusCreated, store)
+}
+
+func (h *StoreHandler) UpdateStore(c *gin.Context) {
+	var req service.StoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.stores.UpdateStore(idParam(c, "id"), req); err != nil {
+		respondError(c, err, "Failed to update store")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Store updated successfully"})
+}
+
+func (h *StoreHandler) ListStores(c *gin.Context) {
+	stores, err := h.stores.ListStores(c.Query("all") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stores"})
+		return
+	}
+	c.JSON(http.StatusOK, stores)
+}
+
+// ListStoreStock shows stock per store, optionally for one product or store
+func (h *StoreHandler) ListStoreStock(c *gin.Context) {
+	productID, _ := strconv.ParseUint(c.Query("product_id"), 10, 64)
+	storeID, _ := storeIDQuery(c)
+	rows, err := h.stores.StoreStock(uint(productID), storeID, c.Query("include_zero") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch store stock"})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
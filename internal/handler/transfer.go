@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+
+	"billing-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TransferHandler struct {
+	transfers service.TransferService
+}
+
+func NewTransferHandler(transfers service.TransferService) *TransferHandler {
+	return &TransferHandler{transfers: transfers}
+}
+
+// CreateTransfer dispatches stock from one store to another. The stock leaves
+// the source now and stays IN_TRANSIT until the destination receives it.
+func (h *TransferHandler) CreateTransfer(c *gin.Context) {
+	var req service.CreateTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transfer, err := h.transfers.CreateTransfer(req, actor(c))
+	if err != nil {
+		respondError(c, err, "Failed to create transfer")
+		return
+	}
+	c.JSON(http.StatusCreated, transfer)
+}
+
+func (h *TransferHandler) ListTransfers(c *gin.Context) {
+	storeID, _ := storeIDQuery(c)
+	transfers, err := h.transfers.ListTransfers(c.Query("status"), storeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transfers"})
+		return
+	}
+	c.JSON(http.StatusOK, transfers)
+}
+
+func (h *TransferHandler) GetTransfer(c *gin.Context) {
+	transfer, err := h.transfers.GetTransfer(idParam(c, "id"))
+	if err != nil {
+		respondError(c, err, "Failed to fetch transfer")
+		return
+	}
+	c.JSON(http.StatusOK, transfer)
+}
+
+// ReceiveTransfer books the transfer into the destination store. Lines can be
+// received short; the shortfall stays recorded on the line as lost in transit.
+func (h *TransferHandler) ReceiveTransfer(c *gin.Context) {
+	var req service.ReceiveTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	short, err := h.transfers.ReceiveTransfer(idParam(c, "id"), req, actor(c))
+	if err != nil {
+		respondError(c, err, "Failed to update transfer")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Transfer received", "short_quantity": short})
+}
+
+// CancelTransfer returns an in-transit transfer to its source store
+func (h *TransferHandler) CancelTransfer(c *gin.Context) {
+	if err := h.transfers.CancelTransfer(idParam(c, "id"), actor(c)); err != nil {
+		respondError(c, err, "Failed to update transfer")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Transfer cancelled"})
+}
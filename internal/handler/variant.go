@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+
+	"billing-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddVariant adds a size/colour to a parent product. A plain product can become
+// a parent only while it has no stock history of its own.
+func (h *InventoryHandler) AddVariant(c *gin.Context) {
+	var req service.CreateVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	variant, err := h.inventory.AddVariant(idParam(c, "id"), req, c.GetUint("userID"))
+	if err != nil {
+		respondError(c, err, "Failed to create product")
+		return
+	}
+
+	c.JSON(http.StatusCreated, variant)
+}
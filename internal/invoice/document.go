@@ -0,0 +1,158 @@
+package invoice
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+
+	"billing-app/config"
+	"billing-app/internal/models"
+	"billing-app/internal/pricing"
+)
+
+// Company is the seller block printed on every invoice
+type Company struct {
+	Name    string
+	Address string
+	Phone   string
+	Email   string
+	GSTIN   string
+	State   string
+}
+
+// TaxRow is one GST rate line of the invoice tax summary
+type TaxRow struct {
+	Rate         float64
+	TaxableValue float64
+	CGSTAmount   float64
+	SGSTAmount   float64
+	IGSTAmount   float64
+}
+
+// Document is everything an invoice template can print.
+// Bill must be loaded with Items.Product, Payments, Customer, User and Store.
+type Document struct {
+	Title     string
+	Company   Company
+	Bill      models.Bill
+	TaxRows   []TaxRow
+	QRPayload string
+	Footer    string
+}
+
+// NewDocument builds the invoice view of a bill from the configured company details
+func NewDocument(bill models.Bill) Document {
+	defaults := config.AppConfig.Defaults
+	site := config.AppConfig.Site
+
+	company := Company{
+		Name:    defaults.CompanyName,
+		Address: defaults.CompanyAddress,
+		Phone:   defaults.CompanyPhone,
+		Email:   site.Email,
+		GSTIN:   config.AppConfig.Billing.GSTIN,
+		State:   config.AppConfig.Billing.StoreState,
+	}
+	// A bill from a branch prints the branch's own details
+	if s := bill.Store; s != nil {
+		if s.Address != "" {
+			company.Address = s.Address
+		}
+		if s.Phone != "" {
+			company.Phone = s.Phone
+		}
+		if s.GSTIN != "" {
+			company.GSTIN = s.GSTIN
+		}
+		if s.State != "" {
+			company.State = s.State
+		}
+		if !s.IsDefault {
+			company.Name = fmt.Sprintf("%s - %s", company.Name, s.Name)
+		}
+	}
+	if company.Name == "" {
+		company.Name = site.Name
+	}
+	if company.Address == "" {
+		company.Address = site.Address
+	}
+	if company.Phone == "" {
+		company.Phone = site.Phone
+	}
+
+	title := "TAX INVOICE"
+	if bill.Status == "CANCELLED" {
+		title = "TAX INVOICE (CANCELLED)"
+	}
+
+	return Document{
+		Title:     title,
+		Company:   company,
+		Bill:      bill,
+		TaxRows:   taxRows(bill.Items),
+		QRPayload: qrPayload(company, bill),
+		Footer:    config.AppConfig.Invoice.Footer,
+	}
+}
+
+// PaymentLabel describes how the bill was paid, e.g. "CASH 200.00 + UPI 250.00"
+func (d Document) PaymentLabel() string {
+	if len(d.Bill.Payments) == 0 {
+		return d.Bill.PaymentMode
+	}
+	label := ""
+	for i, p := range d.Bill.Payments {
+		if i > 0 {
+			label += " + "
+		}
+		label += fmt.Sprintf("%s %.2f", p.TenderType, p.Amount)
+	}
+	return label
+}
+
+// Change is the total change handed back on cash tenders
+func (d Document) Change() float64 {
+	var change float64
+	for _, p := range d.Bill.Payments {
+		change += p.ChangeReturned
+	}
+	return pricing.Round2(change)
+}
+
+func taxRows(items []models.BillItem) []TaxRow {
+	byRate := map[float64]*TaxRow{}
+	for _, item := range items {
+		row, ok := byRate[item.GSTRate]
+		if !ok {
+			row = &TaxRow{Rate: item.GSTRate}
+			byRate[item.GSTRate] = row
+		}
+		row.TaxableValue = pricing.Round2(row.TaxableValue + item.TaxableValue)
+		row.CGSTAmount = pricing.Round2(row.CGSTAmount + item.CGSTAmount)
+		row.SGSTAmount = pricing.Round2(row.SGSTAmount + item.SGSTAmount)
+		row.IGSTAmount = pricing.Round2(row.IGSTAmount + item.IGSTAmount)
+	}
+
+	rows := make([]TaxRow, 0, len(byRate))
+	for _, row := range byRate {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Rate < rows[j].Rate })
+	return rows
+}
+
+// qrPayload is a UPI payment link when a UPI ID is configured,
+// otherwise a compact bill verification string
+func qrPayload(company Company, bill models.Bill) string {
+	if upiID := config.AppConfig.Invoice.UPIID; upiID != "" {
+		q := url.Values{}
+		q.Set("pa", upiID)
+		q.Set("pn", company.Name)
+		q.Set("am", fmt.Sprintf("%.2f", bill.NetPayable))
+		q.Set("tn", bill.BillNo)
+		q.Set("cu", "INR")
+		return "upi://pay?" + q.Encode()
+	}
+	return fmt.Sprintf("BILL:%s|DATE:%s|AMT:%.2f|GSTIN:%s", bill.BillNo, bill.BillDate.Format("2006-01-02"), bill.NetPayable, company.GSTIN)
+}
@@ -0,0 +1,151 @@
+package invoice
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"billing-app/config"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Characters per line in font A for the supported paper widths
+var paperColumns = map[int]int{
+	58: 32,
+	80: 48,
+}
+
+// ESC/POS command bytes
+const (
+	esc = "\x1b"
+	gs  = "\x1d"
+)
+
+// RenderESCPOS writes the bill as raw ESC/POS bytes for a 58 or 80mm thermal printer.
+// The layout comes from thermal.tmpl, which can be overridden in the configured template directory.
+func RenderESCPOS(doc Document, paperMM int, w io.Writer) error {
+	columns, ok := paperColumns[paperMM]
+	if !ok {
+		return fmt.Errorf("unsupported paper width %dmm", paperMM)
+	}
+
+	tmpl, err := loadTemplate("thermal.tmpl", escposFuncs(columns))
+	if err != nil {
+		return err
+	}
+
+	// Thermal code pages are ASCII only, so a Tamil trade name falls back to the site name
+	if strings.TrimSpace(printable(doc.Company.Name)) == "" && config.AppConfig.Site.Name != "" {
+		doc.Company.Name = config.AppConfig.Site.Name
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, doc); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func loadTemplate(name string, funcs template.FuncMap) (*template.Template, error) {
+	tmpl := template.New(name).Funcs(funcs)
+	if dir := config.AppConfig.Invoice.TemplateDir; dir != "" {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return tmpl.ParseFiles(path)
+		}
+	}
+	return tmpl.ParseFS(templateFS, "templates/"+name)
+}
+
+// escposFuncs are the template helpers; they emit printer commands and
+// pad text to the paper's column count
+func escposFuncs(columns int) template.FuncMap {
+	line := func(l, r string) string {
+		l, r = printable(l), printable(r)
+		gap := columns - len(l) - len(r)
+		if gap < 1 {
+			// Left text wraps onto its own line when both sides do not fit
+			return l + "\n" + strings.Repeat(" ", max(columns-len(r), 0)) + r
+		}
+		return l + strings.Repeat(" ", gap) + r
+	}
+
+	return template.FuncMap{
+		"init":   func() string { return esc + "@" },
+		"left":   func() string { return esc + "a\x00" },
+		"center": func() string { return esc + "a\x01" },
+		"right":  func() string { return esc + "a\x02" },
+		"bold":   func(s string) string { return esc + "E\x01" + printable(s) + esc + "E\x00" },
+		"text":   printable,
+		"rule":   func() string { return strings.Repeat("-", columns) },
+		"line":   line,
+		"row":    line,
+		"wrap":   func(s string) string { return strings.Join(wrapText(printable(s), columns), "\n") },
+		"money":  func(v float64) string { return fmt.Sprintf("%.2f", v) },
+		"date":   func(t time.Time) string { return t.Format("02-01-2006 03:04 PM") },
+		"feed":   func(n int) string { return esc + "d" + string(rune(n)) },
+		"cut":    func() string { return gs + "V\x42\x00" },
+		"qr":     qrCommand,
+	}
+}
+
+// qrCommand prints payload as a model 2 QR code using GS ( k
+func qrCommand(payload string) string {
+	data := printable(payload)
+	size := len(data) + 3
+	var b strings.Builder
+	b.WriteString(gs + "(k\x04\x00\x31\x41\x32\x00") // Model 2
+	b.WriteString(gs + "(k\x03\x00\x31\x43\x06")     // Module size 6
+	b.WriteString(gs + "(k\x03\x00\x31\x45\x31")     // Error correction M
+	b.WriteString(gs + "(k" + string([]byte{byte(size % 256), byte(size / 256)}) + "\x31\x50\x30" + data)
+	b.WriteString(gs + "(k\x03\x00\x31\x51\x30") // Print
+	return b.String() + "\n"
+}
+
+// printable drops characters thermal printer code pages cannot show
+func printable(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || (r < ' ' && r != '\n') {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func wrapText(s string, columns int) []string {
+	var lines []string
+	var current string
+	for _, word := range strings.Fields(s) {
+		for len(word) > columns {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			lines = append(lines, word[:columns])
+			word = word[columns:]
+		}
+		switch {
+		case current == "":
+			current = word
+		case len(current)+1+len(word) <= columns:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
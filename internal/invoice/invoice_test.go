@@ -0,0 +1,144 @@
+package invoice
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"billing-app/config"
+	"billing-app/internal/models"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// billDate is the fixed clock for every rendered bill, so the golden files
+// do not change with the day the tests run
+var billDate = time.Date(2025, time.March, 14, 18, 5, 0, 0, time.UTC)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		Site: models.SiteInfo{Name: "Test Mart"},
+		Defaults: config.DefaultsConfig{
+			CompanyName:    "Test Mart Retail",
+			CompanyAddress: "12 Anna Salai, Chennai 600002",
+			CompanyPhone:   "044-2345678",
+		},
+		Billing: config.BillingConfig{GSTIN: "33ABCDE1234F1Z5", StoreState: "Tamil Nadu"},
+		Invoice: config.InvoiceConfig{Footer: "Thank you for shopping with us!", PaperWidth: 80},
+	}
+}
+
+func testBill() models.Bill {
+	customerID := uint(7)
+	return models.Bill{
+		ID:             42,
+		BillNo:         "B-2425-000042",
+		BillDate:       billDate,
+		CustomerID:     &customerID,
+		Customer:       &models.Customer{ID: customerID, Name: "Priya Raman", Mobile: "9876543210", Address: "4 Lake View Road, Chennai"},
+		User:           models.User{Username: "Biller One"},
+		Store:          &models.Store{Name: "Main", State: "Tamil Nadu", IsDefault: true},
+		TotalAmount:    1350,
+		DiscountAmount: 50,
+		GSTAmount:      125.38,
+		CGSTAmount:     62.69,
+		SGSTAmount:     62.69,
+		PlaceOfSupply:  "Tamil Nadu",
+		RoundOff:       0.38,
+		NetPayable:     1300,
+		PaymentMode:    "SPLIT",
+		Status:         "PAID",
+		Items: []models.BillItem{
+			{
+				Product: models.Product{Name: "Basmati Rice 5kg Premium Long Grain"}, Quantity: 2, UnitPrice: 450, Total: 900,
+				HSNCode: "1006", DiscountAmount: 33.33, TaxableValue: 825.4, GSTRate: 5, CGSTAmount: 20.64, SGSTAmount: 20.64,
+			},
+			{
+				Product: models.Product{Name: "Steel Tumbler"}, Quantity: 3, UnitPrice: 150, Total: 450,
+				HSNCode: "7323", DiscountAmount: 16.67, TaxableValue: 366.95, GSTRate: 18, CGSTAmount: 33.03, SGSTAmount: 33.03,
+			},
+		},
+		Payments: []models.BillPayment{
+			{TenderType: "CASH", Amount: 800, Tendered: 1000, ChangeReturned: 200},
+			{TenderType: "UPI", Amount: 500, Tendered: 500, ReferenceNo: "UPI123456"},
+		},
+	}
+}
+
+// golden compares got with testdata/name, rewriting it under -update
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file (%d bytes, want %d); run go test -update if the change is intended", name, len(got), len(want))
+	}
+}
+
+func TestRenderESCPOS(t *testing.T) {
+	config.AppConfig = testConfig()
+
+	tests := []struct {
+		name   string
+		paper  int
+		golden string
+	}{
+		{"58mm", 58, "receipt_58mm.golden"},
+		{"80mm", 80, "receipt_80mm.golden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := RenderESCPOS(NewDocument(testBill()), tt.paper, &buf); err != nil {
+				t.Fatal(err)
+			}
+			golden(t, tt.golden, buf.Bytes())
+		})
+	}
+}
+
+func TestRenderESCPOSRejectsPaperWidth(t *testing.T) {
+	config.AppConfig = testConfig()
+	if err := RenderESCPOS(NewDocument(testBill()), 76, &bytes.Buffer{}); err == nil {
+		t.Error("expected an error for 76mm paper")
+	}
+}
+
+func TestRenderESCPOSTemplateOverride(t *testing.T) {
+	config.AppConfig = testConfig()
+	dir := t.TempDir()
+	config.AppConfig.Invoice.TemplateDir = dir
+	if err := os.WriteFile(filepath.Join(dir, "thermal.tmpl"), []byte(`{{ row .Bill.BillNo (money .Bill.NetPayable) }}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := RenderESCPOS(NewDocument(testBill()), 58, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "B-2425-000042            1300.00"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderPDF(t *testing.T) {
+	config.AppConfig = testConfig()
+
+	var buf bytes.Buffer
+	if err := RenderPDF(NewDocument(testBill()), &buf); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "invoice_pdf.golden", buf.Bytes())
+}
@@ -0,0 +1,185 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"billing-app/config"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/skip2/go-qrcode"
+)
+
+const pdfFont = "invoice"
+
+// RenderPDF writes the bill as an A4 tax invoice. Unlike the thermal receipt,
+// its layout is fixed here and cannot be overridden from the template directory.
+func RenderPDF(doc Document, w io.Writer) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	// Fixed dates keep the output byte-for-byte reproducible for a given bill
+	pdf.SetCreationDate(doc.Bill.BillDate)
+	pdf.SetModificationDate(doc.Bill.BillDate)
+	pdf.SetCatalogSort(true)
+
+	// Core fonts only cover Latin-1; a TTF font is needed for Tamil or the rupee sign
+	family := "Helvetica"
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	if fontPath := config.AppConfig.Invoice.FontPath; fontPath != "" {
+		pdf.AddUTF8Font(pdfFont, "", fontPath)
+		pdf.AddUTF8Font(pdfFont, "B", fontPath)
+		family = pdfFont
+		tr = func(s string) string { return s }
+	}
+	company := doc.Company
+	if family != pdfFont && !isLatin1(company.Name) && config.AppConfig.Site.Name != "" {
+		company.Name = config.AppConfig.Site.Name
+	}
+
+	pdf.AddPage()
+	pageWidth, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	width := pageWidth - left - right
+	money := func(v float64) string { return fmt.Sprintf("%.2f", v) }
+
+	// Seller
+	pdf.SetFont(family, "B", 16)
+	pdf.CellFormat(width, 8, tr(company.Name), "", 1, "C", false, 0, "")
+	pdf.SetFont(family, "", 9)
+	pdf.MultiCell(width, 4.5, tr(company.Address), "", "C", false)
+	contact := "Phone: " + company.Phone
+	if company.Email != "" {
+		contact += "  |  " + company.Email
+	}
+	pdf.CellFormat(width, 4.5, tr(contact), "", 1, "C", false, 0, "")
+	if company.GSTIN != "" {
+		pdf.CellFormat(width, 4.5, tr("GSTIN: "+company.GSTIN), "", 1, "C", false, 0, "")
+	}
+	pdf.Ln(3)
+
+	pdf.SetFont(family, "B", 12)
+	pdf.CellFormat(width, 7, tr(doc.Title), "TB", 1, "C", false, 0, "")
+	pdf.Ln(2)
+
+	// Bill and buyer
+	bill := doc.Bill
+	pdf.SetFont(family, "", 9)
+	pdf.CellFormat(width/2, 5, tr("Bill No: "+bill.BillNo), "", 0, "L", false, 0, "")
+	pdf.CellFormat(width/2, 5, tr("Date: "+bill.BillDate.Format("02-01-2006 03:04 PM")), "", 1, "R", false, 0, "")
+	pdf.CellFormat(width/2, 5, tr("Billed by: "+bill.User.Username), "", 0, "L", false, 0, "")
+	pdf.CellFormat(width/2, 5, tr("Place of supply: "+bill.PlaceOfSupply), "", 1, "R", false, 0, "")
+	if bill.Customer != nil {
+		pdf.CellFormat(width, 5, tr(fmt.Sprintf("Customer: %s (%s)", bill.Customer.Name, bill.Customer.Mobile)), "", 1, "L", false, 0, "")
+		if bill.Customer.Address != "" {
+			pdf.MultiCell(width, 5, tr(bill.Customer.Address), "", "L", false)
+		}
+	}
+	pdf.Ln(2)
+
+	// Line items
+	cols := []struct {
+		title string
+		w     float64
+		align string
+	}{
+		{"#", 8, "C"}, {"Item", 62, "L"}, {"HSN", 18, "C"}, {"Qty", 12, "R"},
+		{"Rate", 20, "R"}, {"Disc", 18, "R"}, {"GST%", 12, "R"}, {"Taxable", width - 150, "R"},
+	}
+	pdf.SetFont(family, "B", 9)
+	pdf.SetFillColor(235, 235, 235)
+	for _, col := range cols {
+		pdf.CellFormat(col.w, 6, col.title, "1", 0, col.align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont(family, "", 9)
+	for i, item := range bill.Items {
+		values := []string{
+			fmt.Sprint(i + 1), item.Product.Name, item.HSNCode, fmt.Sprint(item.Quantity),
+			money(item.UnitPrice), money(item.DiscountAmount), fmt.Sprintf("%g", item.GSTRate), money(item.TaxableValue),
+		}
+		for j, col := range cols {
+			pdf.CellFormat(col.w, 6, tr(values[j]), "1", 0, col.align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.Ln(3)
+
+	// Tax summary and totals side by side
+	y := pdf.GetY()
+	pdf.SetFont(family, "B", 8)
+	taxCols := []string{"GST%", "Taxable", "CGST", "SGST", "IGST"}
+	for _, t := range taxCols {
+		pdf.CellFormat(18, 5, t, "1", 0, "R", true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont(family, "", 8)
+	for _, row := range doc.TaxRows {
+		for _, v := range []string{fmt.Sprintf("%g", row.Rate), money(row.TaxableValue), money(row.CGSTAmount), money(row.SGSTAmount), money(row.IGSTAmount)} {
+			pdf.CellFormat(18, 5, v, "1", 0, "R", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	afterTax := pdf.GetY()
+
+	totals := [][2]string{
+		{"Gross Amount", money(bill.TotalAmount)},
+		{"Discount", "-" + money(bill.DiscountAmount)},
+		{"CGST", money(bill.CGSTAmount)},
+		{"SGST", money(bill.SGSTAmount)},
+		{"IGST", money(bill.IGSTAmount)},
+		{"Round Off", money(bill.RoundOff)},
+	}
+	pdf.SetXY(left+width-70, y)
+	pdf.SetFont(family, "", 9)
+	for _, t := range totals {
+		pdf.SetX(left + width - 70)
+		pdf.CellFormat(40, 5, t[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(30, 5, t[1], "", 1, "R", false, 0, "")
+	}
+	pdf.SetX(left + width - 70)
+	pdf.SetFont(family, "B", 11)
+	pdf.CellFormat(40, 7, "Net Payable", "T", 0, "L", false, 0, "")
+	pdf.CellFormat(30, 7, tr("Rs. "+money(bill.NetPayable)), "T", 1, "R", false, 0, "")
+	pdf.SetFont(family, "", 9)
+	pdf.SetX(left + width - 70)
+	pdf.MultiCell(70, 5, tr("Paid: "+doc.PaymentLabel()), "", "L", false)
+	if change := doc.Change(); change > 0 {
+		pdf.SetX(left + width - 70)
+		pdf.CellFormat(70, 5, "Change: "+money(change), "", 1, "L", false, 0, "")
+	}
+	if pdf.GetY() < afterTax {
+		pdf.SetY(afterTax)
+	}
+	pdf.Ln(4)
+
+	// QR code
+	png, err := qrcode.Encode(doc.QRPayload, qrcode.Medium, 256)
+	if err != nil {
+		return err
+	}
+	pdf.RegisterImageOptionsReader("qr", fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(png))
+	pdf.ImageOptions("qr", left, pdf.GetY(), 30, 30, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	pdf.SetY(pdf.GetY() + 32)
+
+	if doc.Footer != "" {
+		pdf.SetFont(family, "", 9)
+		pdf.MultiCell(width, 5, tr(doc.Footer), "", "C", false)
+	}
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
+
+func isLatin1(s string) bool {
+	for _, r := range s {
+		if r > 0xFF {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+)
+
+// UnbatchedBatchNo collects stock of a batch-tracked product that arrived
+// without a batch (opening stock, returns of old bills, count surpluses)
+const UnbatchedBatchNo = "UNBATCHED"
+
+// StockBatch is the stock of one product received at one store under one batch
+// number. For products with TrackBatches the batches' QuantityRemaining at a
+// store sum to its StoreStock.
+type StockBatch struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	StoreID           uint       `gorm:"uniqueIndex:idx_batch_store_product_no" json:"store_id"`
+	Store             Store      `gorm:"foreignKey:StoreID" json:"store,omitempty"`
+	ProductID         uint       `gorm:"uniqueIndex:idx_batch_store_product_no;index:idx_batch_product_expiry" json:"product_id"`
+	Product           Product    `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	BatchNo           string     `gorm:"size:50;not null;uniqueIndex:idx_batch_store_product_no" json:"batch_no"`
+	MfgDate           *time.Time `gorm:"type:date" json:"mfg_date"`
+	ExpiryDate        *time.Time `gorm:"type:date;index:idx_batch_product_expiry" json:"expiry_date"`
+	CostPrice         float64    `gorm:"type:decimal(10,2);default:0.00" json:"cost_price"`
+	QuantityReceived  int        `gorm:"default:0" json:"quantity_received"`
+	QuantityRemaining int        `gorm:"default:0" json:"quantity_remaining"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// IsExpired reports whether the batch is past its expiry date on the given day
+func (b StockBatch) IsExpired(now time.Time) bool {
+	if b.ExpiryDate == nil {
+		return false
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return b.ExpiryDate.Before(today)
+}
+
+// BatchAllocation is the part of a stock movement taken from or put into one batch
+type BatchAllocation struct {
+	BatchID    uint       `json:"batch_id"`
+	BatchNo    string     `json:"batch_no"`
+	ExpiryDate *time.Time `json:"expiry_date"`
+	Quantity   int        `json:"quantity"` // Signed like the movement
+}
+
+// BillItemBatch records which batches a bill line was sold from, so returns go
+// back to the same batches
+type BillItemBatch struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	BillItemID  uint       `gorm:"index" json:"bill_item_id"`
+	BatchID     uint       `json:"batch_id"`
+	Batch       StockBatch `gorm:"foreignKey:BatchID" json:"batch"`
+	Quantity    int        `json:"quantity"`
+	ReturnedQty int        `gorm:"default:0" json:"returned_qty"`
+}
@@ -5,29 +5,67 @@ import (
 )
 
 type Bill struct {
-	ID             uint       `gorm:"primaryKey" json:"id"`
-	BillNo         string     `gorm:"size:50;unique;not null" json:"bill_no"`
-	OrderNo        string     `gorm:"size:50" json:"order_no"` // Optional reference
-	BillDate       time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"bill_date"`
-	CustomerID     *uint      `json:"customer_id"` // Nullable
-	Customer       *Customer  `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
-	UserID         uint       `json:"user_id"`
-	User           User       `gorm:"foreignKey:UserID" json:"user"`
-	TotalAmount    float64    `gorm:"type:decimal(10,2);not null" json:"total_amount"`
-	DiscountAmount float64    `gorm:"type:decimal(10,2);default:0.00" json:"discount_amount"`
-	GSTAmount      float64    `gorm:"type:decimal(10,2);default:0.00" json:"gst_amount"`
-	NetPayable     float64    `gorm:"type:decimal(10,2);not null" json:"net_payable"`
-	PaymentMode    string     `gorm:"type:enum('CASH', 'ONLINE', 'CARD');default:'CASH'" json:"payment_mode"`
-	Status         string     `gorm:"type:enum('PAID', 'CANCELLED');default:'PAID'" json:"status"`
-	Items          []BillItem `gorm:"foreignKey:BillID" json:"items"`
+	ID             uint          `gorm:"primaryKey" json:"id"`
+	BillNo         string        `gorm:"size:50;unique;not null" json:"bill_no"`
+	OrderNo        string        `gorm:"size:50" json:"order_no"` // Optional reference
+	BillDate       time.Time     `gorm:"default:CURRENT_TIMESTAMP" json:"bill_date"`
+	CustomerID     *uint         `json:"customer_id"` // Nullable
+	Customer       *Customer     `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	UserID         uint          `json:"user_id"`
+	User           User          `gorm:"foreignKey:UserID" json:"user"`
+	ShiftID        *uint         `gorm:"index" json:"shift_id"` // Biller's open till session
+	StoreID        *uint         `gorm:"index" json:"store_id"`
+	Store          *Store        `gorm:"foreignKey:StoreID" json:"store,omitempty"`
+	TotalAmount    float64       `gorm:"type:decimal(10,2);not null" json:"total_amount"`
+	DiscountAmount float64       `gorm:"type:decimal(10,2);default:0.00" json:"discount_amount"`
+	GSTAmount      float64       `gorm:"type:decimal(10,2);default:0.00" json:"gst_amount"`
+	CGSTAmount     float64       `gorm:"type:decimal(10,2);default:0.00" json:"cgst_amount"`
+	SGSTAmount     float64       `gorm:"type:decimal(10,2);default:0.00" json:"sgst_amount"`
+	IGSTAmount     float64       `gorm:"type:decimal(10,2);default:0.00" json:"igst_amount"`
+	PlaceOfSupply  string        `gorm:"size:50" json:"place_of_supply"`
+	InterState     bool          `gorm:"default:false" json:"inter_state"`
+	RoundOff       float64       `gorm:"type:decimal(10,2);default:0.00" json:"round_off"`
+	NetPayable     float64       `gorm:"type:decimal(10,2);not null" json:"net_payable"`
+	RefundedAmount float64       `gorm:"type:decimal(10,2);default:0.00" json:"refunded_amount"` // Credit notes issued against the bill
+	PaymentMode    string        `gorm:"size:20;not null;default:'CASH'" json:"payment_mode"`    // CASH, ONLINE, CARD, UPI, or SPLIT for multi-tender
+	Status         string        `gorm:"size:20;not null;default:'PAID'" json:"status"`          // PAID, CANCELLED
+	Items          []BillItem    `gorm:"foreignKey:BillID" json:"items"`
+	Payments       []BillPayment `gorm:"foreignKey:BillID" json:"payments"`
+	CreditNotes    []CreditNote  `gorm:"foreignKey:BillID" json:"credit_notes,omitempty"`
 }
 
 type BillItem struct {
-	ID        uint    `gorm:"primaryKey" json:"id"`
-	BillID    uint    `json:"bill_id"`
-	ProductID uint    `json:"product_id"`
-	Product   Product `gorm:"foreignKey:ProductID" json:"product"`
-	Quantity  int     `json:"quantity"`
-	UnitPrice float64 `gorm:"type:decimal(10,2);not null" json:"unit_price"`
-	Total     float64 `gorm:"type:decimal(10,2);not null" json:"total"`
+	ID             uint            `gorm:"primaryKey" json:"id"`
+	BillID         uint            `json:"bill_id"`
+	ProductID      uint            `json:"product_id"`
+	Product        Product         `gorm:"foreignKey:ProductID" json:"product"`
+	Quantity       int             `json:"quantity"`
+	UnitPrice      float64         `gorm:"type:decimal(10,2);not null" json:"unit_price"`
+	Total          float64         `gorm:"type:decimal(10,2);not null" json:"total"`
+	ReturnedQty    int             `gorm:"default:0" json:"returned_qty"`
+	HSNCode        string          `gorm:"size:10" json:"hsn_code"`
+	DiscountAmount float64         `gorm:"type:decimal(10,2);default:0.00" json:"discount_amount"`
+	TaxableValue   float64         `gorm:"type:decimal(10,2);default:0.00" json:"taxable_value"`
+	GSTRate        float64         `gorm:"type:decimal(5,2);default:0.00" json:"gst_rate"`
+	CGSTAmount     float64         `gorm:"type:decimal(10,2);default:0.00" json:"cgst_amount"`
+	SGSTAmount     float64         `gorm:"type:decimal(10,2);default:0.00" json:"sgst_amount"`
+	IGSTAmount     float64         `gorm:"type:decimal(10,2);default:0.00" json:"igst_amount"`
+	Batches        []BillItemBatch `json:"batches,omitempty"`
+}
+
+// Tender types accepted on a bill payment
+var TenderTypes = []string{"CASH", "UPI", "CARD", "ONLINE"}
+
+// BillPayment is one tender used to settle a bill. Amount is what was applied to
+// the bill; Tendered - Amount is the change handed back (cash only).
+type BillPayment struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	BillID         uint      `gorm:"index" json:"bill_id"`
+	TenderType     string    `gorm:"size:20;not null" json:"tender_type"`
+	Amount         float64   `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Tendered       float64   `gorm:"type:decimal(10,2);not null" json:"tendered"`
+	ChangeReturned float64   `gorm:"type:decimal(10,2);default:0.00" json:"change_returned"`
+	ReferenceNo    string    `gorm:"size:100" json:"reference_no"` // UPI txn id, card auth code
+	CardLast4      string    `gorm:"size:4" json:"card_last4"`
+	CreatedAt      time.Time `json:"created_at"`
 }
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+)
+
+// CreditNote documents a bill cancellation or a (partial) goods return
+type CreditNote struct {
+	ID             uint             `gorm:"primaryKey" json:"id"`
+	CreditNoteNo   string           `gorm:"size:50;unique;not null" json:"credit_note_no"`
+	BillID         uint             `gorm:"index" json:"bill_id"`
+	Type           string           `gorm:"size:20;not null" json:"type"` // CANCELLATION, RETURN
+	Reason         string           `gorm:"type:text;not null" json:"reason"`
+	UserID         uint             `json:"user_id"`
+	ShiftID        *uint            `gorm:"index" json:"shift_id"` // Till the refund was paid from
+	User           User             `gorm:"foreignKey:UserID" json:"user"`
+	TotalAmount    float64          `gorm:"type:decimal(10,2);not null" json:"total_amount"`
+	DiscountAmount float64          `gorm:"type:decimal(10,2);default:0.00" json:"discount_amount"`
+	TaxableValue   float64          `gorm:"type:decimal(10,2);default:0.00" json:"taxable_value"`
+	CGSTAmount     float64          `gorm:"type:decimal(10,2);default:0.00" json:"cgst_amount"`
+	SGSTAmount     float64          `gorm:"type:decimal(10,2);default:0.00" json:"sgst_amount"`
+	IGSTAmount     float64          `gorm:"type:decimal(10,2);default:0.00" json:"igst_amount"`
+	RefundAmount   float64          `gorm:"type:decimal(10,2);not null" json:"refund_amount"`
+	RefundMode     string           `gorm:"size:20" json:"refund_mode"`
+	CreatedAt      time.Time        `json:"created_at"`
+	Items          []CreditNoteItem `gorm:"foreignKey:CreditNoteID" json:"items"`
+}
+
+type CreditNoteItem struct {
+	ID             uint    `gorm:"primaryKey" json:"id"`
+	CreditNoteID   uint    `json:"credit_note_id"`
+	BillItemID     uint    `json:"bill_item_id"`
+	ProductID      uint    `json:"product_id"`
+	Product        Product `gorm:"foreignKey:ProductID" json:"product"`
+	Quantity       int     `json:"quantity"`
+	UnitPrice      float64 `gorm:"type:decimal(10,2);not null" json:"unit_price"`
+	Total          float64 `gorm:"type:decimal(10,2);not null" json:"total"`
+	HSNCode        string  `gorm:"size:10" json:"hsn_code"`
+	DiscountAmount float64 `gorm:"type:decimal(10,2);default:0.00" json:"discount_amount"`
+	TaxableValue   float64 `gorm:"type:decimal(10,2);default:0.00" json:"taxable_value"`
+	GSTRate        float64 `gorm:"type:decimal(5,2);default:0.00" json:"gst_rate"`
+	CGSTAmount     float64 `gorm:"type:decimal(10,2);default:0.00" json:"cgst_amount"`
+	SGSTAmount     float64 `gorm:"type:decimal(10,2);default:0.00" json:"sgst_amount"`
+	IGSTAmount     float64 `gorm:"type:decimal(10,2);default:0.00" json:"igst_amount"`
+}
@@ -9,6 +9,7 @@ type Customer struct {
 	Name            string    `gorm:"size:100;not null" json:"name"`
 	Mobile          string    `gorm:"size:15;unique;not null" json:"mobile"`
 	Address         string    `gorm:"type:text" json:"address"`
+	State           string    `gorm:"size:50" json:"state"` // Place of supply for GST
 	WhatsappOptIn   bool      `gorm:"default:false" json:"whatsapp_opt_in"`
 	DiscountPercent float64   `gorm:"type:decimal(5,2);default:0.00" json:"discount_percent"`
 	CreatedAt       time.Time `json:"created_at"`
@@ -19,8 +20,9 @@ type CustomerOrder struct {
 	OrderNo        string      `gorm:"size:50;unique;not null" json:"order_no"`
 	CustomerID     uint        `json:"customer_id"`
 	Customer       Customer    `gorm:"foreignKey:CustomerID" json:"customer"`
+	StoreID        *uint       `gorm:"index" json:"store_id"` // Store that fulfils the order
 	OrderDate      time.Time   `gorm:"default:CURRENT_TIMESTAMP" json:"order_date"`
-	Status         string      `gorm:"type:enum('PENDING', 'COMPLETED', 'CANCELLED');default:'PENDING'" json:"status"`
+	Status         string      `gorm:"size:20;not null;default:'PENDING'" json:"status"` // PENDING, COMPLETED, CANCELLED
 	TotalEstimated float64     `gorm:"type:decimal(10,2)" json:"total_estimated"`
 	Items          []OrderItem `gorm:"foreignKey:OrderID" json:"items"`
 }
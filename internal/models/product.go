@@ -13,38 +13,101 @@ type Brand struct {
 	Products  []Product `json:"-"`
 }
 
+// Category is a node in the category tree, e.g. Apparel > Men > Shirts. Path
+// lists the IDs from the root down ("/1/4/9/"), so a subtree is every category
+// whose Path starts with its root's Path. Names are unique across the tree.
 type Category struct {
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	Name        string    `gorm:"size:100;unique;not null" json:"name"`
-	Description string    `gorm:"type:text" json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
-	Products    []Product `json:"-"`
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Name        string     `gorm:"size:100;unique;not null" json:"name"`
+	ParentID    *uint      `gorm:"index" json:"parent_id"`
+	Parent      *Category  `gorm:"foreignKey:ParentID" json:"parent,omitempty"`
+	Children    []Category `gorm:"foreignKey:ParentID" json:"children,omitempty"`
+	Path        string     `gorm:"size:255;index" json:"path"`
+	Description string     `gorm:"type:text" json:"description"`
+	HSNCode     string     `gorm:"size:10" json:"hsn_code"`
+	GSTRate     *float64   `gorm:"type:decimal(5,2)" json:"gst_rate"` // Default for products in this category
+	CreatedAt   time.Time  `json:"created_at"`
+	Products    []Product  `json:"-"`
 }
 
+// SubtreePattern matches the paths of the category and all its descendants
+func (c Category) SubtreePattern() string {
+	return c.Path + "%"
+}
+
+// Product is a sellable item. A product with HasVariants is a parent that only
+// groups its Variants (sizes, colours); stock, barcodes and sales sit on the
+// variants, which are products themselves with ParentID set.
 type Product struct {
-	ID                uint           `gorm:"primaryKey" json:"id"`
-	Name              string         `gorm:"size:150;not null" json:"name"`
-	BrandID           uint           `json:"brand_id"`
-	Brand             Brand          `gorm:"foreignKey:BrandID" json:"brand"`
-	CategoryID        *uint          `json:"category_id"`
-	Category          *Category      `gorm:"foreignKey:CategoryID" json:"category"`
-	Description       string         `gorm:"type:text" json:"description"`
-	UnitPrice         float64        `gorm:"type:decimal(10,2);not null" json:"unit_price"`
-	CurrentStock      int            `gorm:"default:0" json:"current_stock"`
-	LowStockThreshold int            `gorm:"default:10" json:"low_stock_threshold"`
-	Barcode           string         `gorm:"size:50;index" json:"barcode"`
-	IsActive          bool           `gorm:"default:true" json:"is_active"`
-	CreatedAt         time.Time      `json:"created_at"`
-	UpdatedAt         time.Time      `json:"updated_at"`
-	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                uint             `gorm:"primaryKey" json:"id"`
+	Name              string           `gorm:"size:150;not null" json:"name"`
+	ParentID          *uint            `gorm:"index" json:"parent_id"`
+	Parent            *Product         `gorm:"foreignKey:ParentID" json:"parent,omitempty"`
+	Variants          []Product        `gorm:"foreignKey:ParentID" json:"variants,omitempty"`
+	HasVariants       bool             `gorm:"default:false" json:"has_variants"`
+	TrackBatches      bool             `gorm:"default:false" json:"track_batches"` // Stock is held in StockBatch rows, sold FEFO
+	SKU               string           `gorm:"size:64;index" json:"sku"`
+	Size              string           `gorm:"size:30" json:"size"`
+	Colour            string           `gorm:"size:30" json:"colour"`
+	BrandID           uint             `json:"brand_id"`
+	Brand             Brand            `gorm:"foreignKey:BrandID" json:"brand"`
+	CategoryID        *uint            `json:"category_id"`
+	Category          *Category        `gorm:"foreignKey:CategoryID" json:"category"`
+	SupplierID        *uint            `gorm:"index" json:"supplier_id"` // Preferred supplier for reorders
+	Supplier          *Supplier        `gorm:"foreignKey:SupplierID" json:"supplier,omitempty"`
+	Description       string           `gorm:"type:text" json:"description"`
+	UnitPrice         float64          `gorm:"type:decimal(10,2);not null" json:"unit_price"`
+	CurrentStock      int              `gorm:"default:0" json:"current_stock"`
+	LowStockThreshold int              `gorm:"default:10" json:"low_stock_threshold"`
+	Barcode           string           `gorm:"size:50;index" json:"barcode"`
+	HSNCode           string           `gorm:"size:10" json:"hsn_code"`
+	GSTRate           *float64         `gorm:"type:decimal(5,2)" json:"gst_rate"` // Nil falls back to category, then config
+	Barcodes          []ProductBarcode `json:"barcodes,omitempty"`                // Extra codes, e.g. outer packs
+	IsActive          bool             `gorm:"default:true" json:"is_active"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt   `gorm:"index" json:"-"`
 }
 
 type StockEntry struct {
-	ID            uint      `gorm:"primaryKey" json:"id"`
-	ProductID     uint      `json:"product_id"`
-	Product       Product   `gorm:"foreignKey:ProductID" json:"product"`
-	QuantityAdded int       `json:"quantity_added"`
-	AddedBy       uint      `json:"added_by"`
-	User          User      `gorm:"foreignKey:AddedBy" json:"user"`
-	EntryDate     time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"entry_date"`
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	ProductID       uint      `json:"product_id"`
+	Product         Product   `gorm:"foreignKey:ProductID" json:"product"`
+	QuantityAdded   int       `json:"quantity_added"`
+	Source          string    `gorm:"size:20;default:'PURCHASE'" json:"source"` // OPENING, PURCHASE, RETURN
+	Reference       string    `gorm:"size:50" json:"reference"`                 // Source document number, e.g. credit note
+	StoreID         *uint     `gorm:"index" json:"store_id"`
+	PurchaseOrderID *uint     `gorm:"index" json:"purchase_order_id"`
+	BatchID         *uint     `json:"batch_id"`
+	SupplierID      *uint     `json:"supplier_id"`
+	Supplier        *Supplier `gorm:"foreignKey:SupplierID" json:"supplier,omitempty"`
+	CostPrice       float64   `gorm:"type:decimal(10,2);default:0.00" json:"cost_price"`
+	AddedBy         uint      `json:"added_by"`
+	User            User      `gorm:"foreignKey:AddedBy" json:"user"`
+	EntryDate       time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"entry_date"`
+}
+
+// ProductPriceHistory records each selling price a product has had and when it applied.
+// The open row (EffectiveTo nil) matches Product.UnitPrice.
+type ProductPriceHistory struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	ProductID     uint       `gorm:"index" json:"product_id"`
+	UnitPrice     float64    `gorm:"type:decimal(10,2);not null" json:"unit_price"`
+	EffectiveFrom time.Time  `gorm:"index" json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to"`
+	Reason        string     `gorm:"size:255" json:"reason"`
+	ChangedBy     uint       `json:"changed_by"`
+	User          User       `gorm:"foreignKey:ChangedBy" json:"user"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ProductBarcode is an additional barcode for a product. Scanning it bills
+// PackQty units, so a case barcode can sell a whole pack in one scan.
+type ProductBarcode struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ProductID uint      `gorm:"index" json:"product_id"`
+	Code      string    `gorm:"size:50;uniqueIndex" json:"code"`
+	PackQty   int       `gorm:"default:1" json:"pack_qty"`
+	Label     string    `gorm:"size:50" json:"label"` // e.g. "Box of 12"
+	CreatedAt time.Time `json:"created_at"`
 }
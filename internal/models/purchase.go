@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+)
+
+type Supplier struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	Name          string    `gorm:"size:150;unique;not null" json:"name"`
+	ContactPerson string    `gorm:"size:100" json:"contact_person"`
+	Mobile        string    `gorm:"size:15" json:"mobile"`
+	Email         string    `gorm:"size:100" json:"email"`
+	Address       string    `gorm:"type:text" json:"address"`
+	GSTIN         string    `gorm:"size:15" json:"gstin"`
+	State         string    `gorm:"size:50" json:"state"`
+	LeadTimeDays  int       `gorm:"default:0" json:"lead_time_days"` // Order to delivery; 0 uses REORDER_LEAD_TIME_DAYS
+	IsActive      bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Purchase order lifecycle
+const (
+	POStatusDraft             = "DRAFT"
+	POStatusOrdered           = "ORDERED"
+	POStatusPartiallyReceived = "PARTIALLY_RECEIVED"
+	POStatusReceived          = "RECEIVED"
+	POStatusCancelled         = "CANCELLED"
+)
+
+type PurchaseOrder struct {
+	ID           uint                `gorm:"primaryKey" json:"id"`
+	PONo         string              `gorm:"size:50;unique;not null" json:"po_no"`
+	SupplierID   uint                `gorm:"index" json:"supplier_id"`
+	Supplier     Supplier            `gorm:"foreignKey:SupplierID" json:"supplier"`
+	StoreID      *uint               `gorm:"index" json:"store_id"` // Delivery location
+	Status       string              `gorm:"size:20;not null;default:'DRAFT'" json:"status"`
+	OrderedAt    *time.Time          `json:"ordered_at"`
+	ExpectedDate *time.Time          `json:"expected_date"`
+	Notes        string              `gorm:"type:text" json:"notes"`
+	TotalCost    float64             `gorm:"type:decimal(12,2);default:0.00" json:"total_cost"`
+	CreatedBy    uint                `json:"created_by"`
+	Creator      User                `gorm:"foreignKey:CreatedBy" json:"creator"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+	Items        []PurchaseOrderItem `gorm:"foreignKey:PurchaseOrderID" json:"items"`
+}
+
+type PurchaseOrderItem struct {
+	ID              uint    `gorm:"primaryKey" json:"id"`
+	PurchaseOrderID uint    `gorm:"index" json:"purchase_order_id"`
+	ProductID       uint    `json:"product_id"`
+	Product         Product `gorm:"foreignKey:ProductID" json:"product"`
+	Quantity        int     `gorm:"not null" json:"quantity"`
+	ReceivedQty     int     `gorm:"default:0" json:"received_qty"`
+	CostPrice       float64 `gorm:"type:decimal(10,2);not null" json:"cost_price"`
+	Total           float64 `gorm:"type:decimal(12,2);not null" json:"total"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Sequence holds the last number issued for a document series within a period.
+// A new period (day, financial year) gets a new row, which is how counters reset.
+type Sequence struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:50;not null;uniqueIndex:idx_sequence_name_period" json:"name"`
+	Period    string    `gorm:"size:20;not null;uniqueIndex:idx_sequence_name_period" json:"period"`
+	LastValue int       `gorm:"not null;default:0" json:"last_value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
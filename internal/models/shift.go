@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// Shift is a cashier's till session. Bills and credit notes created by the
+// user while the shift is open are stamped with its ID.
+type Shift struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	UserID         uint           `gorm:"index" json:"user_id"`
+	User           User           `gorm:"foreignKey:UserID" json:"user"`
+	LoginHistoryID *uint          `json:"login_history_id"`                              // Login session the shift was opened from
+	Status         string         `gorm:"size:20;not null;default:'OPEN'" json:"status"` // OPEN, CLOSED, APPROVED
+	OpenedAt       time.Time      `json:"opened_at"`
+	ClosedAt       *time.Time     `json:"closed_at"`
+	OpeningFloat   float64        `gorm:"type:decimal(10,2);not null" json:"opening_float"`
+	ExpectedCash   float64        `gorm:"type:decimal(10,2);default:0.00" json:"expected_cash"` // Frozen at close
+	CountedCash    float64        `gorm:"type:decimal(10,2);default:0.00" json:"counted_cash"`
+	Variance       float64        `gorm:"type:decimal(10,2);default:0.00" json:"variance"` // Counted - Expected
+	ClosingNote    string         `gorm:"type:text" json:"closing_note"`
+	ApprovedBy     *uint          `json:"approved_by"`
+	Approver       *User          `gorm:"foreignKey:ApprovedBy" json:"approver,omitempty"`
+	ApprovedAt     *time.Time     `json:"approved_at"`
+	ApprovalNote   string         `gorm:"type:text" json:"approval_note"`
+	CashMovements  []CashMovement `gorm:"foreignKey:ShiftID" json:"cash_movements,omitempty"`
+}
+
+// CashMovement is cash put into or taken out of the drawer outside of bills,
+// e.g. a float top-up or a petty expense
+type CashMovement struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ShiftID   uint      `gorm:"index" json:"shift_id"`
+	Type      string    `gorm:"size:10;not null" json:"type"` // CASH_IN, CASH_OUT
+	Amount    float64   `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Reason    string    `gorm:"type:text;not null" json:"reason"`
+	UserID    uint      `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
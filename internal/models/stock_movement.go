@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// Stock movement types
+const (
+	MovementOpening    = "OPENING"
+	MovementPurchase   = "PURCHASE"
+	MovementSale       = "SALE"
+	MovementReturn     = "RETURN"
+	MovementAdjustment = "ADJUSTMENT"
+	MovementDamage     = "DAMAGE"
+	MovementTransfer   = "TRANSFER"
+)
+
+// StockMovement is the ledger of every change to Product.CurrentStock.
+// Quantity is signed; BalanceAfter is the product's stock right after the movement.
+type StockMovement struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ProductID    uint      `gorm:"index:idx_movement_product_time" json:"product_id"`
+	Product      Product   `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Type         string    `gorm:"size:20;not null;index" json:"type"`
+	Quantity     int       `gorm:"not null" json:"quantity"`
+	BalanceAfter int       `gorm:"not null" json:"balance_after"` // Across all stores
+	RefType      string    `gorm:"size:30" json:"ref_type"`       // BILL, CREDIT_NOTE, PURCHASE_ORDER, STOCK_ENTRY
+	RefID        *uint     `json:"ref_id"`
+	RefNo        string    `gorm:"size:50" json:"ref_no"`
+	BatchID      *uint     `gorm:"index" json:"batch_id"` // Set when the whole movement is one batch
+	StoreID      *uint     `gorm:"index" json:"store_id"`
+	Note         string    `gorm:"type:text" json:"note"`
+	UserID       uint      `json:"user_id"`
+	User         User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	CreatedAt    time.Time `gorm:"index:idx_movement_product_time" json:"created_at"`
+
+	Batches []BatchAllocation `gorm:"-" json:"batches,omitempty"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+)
+
+// Adjustment reason codes
+var AdjustmentReasons = []string{"DAMAGE", "THEFT", "EXPIRED", "COUNT_ERROR", "FOUND", "OTHER"}
+
+func IsValidAdjustmentReason(reason string) bool {
+	for _, r := range AdjustmentReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// MovementTypeForReason maps a reason code to the ledger movement type
+func MovementTypeForReason(reason string) string {
+	if reason == "DAMAGE" || reason == "EXPIRED" {
+		return MovementDamage
+	}
+	return MovementAdjustment
+}
+
+// StockTake is a physical count sheet (cycle count) for a category or location.
+// Status: OPEN (counting), SUBMITTED (awaiting review), APPROVED, CANCELLED.
+type StockTake struct {
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	TakeNo     string          `gorm:"size:50;unique;not null" json:"take_no"`
+	CategoryID *uint           `json:"category_id"`
+	Category   *Category       `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+	StoreID    *uint           `gorm:"index" json:"store_id"`
+	Store      *Store          `gorm:"foreignKey:StoreID" json:"store,omitempty"`
+	Location   string          `gorm:"size:100" json:"location"` // Aisle or shelf within the store
+	Status     string          `gorm:"size:20;not null;default:'OPEN'" json:"status"`
+	Notes      string          `gorm:"type:text" json:"notes"`
+	CreatedBy  uint            `json:"created_by"`
+	Creator    User            `gorm:"foreignKey:CreatedBy" json:"creator"`
+	ApprovedBy *uint           `json:"approved_by"`
+	ApprovedAt *time.Time      `json:"approved_at"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	Items      []StockTakeItem `gorm:"foreignKey:StockTakeID" json:"items"`
+}
+
+// StockTakeItem is one product line on a count sheet. SystemQty is the stock
+// when the sheet was created and SystemQtyAtCount the stock when the line was
+// last counted; approval posts CountedQty - SystemQtyAtCount to the ledger as
+// Adjustment, so sales made since the count are not undone.
+type StockTakeItem struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	StockTakeID      uint       `gorm:"index" json:"stock_take_id"`
+	ProductID        uint       `json:"product_id"`
+	Product          Product    `gorm:"foreignKey:ProductID" json:"product"`
+	SystemQty        int        `json:"system_qty"`
+	CountedQty       *int       `json:"counted_qty"` // Nil until counted
+	SystemQtyAtCount *int       `json:"system_qty_at_count"`
+	ReasonCode       string     `gorm:"size:20" json:"reason_code"`
+	Adjustment       int        `gorm:"default:0" json:"adjustment"`
+	CountedBy        *uint      `json:"counted_by"`
+	CountedAt        *time.Time `json:"counted_at"`
+}
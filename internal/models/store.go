@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+)
+
+// Store types
+const (
+	StoreTypeStore     = "STORE"
+	StoreTypeWarehouse = "WAREHOUSE"
+)
+
+// Store is a branch or warehouse holding stock. Bills print the store's
+// address and GSTIN; the default store takes anything not assigned elsewhere.
+type Store struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Code      string    `gorm:"size:20;unique;not null" json:"code"`
+	Name      string    `gorm:"size:100;not null" json:"name"`
+	Type      string    `gorm:"size:20;default:'STORE'" json:"type"` // STORE, WAREHOUSE
+	Address   string    `gorm:"type:text" json:"address"`
+	Phone     string    `gorm:"size:20" json:"phone"`
+	GSTIN     string    `gorm:"size:15" json:"gstin"`
+	State     string    `gorm:"size:50" json:"state"` // Intra-state sales are to this state
+	IsDefault bool      `gorm:"default:false" json:"is_default"`
+	IsActive  bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StoreStock is a product's stock at one store. Product.CurrentStock is the
+// sum over all stores; stock in transit between stores is in neither.
+type StoreStock struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	StoreID   uint      `gorm:"uniqueIndex:idx_store_product" json:"store_id"`
+	Store     Store     `gorm:"foreignKey:StoreID" json:"store,omitempty"`
+	ProductID uint      `gorm:"uniqueIndex:idx_store_product;index" json:"product_id"`
+	Product   Product   `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Quantity  int       `gorm:"default:0" json:"quantity"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Stock transfer statuses
+const (
+	TransferStatusInTransit = "IN_TRANSIT"
+	TransferStatusReceived  = "RECEIVED"
+	TransferStatusCancelled = "CANCELLED"
+)
+
+// StockTransfer moves stock between stores. Stock leaves the source on
+// dispatch and reaches the destination when the transfer is received.
+type StockTransfer struct {
+	ID           uint                `gorm:"primaryKey" json:"id"`
+	TransferNo   string              `gorm:"size:50;unique;not null" json:"transfer_no"`
+	FromStoreID  uint                `gorm:"index" json:"from_store_id"`
+	FromStore    Store               `gorm:"foreignKey:FromStoreID" json:"from_store"`
+	ToStoreID    uint                `gorm:"index" json:"to_store_id"`
+	ToStore      Store               `gorm:"foreignKey:ToStoreID" json:"to_store"`
+	Status       string              `gorm:"size:20;not null;index" json:"status"`
+	Notes        string              `gorm:"type:text" json:"notes"`
+	CreatedBy    uint                `json:"created_by"`
+	Creator      User                `gorm:"foreignKey:CreatedBy" json:"creator"`
+	DispatchedAt time.Time           `json:"dispatched_at"`
+	ReceivedBy   *uint               `json:"received_by"`
+	ReceivedAt   *time.Time          `json:"received_at"`
+	Items        []StockTransferItem `json:"items"`
+}
+
+// StockTransferItem is one product, and for batch-tracked products one batch, on a transfer
+type StockTransferItem struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	StockTransferID uint       `gorm:"index" json:"stock_transfer_id"`
+	ProductID       uint       `json:"product_id"`
+	Product         Product    `gorm:"foreignKey:ProductID" json:"product"`
+	Quantity        int        `json:"quantity"`
+	ReceivedQty     int        `gorm:"default:0" json:"received_qty"`
+	BatchNo         string     `gorm:"size:50" json:"batch_no"`
+	MfgDate         *time.Time `gorm:"type:date" json:"mfg_date"`
+	ExpiryDate      *time.Time `gorm:"type:date" json:"expiry_date"`
+	CostPrice       float64    `gorm:"type:decimal(10,2);default:0.00" json:"cost_price"`
+}
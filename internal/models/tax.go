@@ -0,0 +1,32 @@
+package models
+
+// GSTSlabs are the GST rates (in percent) allowed on products and categories
+var GSTSlabs = []float64{0, 5, 12, 18, 28}
+
+func IsValidGSTSlab(rate float64) bool {
+	for _, slab := range GSTSlabs {
+		if rate == slab {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveTax returns the HSN/SAC code and GST rate for a product.
+// Product values win over its category; fallbackRate applies when neither sets a rate.
+func ResolveTax(product Product, fallbackRate float64) (string, float64) {
+	hsn := product.HSNCode
+	rate := fallbackRate
+	if product.Category != nil {
+		if hsn == "" {
+			hsn = product.Category.HSNCode
+		}
+		if product.Category.GSTRate != nil {
+			rate = *product.Category.GSTRate
+		}
+	}
+	if product.GSTRate != nil {
+		rate = *product.GSTRate
+	}
+	return hsn, rate
+}
@@ -21,6 +21,8 @@ type User struct {
 	PasswordHash   string         `gorm:"size:255;not null" json:"-"`
 	RoleID         uint           `json:"role_id"`
 	Role           Role           `gorm:"foreignKey:RoleID" json:"role"`
+	StoreID        *uint          `json:"store_id"` // Nil works at the default store
+	Store          *Store         `gorm:"foreignKey:StoreID" json:"store,omitempty"`
 	IsActive       bool           `gorm:"default:true" json:"is_active"`
 	InactiveReason string         `gorm:"type:text" json:"inactive_reason"`
 	CreatedAt      time.Time      `json:"created_at"`
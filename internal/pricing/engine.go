@@ -0,0 +1,212 @@
+package pricing
+
+import (
+	"fmt"
+	"math"
+
+	"billing-app/internal/models"
+)
+
+// Discount sources reported in the breakdown
+const (
+	SourceNone     = "NONE"
+	SourceCustomer = "CUSTOMER"
+	SourceGlobal   = "GLOBAL"
+	SourceRule     = "RULE"
+)
+
+// Line is a single product/quantity pair to be priced.
+// HSNCode and GSTRate are resolved by the caller (see models.ResolveTax).
+type Line struct {
+	Product  models.Product
+	Quantity int
+	HSNCode  string
+	GSTRate  float64
+}
+
+// Input carries everything the engine needs to price a bill.
+// It is loaded by the caller (usually inside the bill transaction).
+type Input struct {
+	Lines                   []Line
+	CustomerDiscountPercent float64
+	GlobalDiscountPercent   float64
+	Rules                   []models.DiscountRule
+	PlaceOfSupply           string
+	InterState              bool    // IGST instead of CGST+SGST
+	RoundTo                 float64 // 0 disables rounding of the net payable
+}
+
+type LineResult struct {
+	ProductID      uint    `json:"product_id"`
+	Name           string  `json:"name"`
+	HSNCode        string  `json:"hsn_code"`
+	Quantity       int     `json:"quantity"`
+	UnitPrice      float64 `json:"unit_price"`
+	Total          float64 `json:"total"`
+	DiscountAmount float64 `json:"discount_amount"`
+	TaxableValue   float64 `json:"taxable_value"`
+	GSTRate        float64 `json:"gst_rate"`
+	CGSTAmount     float64 `json:"cgst_amount"`
+	SGSTAmount     float64 `json:"sgst_amount"`
+	IGSTAmount     float64 `json:"igst_amount"`
+}
+
+type Breakdown struct {
+	Items           []LineResult `json:"items"`
+	TotalAmount     float64      `json:"total_amount"`
+	DiscountPercent float64      `json:"discount_percent"`
+	DiscountSource  string       `json:"discount_source"`
+	DiscountAmount  float64      `json:"discount_amount"`
+	TaxableAmount   float64      `json:"taxable_amount"`
+	PlaceOfSupply   string       `json:"place_of_supply"`
+	InterState      bool         `json:"inter_state"`
+	CGSTAmount      float64      `json:"cgst_amount"`
+	SGSTAmount      float64      `json:"sgst_amount"`
+	IGSTAmount      float64      `json:"igst_amount"`
+	GSTAmount       float64      `json:"gst_amount"`
+	RoundOff        float64      `json:"round_off"`
+	NetPayable      float64      `json:"net_payable"`
+}
+
+// Calculate prices the bill from catalogue prices only.
+// The best single discount among customer, global and matching rule is applied;
+// discounts are not stacked. The discount is spread over lines pro rata and GST
+// is charged per line on the discounted (taxable) value.
+func Calculate(in Input) Breakdown {
+	b := Breakdown{Items: make([]LineResult, 0, len(in.Lines)), DiscountSource: SourceNone, PlaceOfSupply: in.PlaceOfSupply, InterState: in.InterState}
+
+	for _, l := range in.Lines {
+		total := Round2(l.Product.UnitPrice * float64(l.Quantity))
+		b.Items = append(b.Items, LineResult{
+			ProductID: l.Product.ID,
+			Name:      l.Product.Name,
+			HSNCode:   l.HSNCode,
+			Quantity:  l.Quantity,
+			UnitPrice: l.Product.UnitPrice,
+			Total:     total,
+			GSTRate:   l.GSTRate,
+		})
+		b.TotalAmount += total
+	}
+	b.TotalAmount = Round2(b.TotalAmount)
+
+	if in.CustomerDiscountPercent > b.DiscountPercent {
+		b.DiscountPercent, b.DiscountSource = in.CustomerDiscountPercent, SourceCustomer
+	}
+	if in.GlobalDiscountPercent > b.DiscountPercent {
+		b.DiscountPercent, b.DiscountSource = in.GlobalDiscountPercent, SourceGlobal
+	}
+	if rule := MatchRule(in.Rules, b.TotalAmount); rule != nil && rule.Percentage > b.DiscountPercent {
+		b.DiscountPercent, b.DiscountSource = rule.Percentage, SourceRule
+	}
+	if b.DiscountPercent > 100 {
+		b.DiscountPercent = 100
+	}
+
+	for i := range b.Items {
+		item := &b.Items[i]
+		item.DiscountAmount = Round2(item.Total * b.DiscountPercent / 100)
+		item.TaxableValue = Round2(item.Total - item.DiscountAmount)
+
+		tax := Round2(item.TaxableValue * item.GSTRate / 100)
+		if in.InterState {
+			item.IGSTAmount = tax
+		} else {
+			item.CGSTAmount = Round2(tax / 2)
+			item.SGSTAmount = Round2(tax - item.CGSTAmount)
+		}
+
+		b.DiscountAmount += item.DiscountAmount
+		b.CGSTAmount += item.CGSTAmount
+		b.SGSTAmount += item.SGSTAmount
+		b.IGSTAmount += item.IGSTAmount
+	}
+	b.DiscountAmount = Round2(b.DiscountAmount)
+	b.TaxableAmount = Round2(b.TotalAmount - b.DiscountAmount)
+	b.CGSTAmount = Round2(b.CGSTAmount)
+	b.SGSTAmount = Round2(b.SGSTAmount)
+	b.IGSTAmount = Round2(b.IGSTAmount)
+	b.GSTAmount = Round2(b.CGSTAmount + b.SGSTAmount + b.IGSTAmount)
+
+	gross := Round2(b.TaxableAmount + b.GSTAmount)
+	b.NetPayable = gross
+	if in.RoundTo > 0 {
+		b.NetPayable = Round2(math.Round(gross/in.RoundTo) * in.RoundTo)
+	}
+	b.RoundOff = Round2(b.NetPayable - gross)
+
+	return b
+}
+
+// MatchRule returns the active rule whose slab contains amount.
+// MaxAmount of 0 means the slab is open ended. When slabs overlap the highest percentage wins.
+func MatchRule(rules []models.DiscountRule, amount float64) *models.DiscountRule {
+	var best *models.DiscountRule
+	for i := range rules {
+		r := &rules[i]
+		if !r.IsActive || amount < r.MinAmount {
+			continue
+		}
+		if r.MaxAmount > 0 && amount > r.MaxAmount {
+			continue
+		}
+		if best == nil || r.Percentage > best.Percentage {
+			best = r
+		}
+	}
+	return best
+}
+
+// ClientLine is what the POS client claims for a line item
+type ClientLine struct {
+	ProductID uint
+	UnitPrice *float64
+	Total     *float64
+}
+
+// ClientFigures is what the POS client claims for the bill.
+// Nil values were not sent and are not checked.
+type ClientFigures struct {
+	TotalAmount    *float64
+	DiscountAmount *float64
+	GSTAmount      *float64
+	NetPayable     *float64
+	Lines          []ClientLine
+}
+
+type Mismatch struct {
+	Field    string  `json:"field"`
+	Client   float64 `json:"client"`
+	Computed float64 `json:"computed"`
+}
+
+// Verify compares the client's figures against the computed breakdown and
+// returns every field that differs by more than tolerance.
+func (b Breakdown) Verify(client ClientFigures, tolerance float64) []Mismatch {
+	var mismatches []Mismatch
+	check := func(field string, got *float64, want float64) {
+		if got != nil && math.Abs(*got-want) > tolerance+1e-9 {
+			mismatches = append(mismatches, Mismatch{Field: field, Client: *got, Computed: want})
+		}
+	}
+
+	check("total_amount", client.TotalAmount, b.TotalAmount)
+	check("discount_amount", client.DiscountAmount, b.DiscountAmount)
+	check("gst_amount", client.GSTAmount, b.GSTAmount)
+	check("net_payable", client.NetPayable, b.NetPayable)
+
+	for i, l := range client.Lines {
+		if i >= len(b.Items) {
+			break
+		}
+		check(fmt.Sprintf("items[%d].unit_price", i), l.UnitPrice, b.Items[i].UnitPrice)
+		check(fmt.Sprintf("items[%d].total", i), l.Total, b.Items[i].Total)
+	}
+
+	return mismatches
+}
+
+// Round2 rounds a currency amount to paise
+func Round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
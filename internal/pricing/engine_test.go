@@ -0,0 +1,225 @@
+package pricing_test
+
+import (
+	"math"
+	"testing"
+
+	"billing-app/internal/models"
+	"billing-app/internal/pricing"
+)
+
+func line(id uint, price float64, quantity int, gstRate float64) pricing.Line {
+	return pricing.Line{
+		Product:  models.Product{ID: id, Name: "Product", UnitPrice: price},
+		Quantity: quantity,
+		GSTRate:  gstRate,
+	}
+}
+
+func ptr(v float64) *float64 { return &v }
+
+func TestCalculateDiscountSource(t *testing.T) {
+	slab := []models.DiscountRule{{MinAmount: 150, Percentage: 20, IsActive: true}}
+
+	tests := []struct {
+		name        string
+		customer    float64
+		global      float64
+		rules       []models.DiscountRule
+		wantPercent float64
+		wantSource  string
+	}{
+		{"no discount", 0, 0, nil, 0, pricing.SourceNone},
+		{"global only", 0, 10, nil, 10, pricing.SourceGlobal},
+		{"customer only", 15, 0, nil, 15, pricing.SourceCustomer},
+		{"larger customer discount wins", 15, 10, nil, 15, pricing.SourceCustomer},
+		{"larger global discount wins", 5, 10, nil, 10, pricing.SourceGlobal},
+		{"tie goes to the customer", 10, 10, nil, 10, pricing.SourceCustomer},
+		{"matching slab beats both", 15, 10, slab, 20, pricing.SourceRule},
+		{"slab out of range", 15, 10, []models.DiscountRule{{MinAmount: 500, Percentage: 20, IsActive: true}}, 15, pricing.SourceCustomer},
+		{"inactive slab", 15, 10, []models.DiscountRule{{MinAmount: 150, Percentage: 20}}, 15, pricing.SourceCustomer},
+		{"capped at 100", 150, 0, nil, 100, pricing.SourceCustomer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := pricing.Calculate(pricing.Input{
+				Lines:                   []pricing.Line{line(1, 100, 2, 0)},
+				CustomerDiscountPercent: tt.customer,
+				GlobalDiscountPercent:   tt.global,
+				Rules:                   tt.rules,
+			})
+			if b.DiscountPercent != tt.wantPercent || b.DiscountSource != tt.wantSource {
+				t.Errorf("discount %.2f%% from %s, want %.2f%% from %s", b.DiscountPercent, b.DiscountSource, tt.wantPercent, tt.wantSource)
+			}
+			if want := pricing.Round2(200 * tt.wantPercent / 100); b.DiscountAmount != want {
+				t.Errorf("discount amount %.2f, want %.2f", b.DiscountAmount, want)
+			}
+		})
+	}
+}
+
+// The discount is spread over lines in proportion to their totals, each share
+// rounded to paise; the bill's discount is the sum of the shares.
+func TestCalculateDiscountSpread(t *testing.T) {
+	tests := []struct {
+		name         string
+		lines        []pricing.Line
+		percent      float64
+		wantDiscount []float64
+		wantTaxable  []float64
+		wantTotal    float64
+	}{
+		{
+			"even split",
+			[]pricing.Line{line(1, 100, 1, 0), line(2, 50, 2, 0)},
+			10, []float64{10, 10}, []float64{90, 90}, 20,
+		},
+		{
+			"shares rounded per line",
+			[]pricing.Line{line(1, 250, 2, 0), line(2, 125.50, 1, 0)},
+			12.5, []float64{62.50, 15.69}, []float64{437.50, 109.81}, 78.19,
+		},
+		{
+			"full discount",
+			[]pricing.Line{line(1, 19.99, 3, 0), line(2, 0.01, 1, 0)},
+			100, []float64{59.97, 0.01}, []float64{0, 0}, 59.98,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := pricing.Calculate(pricing.Input{Lines: tt.lines, GlobalDiscountPercent: tt.percent})
+			for i, item := range b.Items {
+				if item.DiscountAmount != tt.wantDiscount[i] || item.TaxableValue != tt.wantTaxable[i] {
+					t.Errorf("line %d: discount %.2f, taxable %.2f; want %.2f, %.2f",
+						i, item.DiscountAmount, item.TaxableValue, tt.wantDiscount[i], tt.wantTaxable[i])
+				}
+			}
+			if b.DiscountAmount != tt.wantTotal {
+				t.Errorf("discount %.2f, want %.2f", b.DiscountAmount, tt.wantTotal)
+			}
+			if want := pricing.Round2(b.TotalAmount - tt.wantTotal); b.TaxableAmount != want {
+				t.Errorf("taxable %.2f, want %.2f", b.TaxableAmount, want)
+			}
+		})
+	}
+}
+
+// GST is charged and rounded per line on its taxable value: intra-state as a
+// CGST/SGST pair that adds back to the line's tax, inter-state as IGST
+func TestCalculateGST(t *testing.T) {
+	tests := []struct {
+		name       string
+		lines      []pricing.Line
+		discount   float64
+		interState bool
+		wantCGST   float64
+		wantSGST   float64
+		wantIGST   float64
+	}{
+		{"intra-state", []pricing.Line{line(1, 100, 1, 18)}, 0, false, 9, 9, 0},
+		{"inter-state", []pricing.Line{line(1, 100, 1, 18)}, 0, true, 0, 0, 18},
+		{"on the discounted value", []pricing.Line{line(1, 100, 1, 18)}, 10, false, 8.10, 8.10, 0},
+		{"exempt line", []pricing.Line{line(1, 100, 1, 0), line(2, 100, 1, 5)}, 0, false, 2.50, 2.50, 0},
+		// 0.222 a line rounds to 0.22; rounding the bill's 0.666 would give 0.67
+		{"rounded per line intra-state", []pricing.Line{line(1, 1.85, 1, 12), line(2, 1.85, 1, 12), line(3, 1.85, 1, 12)}, 0, false, 0.33, 0.33, 0},
+		{"rounded per line inter-state", []pricing.Line{line(1, 1.85, 1, 12), line(2, 1.85, 1, 12), line(3, 1.85, 1, 12)}, 0, true, 0, 0, 0.66},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := pricing.Calculate(pricing.Input{Lines: tt.lines, GlobalDiscountPercent: tt.discount, InterState: tt.interState})
+			if b.CGSTAmount != tt.wantCGST || b.SGSTAmount != tt.wantSGST || b.IGSTAmount != tt.wantIGST {
+				t.Errorf("CGST %.2f, SGST %.2f, IGST %.2f; want %.2f, %.2f, %.2f",
+					b.CGSTAmount, b.SGSTAmount, b.IGSTAmount, tt.wantCGST, tt.wantSGST, tt.wantIGST)
+			}
+			if want := pricing.Round2(tt.wantCGST + tt.wantSGST + tt.wantIGST); b.GSTAmount != want {
+				t.Errorf("GST %.2f, want %.2f", b.GSTAmount, want)
+			}
+			if want := pricing.Round2(b.TaxableAmount + b.GSTAmount); b.NetPayable != want {
+				t.Errorf("net payable %.2f, want %.2f", b.NetPayable, want)
+			}
+		})
+	}
+}
+
+// An odd paise of tax cannot halve evenly; the two halves must still add back
+func TestCalculateGSTSplitsOddPaise(t *testing.T) {
+	b := pricing.Calculate(pricing.Input{Lines: []pricing.Line{line(1, 11, 1, 5)}})
+	item := b.Items[0]
+	if pricing.Round2(item.CGSTAmount+item.SGSTAmount) != 0.55 {
+		t.Errorf("CGST %.2f + SGST %.2f, want 0.55", item.CGSTAmount, item.SGSTAmount)
+	}
+	if diff := math.Abs(item.CGSTAmount - item.SGSTAmount); pricing.Round2(diff) != 0.01 {
+		t.Errorf("CGST %.2f and SGST %.2f differ by %.2f, want 0.01", item.CGSTAmount, item.SGSTAmount, diff)
+	}
+}
+
+func TestCalculateRoundOff(t *testing.T) {
+	tests := []struct {
+		name         string
+		price        float64
+		roundTo      float64
+		wantNet      float64
+		wantRoundOff float64
+	}{
+		{"disabled", 99.40, 0, 99.40, 0},
+		{"rounds up to the rupee", 99.60, 1, 100, 0.40},
+		{"rounds down to the rupee", 99.40, 1, 99, -0.40},
+		{"already whole", 99, 1, 99, 0},
+		{"to 50 paise up", 99.30, 0.5, 99.50, 0.20},
+		{"to 50 paise down", 99.20, 0.5, 99, -0.20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := pricing.Calculate(pricing.Input{Lines: []pricing.Line{line(1, tt.price, 1, 0)}, RoundTo: tt.roundTo})
+			if b.NetPayable != tt.wantNet || b.RoundOff != tt.wantRoundOff {
+				t.Errorf("net %.2f, round-off %.2f; want %.2f, %.2f", b.NetPayable, b.RoundOff, tt.wantNet, tt.wantRoundOff)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	// Total 100, GST 18, net 118
+	b := pricing.Calculate(pricing.Input{Lines: []pricing.Line{line(1, 100, 1, 18)}, RoundTo: 1})
+
+	tests := []struct {
+		name   string
+		client pricing.ClientFigures
+		want   []string
+	}{
+		{"nothing sent", pricing.ClientFigures{}, nil},
+		{"exact", pricing.ClientFigures{TotalAmount: ptr(100), DiscountAmount: ptr(0), GSTAmount: ptr(18), NetPayable: ptr(118)}, nil},
+		{"within tolerance", pricing.ClientFigures{NetPayable: ptr(118.05)}, nil},
+		{"at tolerance below", pricing.ClientFigures{NetPayable: ptr(117.95)}, nil},
+		{"past tolerance", pricing.ClientFigures{NetPayable: ptr(118.06)}, []string{"net_payable"}},
+		{"several fields", pricing.ClientFigures{TotalAmount: ptr(90), GSTAmount: ptr(16.20)}, []string{"total_amount", "gst_amount"}},
+		{"discount claimed", pricing.ClientFigures{DiscountAmount: ptr(10)}, []string{"discount_amount"}},
+		{"line price", pricing.ClientFigures{Lines: []pricing.ClientLine{{ProductID: 1, UnitPrice: ptr(90)}}}, []string{"items[0].unit_price"}},
+		{"line total within tolerance", pricing.ClientFigures{Lines: []pricing.ClientLine{{ProductID: 1, Total: ptr(100.04)}}}, nil},
+		{"extra client lines ignored", pricing.ClientFigures{Lines: []pricing.ClientLine{{ProductID: 1}, {ProductID: 2, Total: ptr(1)}}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mismatches := b.Verify(tt.client, 0.05)
+			var got []string
+			for _, m := range mismatches {
+				got = append(got, m.Field)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("mismatches %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("mismatches %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	if m := b.Verify(pricing.ClientFigures{NetPayable: ptr(100)}, 0.05); len(m) != 1 || m[0].Client != 100 || m[0].Computed != 118 {
+		t.Errorf("mismatch %+v, want client 100 against computed 118", m)
+	}
+	if m := b.Verify(pricing.ClientFigures{NetPayable: ptr(118.06)}, 0); len(m) != 1 {
+		t.Errorf("zero tolerance: %+v, want a net_payable mismatch", m)
+	}
+}
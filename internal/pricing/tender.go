@@ -0,0 +1,85 @@
+package pricing
+
+import (
+	"fmt"
+	"strings"
+
+	"billing-app/internal/models"
+)
+
+// Tender is one payment handed over at the counter
+type Tender struct {
+	TenderType  string
+	Tendered    float64
+	ReferenceNo string
+	CardLast4   string
+}
+
+// Settle applies tenders to netPayable. The tenders must cover the bill and any
+// excess must be cash, which is recorded as change against the cash tenders.
+// It returns the payment rows and the bill payment mode (the tender type, or SPLIT).
+func Settle(netPayable float64, tenders []Tender) ([]models.BillPayment, string, error) {
+	if len(tenders) == 0 {
+		return nil, "", fmt.Errorf("At least one payment is required")
+	}
+
+	payments := make([]models.BillPayment, 0, len(tenders))
+	var total, cash float64
+	for _, t := range tenders {
+		tenderType := strings.ToUpper(t.TenderType)
+		if !isTenderType(tenderType) {
+			return nil, "", fmt.Errorf("Unsupported tender type %q", t.TenderType)
+		}
+		if t.Tendered <= 0 {
+			return nil, "", fmt.Errorf("Tender amount must be positive")
+		}
+		total += t.Tendered
+		if tenderType == "CASH" {
+			cash += t.Tendered
+		}
+		payments = append(payments, models.BillPayment{
+			TenderType:  tenderType,
+			Amount:      Round2(t.Tendered),
+			Tendered:    Round2(t.Tendered),
+			ReferenceNo: t.ReferenceNo,
+			CardLast4:   t.CardLast4,
+		})
+	}
+
+	excess := Round2(total - netPayable)
+	if excess < 0 {
+		return nil, "", fmt.Errorf("Payments total %.2f is short of net payable %.2f", total, netPayable)
+	}
+	if excess > Round2(cash) {
+		return nil, "", fmt.Errorf("Payments exceed net payable by %.2f; only cash can be over-tendered", excess)
+	}
+
+	// Hand change back from the last cash tender first
+	for i := len(payments) - 1; i >= 0 && excess > 0; i-- {
+		if payments[i].TenderType != "CASH" {
+			continue
+		}
+		change := excess
+		if change > payments[i].Tendered {
+			change = payments[i].Tendered
+		}
+		payments[i].ChangeReturned = Round2(change)
+		payments[i].Amount = Round2(payments[i].Tendered - change)
+		excess = Round2(excess - change)
+	}
+
+	mode := payments[0].TenderType
+	if len(payments) > 1 {
+		mode = "SPLIT"
+	}
+	return payments, mode, nil
+}
+
+func isTenderType(t string) bool {
+	for _, tt := range models.TenderTypes {
+		if t == tt {
+			return true
+		}
+	}
+	return false
+}
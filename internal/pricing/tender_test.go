@@ -0,0 +1,136 @@
+package pricing_test
+
+import (
+	"strings"
+	"testing"
+
+	"billing-app/internal/pricing"
+)
+
+func TestSettle(t *testing.T) {
+	type payment struct {
+		tenderType string
+		amount     float64
+		change     float64
+	}
+
+	tests := []struct {
+		name     string
+		net      float64
+		tenders  []pricing.Tender
+		want     []payment
+		wantMode string
+		wantErr  string
+	}{
+		{
+			name:     "exact cash",
+			net:      118,
+			tenders:  []pricing.Tender{{TenderType: "CASH", Tendered: 118}},
+			want:     []payment{{"CASH", 118, 0}},
+			wantMode: "CASH",
+		},
+		{
+			name:     "cash with change",
+			net:      118,
+			tenders:  []pricing.Tender{{TenderType: "cash", Tendered: 200}},
+			want:     []payment{{"CASH", 118, 82}},
+			wantMode: "CASH",
+		},
+		{
+			name:     "change from the last cash tender",
+			net:      118,
+			tenders:  []pricing.Tender{{TenderType: "CASH", Tendered: 100}, {TenderType: "CASH", Tendered: 50}},
+			want:     []payment{{"CASH", 100, 0}, {"CASH", 18, 32}},
+			wantMode: "SPLIT",
+		},
+		{
+			name: "change spills into an earlier cash tender",
+			net:  118,
+			tenders: []pricing.Tender{
+				{TenderType: "CASH", Tendered: 100},
+				{TenderType: "UPI", Tendered: 50},
+				{TenderType: "CASH", Tendered: 20},
+			},
+			want:     []payment{{"CASH", 68, 32}, {"UPI", 50, 0}, {"CASH", 0, 20}},
+			wantMode: "SPLIT",
+		},
+		{
+			name:     "card and cash split",
+			net:      118,
+			tenders:  []pricing.Tender{{TenderType: "CARD", Tendered: 100}, {TenderType: "CASH", Tendered: 20}},
+			want:     []payment{{"CARD", 100, 0}, {"CASH", 18, 2}},
+			wantMode: "SPLIT",
+		},
+		{
+			name:    "non-cash over-tendered",
+			net:     118,
+			tenders: []pricing.Tender{{TenderType: "UPI", Tendered: 120}},
+			wantErr: "only cash can be over-tendered",
+		},
+		{
+			name:    "excess larger than the cash",
+			net:     100,
+			tenders: []pricing.Tender{{TenderType: "CARD", Tendered: 110}, {TenderType: "CASH", Tendered: 10}},
+			wantErr: "only cash can be over-tendered",
+		},
+		{
+			name:    "short",
+			net:     118,
+			tenders: []pricing.Tender{{TenderType: "CASH", Tendered: 100}, {TenderType: "UPI", Tendered: 17.99}},
+			wantErr: "short of net payable",
+		},
+		{
+			name:    "no tenders",
+			net:     118,
+			wantErr: "At least one payment",
+		},
+		{
+			name:    "zero tender",
+			net:     118,
+			tenders: []pricing.Tender{{TenderType: "CASH", Tendered: 118}, {TenderType: "UPI", Tendered: 0}},
+			wantErr: "must be positive",
+		},
+		{
+			name:    "unknown tender type",
+			net:     118,
+			tenders: []pricing.Tender{{TenderType: "CHEQUE", Tendered: 118}},
+			wantErr: "Unsupported tender type",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payments, mode, err := pricing.Settle(tt.net, tt.tenders)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Settle: %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Settle: %v", err)
+			}
+			if mode != tt.wantMode {
+				t.Errorf("mode %s, want %s", mode, tt.wantMode)
+			}
+			if len(payments) != len(tt.want) {
+				t.Fatalf("%d payments, want %d", len(payments), len(tt.want))
+			}
+
+			var applied float64
+			for i, p := range payments {
+				want := tt.want[i]
+				if p.TenderType != want.tenderType || p.Amount != want.amount || p.ChangeReturned != want.change {
+					t.Errorf("payment %d: %s %.2f with %.2f change, want %s %.2f with %.2f change",
+						i, p.TenderType, p.Amount, p.ChangeReturned, want.tenderType, want.amount, want.change)
+				}
+				if p.Tendered != pricing.Round2(p.Amount+p.ChangeReturned) {
+					t.Errorf("payment %d: tendered %.2f is not amount %.2f plus change %.2f", i, p.Tendered, p.Amount, p.ChangeReturned)
+				}
+				applied += p.Amount
+			}
+			if pricing.Round2(applied) != tt.net {
+				t.Errorf("payments apply %.2f, want the net payable %.2f", applied, tt.net)
+			}
+		})
+	}
+}
@@ -0,0 +1,356 @@
+package sequence
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"billing-app/config"
+	"billing-app/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Reset policies
+const (
+	ResetDaily         = "DAILY"
+	ResetFinancialYear = "FINANCIAL_YEAR" // April to March
+	ResetNever         = "NEVER"
+)
+
+// Definition describes one document number series.
+//
+// Format tokens: {PREFIX}, {YYYY}, {YY}, {MM}, {DD}, {FY} (e.g. 2026-27),
+// {FYS} (e.g. 2627) and {SEQ} or {SEQ:n} for the counter zero-padded to n digits.
+type Definition struct {
+	Name   string
+	Prefix string
+	Format string
+	Reset  string
+}
+
+// Bill is the series used for bill numbers
+func Bill() Definition {
+	cfg := config.AppConfig.Sequences
+	return Definition{
+		Name:   "bill",
+		Prefix: config.AppConfig.Defaults.BillerPrefix,
+		Format: withDefault(cfg.BillNoFormat, "{PREFIX}-{YYYY}{MM}{DD}-{SEQ:5}"),
+		Reset:  withDefault(cfg.BillNoReset, ResetDaily),
+	}
+}
+
+// Order is the series used for public customer orders
+func Order() Definition {
+	cfg := config.AppConfig.Sequences
+	return Definition{
+		Name:   "order",
+		Prefix: "ORD",
+		Format: withDefault(cfg.OrderNoFormat, "{PREFIX}-{YYYY}{MM}{DD}-{SEQ:4}"),
+		Reset:  withDefault(cfg.OrderNoReset, ResetDaily),
+	}
+}
+
+// CreditNote is the series used for bill cancellations and returns
+func CreditNote() Definition {
+	cfg := config.AppConfig.Sequences
+	return Definition{
+		Name:   "credit_note",
+		Prefix: "CN",
+		Format: withDefault(cfg.CreditNoteNoFormat, "{PREFIX}-{FY}-{SEQ:5}"),
+		Reset:  withDefault(cfg.CreditNoteNoReset, ResetFinancialYear),
+	}
+}
+
+// PurchaseOrder is the series used for purchase orders to suppliers
+func PurchaseOrder() Definition {
+	cfg := config.AppConfig.Sequences
+	return Definition{
+		Name:   "purchase_order",
+		Prefix: "PO",
+		Format: withDefault(cfg.PONoFormat, "{PREFIX}-{FY}-{SEQ:5}"),
+		Reset:  withDefault(cfg.PONoReset, ResetFinancialYear),
+	}
+}
+
+// StockTake is the series used for physical stock count sheets
+func StockTake() Definition {
+	cfg := config.AppConfig.Sequences
+	return Definition{
+		Name:   "stock_take",
+		Prefix: "ST",
+		Format: withDefault(cfg.StockTakeNoFormat, "{PREFIX}-{FY}-{SEQ:4}"),
+		Reset:  withDefault(cfg.StockTakeNoReset, ResetFinancialYear),
+	}
+}
+
+// Transfer is the series used for inter-store stock transfers
+func Transfer() Definition {
+	cfg := config.AppConfig.Sequences
+	return Definition{
+		Name:   "transfer",
+		Prefix: "TR",
+		Format: withDefault(cfg.TransferNoFormat, "{PREFIX}-{FY}-{SEQ:5}"),
+		Reset:  withDefault(cfg.TransferNoReset, ResetFinancialYear),
+	}
+}
+
+// Definitions returns every configured document series
+func Definitions() []Definition {
+	return []Definition{Bill(), Order(), CreditNote(), PurchaseOrder(), StockTake(), Transfer()}
+}
+
+// Validate checks every configured series, so a bad format stops the server
+// at boot rather than on the first document
+func Validate() error {
+	var errs []error
+	for _, def := range Definitions() {
+		if err := def.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s number format %q: %w", def.Name, def.Format, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks that the format only uses known tokens, has a {SEQ} and
+// shows the period its counter resets on. A daily counter in a number without
+// the date would hand out the same number every day.
+func (d Definition) Validate() error {
+	tokens := map[string]bool{}
+	for _, m := range tokenPattern.FindAllStringSubmatch(d.Format, -1) {
+		switch m[1] {
+		case "PREFIX", "YYYY", "YY", "MM", "DD", "FY", "FYS", "SEQ":
+			tokens[m[1]] = true
+		default:
+			return fmt.Errorf("unknown token {%s}", m[1])
+		}
+	}
+	if !tokens["SEQ"] {
+		return errors.New("missing {SEQ}")
+	}
+
+	year := tokens["YYYY"] || tokens["YY"]
+	switch d.Reset {
+	case ResetDaily:
+		if !year || !tokens["MM"] || !tokens["DD"] {
+			return errors.New("DAILY reset needs the date: a year ({YYYY} or {YY}), {MM} and {DD}")
+		}
+	case ResetFinancialYear:
+		if !tokens["FY"] && !tokens["FYS"] && !(year && tokens["MM"]) {
+			return errors.New("FINANCIAL_YEAR reset needs {FY}, {FYS}, or a year and {MM}")
+		}
+	case ResetNever:
+	default:
+		return fmt.Errorf("unknown reset policy %q", d.Reset)
+	}
+	return nil
+}
+
+// Next reserves the next number of the series. It must run inside the
+// transaction that stores the document: the counter row stays locked until
+// commit, and a rollback returns the number so the series stays gap-free.
+func Next(tx *gorm.DB, def Definition, now time.Time) (string, error) {
+	period := def.Period(now)
+
+	bump := func() (int64, error) {
+		res := tx.Model(&models.Sequence{}).
+			Where("name = ? AND period = ?", def.Name, period).
+			Update("last_value", gorm.Expr("last_value + 1"))
+		return res.RowsAffected, res.Error
+	}
+
+	affected, err := bump()
+	if err != nil {
+		return "", err
+	}
+	if affected == 0 {
+		// First number of the period; a concurrent creator is tolerated by DO NOTHING
+		row := models.Sequence{Name: def.Name, Period: period}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+			return "", err
+		}
+		if _, err := bump(); err != nil {
+			return "", err
+		}
+	}
+
+	var seq models.Sequence
+	if err := tx.Where("name = ? AND period = ?", def.Name, period).First(&seq).Error; err != nil {
+		return "", err
+	}
+	return def.Render(now, seq.LastValue), nil
+}
+
+// Peek returns the number the next document would get without reserving it.
+// It is only a preview; the actual number is assigned by Next on save.
+func Peek(db *gorm.DB, def Definition, now time.Time) string {
+	var seq models.Sequence
+	db.Where("name = ? AND period = ?", def.Name, def.Period(now)).Limit(1).Find(&seq)
+	return def.Render(now, seq.LastValue+1)
+}
+
+// Period returns the counter bucket for now under the reset policy
+func (d Definition) Period(now time.Time) string {
+	switch d.Reset {
+	case ResetDaily:
+		return now.Format("20060102")
+	case ResetFinancialYear:
+		return financialYear(now)
+	default:
+		return "ALL"
+	}
+}
+
+var tokenPattern = regexp.MustCompile(`\{([A-Z]+)(?::(\d+))?\}`)
+
+// Render formats value as a document number
+func (d Definition) Render(now time.Time, value int) string {
+	return tokenPattern.ReplaceAllStringFunc(d.Format, func(token string) string {
+		m := tokenPattern.FindStringSubmatch(token)
+		switch m[1] {
+		case "PREFIX":
+			return d.Prefix
+		case "YYYY":
+			return now.Format("2006")
+		case "YY":
+			return now.Format("06")
+		case "MM":
+			return now.Format("01")
+		case "DD":
+			return now.Format("02")
+		case "FY":
+			return financialYear(now)
+		case "FYS":
+			return strings.ReplaceAll(financialYear(now), "-", "")[2:]
+		case "SEQ":
+			width, _ := strconv.Atoi(m[2])
+			return fmt.Sprintf("%0*d", width, value)
+		}
+		return token
+	})
+}
+
+// Parse reads a number rendered from the format back into the period it was
+// issued in and its counter value. ok is false for numbers in another format.
+func (d Definition) Parse(no string) (period string, value int, ok bool) {
+	return d.parser()(no)
+}
+
+// parser compiles the format into a matcher for Parse
+func (d Definition) parser() func(no string) (string, int, bool) {
+	var fields []string
+	pattern := "^"
+	last := 0
+	for _, loc := range tokenPattern.FindAllStringSubmatchIndex(d.Format, -1) {
+		pattern += regexp.QuoteMeta(d.Format[last:loc[0]])
+		last = loc[1]
+		token := d.Format[loc[2]:loc[3]]
+		switch token {
+		case "PREFIX":
+			pattern += regexp.QuoteMeta(d.Prefix)
+			continue
+		case "YYYY", "FYS":
+			pattern += `(\d{4})`
+		case "YY", "MM", "DD":
+			pattern += `(\d{2})`
+		case "FY":
+			pattern += `(\d{4}-\d{2})`
+		case "SEQ":
+			pattern += `(\d+)`
+		default:
+			pattern += regexp.QuoteMeta(d.Format[loc[0]:loc[1]])
+			continue
+		}
+		fields = append(fields, token)
+	}
+	re := regexp.MustCompile(pattern + regexp.QuoteMeta(d.Format[last:]) + "$")
+
+	return func(no string) (string, int, bool) {
+		m := re.FindStringSubmatch(no)
+		if m == nil {
+			return "", 0, false
+		}
+		year, month, day, value := 0, 1, 1, 0
+		fy := ""
+		for i, token := range fields {
+			v := m[i+1]
+			n, _ := strconv.Atoi(v)
+			switch token {
+			case "YYYY":
+				year = n
+			case "YY":
+				year = 2000 + n
+			case "MM":
+				month = n
+			case "DD":
+				day = n
+			case "FY":
+				fy = v
+			case "FYS":
+				fy = "20" + v[:2] + "-" + v[2:]
+			case "SEQ":
+				value = n
+			}
+		}
+
+		if d.Reset == ResetFinancialYear && fy != "" {
+			return fy, value, true
+		}
+		if d.Reset != ResetNever && year == 0 {
+			return "", 0, false
+		}
+		return d.Period(time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local)), value, true
+	}
+}
+
+// Seed raises the series' counters to the highest numbers already stored in
+// column of table, so numbers issued before the series existed are not
+// issued again. Numbers not in the series' format are ignored.
+func Seed(tx *gorm.DB, def Definition, table, column string) error {
+	var numbers []string
+	if err := tx.Table(table).Pluck(column, &numbers).Error; err != nil {
+		return err
+	}
+
+	parse := def.parser()
+	highest := map[string]int{}
+	for _, no := range numbers {
+		if period, value, ok := parse(no); ok && value > highest[period] {
+			highest[period] = value
+		}
+	}
+
+	for period, value := range highest {
+		row := models.Sequence{Name: def.Name, Period: period}
+		if err := tx.Where(row).FirstOrCreate(&row).Error; err != nil {
+			return err
+		}
+		if row.LastValue >= value {
+			continue
+		}
+		if err := tx.Model(&row).Update("last_value", value).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// financialYear returns the Indian financial year label, e.g. 2026-27
+func financialYear(now time.Time) string {
+	start := now.Year()
+	if now.Month() < time.April {
+		start--
+	}
+	return fmt.Sprintf("%d-%02d", start, (start+1)%100)
+}
+
+func withDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,108 @@
+package sequence_test
+
+import (
+	"testing"
+	"time"
+
+	"billing-app/internal/models"
+	"billing-app/internal/sequence"
+	"billing-app/internal/service/servicetest"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		reset  string
+		ok     bool
+	}{
+		{"daily with date", "{PREFIX}-{YYYY}{MM}{DD}-{SEQ:5}", sequence.ResetDaily, true},
+		{"daily with short year", "{PREFIX}{YY}{MM}{DD}{SEQ:4}", sequence.ResetDaily, true},
+		{"daily without date", "{PREFIX}-{SEQ:5}", sequence.ResetDaily, false},
+		{"daily without day", "{PREFIX}-{YYYY}{MM}-{SEQ:5}", sequence.ResetDaily, false},
+		{"daily with financial year", "{PREFIX}-{FY}-{SEQ:5}", sequence.ResetDaily, false},
+		{"financial year", "{PREFIX}-{FY}-{SEQ:5}", sequence.ResetFinancialYear, true},
+		{"financial year short", "{PREFIX}{FYS}{SEQ:5}", sequence.ResetFinancialYear, true},
+		{"financial year from month", "{PREFIX}-{YYYY}{MM}-{SEQ:5}", sequence.ResetFinancialYear, true},
+		{"financial year from year alone", "{PREFIX}-{YYYY}-{SEQ:5}", sequence.ResetFinancialYear, false},
+		{"never", "{PREFIX}-{SEQ:6}", sequence.ResetNever, true},
+		{"no counter", "{PREFIX}-{YYYY}{MM}{DD}", sequence.ResetDaily, false},
+		{"unknown token", "{PREFIX}-{DATE}-{SEQ}", sequence.ResetNever, false},
+		{"unknown reset", "{PREFIX}-{SEQ}", "WEEKLY", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := sequence.Definition{Name: "test", Prefix: "T", Format: tt.format, Reset: tt.reset}
+			if err := def.Validate(); (err == nil) != tt.ok {
+				t.Errorf("Validate() = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParseReadsRenderedNumbers(t *testing.T) {
+	now := time.Date(2027, 2, 9, 10, 0, 0, 0, time.Local)
+	tests := []struct {
+		format string
+		reset  string
+		period string
+	}{
+		{"{PREFIX}-{YYYY}{MM}{DD}-{SEQ:5}", sequence.ResetDaily, "20270209"},
+		{"{PREFIX}{YY}{MM}{DD}{SEQ:3}", sequence.ResetDaily, "20270209"},
+		{"{PREFIX}/{FY}/{SEQ:5}", sequence.ResetFinancialYear, "2026-27"},
+		{"{PREFIX}{FYS}{SEQ:4}", sequence.ResetFinancialYear, "2026-27"},
+		{"{PREFIX}-{YYYY}{MM}-{SEQ}", sequence.ResetFinancialYear, "2026-27"},
+		{"{PREFIX}-{SEQ:6}", sequence.ResetNever, "ALL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			def := sequence.Definition{Name: "test", Prefix: "INV", Format: tt.format, Reset: tt.reset}
+			no := def.Render(now, 1234)
+			period, value, ok := def.Parse(no)
+			if !ok || period != tt.period || value != 1234 {
+				t.Errorf("Parse(%q) = %q, %d, %v; want %q, 1234, true", no, period, value, ok, tt.period)
+			}
+			if _, _, ok := def.Parse("X" + no); ok {
+				t.Errorf("Parse accepted %q", "X"+no)
+			}
+		})
+	}
+}
+
+// Bills numbered before the sequence existed carried their row ID; the
+// seeded counter must continue above them rather than restart at 1.
+func TestSeedContinuesAboveExistingNumbers(t *testing.T) {
+	env, err := servicetest.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+
+	for _, no := range []string{"B-20261015-00040", "B-20261016-00041", "B-20261016-00042", "legacy-7"} {
+		bill := models.Bill{BillNo: no, UserID: env.Admin.ID, PaymentMode: "CASH", Status: "PAID"}
+		if err := env.DB.Create(&bill).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sequence.Seed(env.DB, sequence.Bill(), "bills", "bill_no"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		day  int
+		want string
+	}{
+		{15, "B-20261015-00041"},
+		{16, "B-20261016-00043"},
+		{17, "B-20261017-00001"},
+	}
+	for _, tt := range tests {
+		got, err := sequence.Next(env.DB, sequence.Bill(), time.Date(2026, 10, tt.day, 12, 0, 0, 0, time.Local))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Next on the %dth = %q, want %q", tt.day, got, tt.want)
+		}
+	}
+}
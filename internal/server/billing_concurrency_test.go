@@ -0,0 +1,81 @@
+package server_test
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"billing-app/internal/models"
+	"billing-app/internal/server/servertest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestConcurrentBillsDoNotOversell races more billers than there are units
+// for the last stock of a product: each unit is sold exactly once and the
+// ledger agrees with the product's stock.
+func TestConcurrentBillsDoNotOversell(t *testing.T) {
+	const stock, billers = 5, 20
+
+	h, err := servertest.NewFile(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	product, err := h.Product("Last Units", 100, stock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := h.Token("biller")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := gin.H{
+		"payment_mode": "CASH",
+		"items":        []gin.H{{"product_id": product.ID, "quantity": 1}},
+	}
+
+	codes := make(chan int, billers)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < billers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			rec := h.Do(http.MethodPost, "/api/v1/billing/bills", token, body)
+			if rec.Code != http.StatusCreated && rec.Code != http.StatusConflict {
+				t.Errorf("bill: %d %s", rec.Code, rec.Body.String())
+			}
+			codes <- rec.Code
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(codes)
+
+	count := map[int]int{}
+	for code := range codes {
+		count[code]++
+	}
+	if count[http.StatusCreated] != stock || count[http.StatusConflict] != billers-stock {
+		t.Errorf("got %d created and %d conflicts, want %d and %d",
+			count[http.StatusCreated], count[http.StatusConflict], stock, billers-stock)
+	}
+
+	if err := h.DB.First(&product, product.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if product.CurrentStock != 0 {
+		t.Errorf("current_stock = %d, want 0", product.CurrentStock)
+	}
+
+	var ledger int
+	h.DB.Model(&models.StockMovement{}).Where("product_id = ?", product.ID).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&ledger)
+	if ledger != product.CurrentStock {
+		t.Errorf("ledger sums to %d, current_stock is %d", ledger, product.CurrentStock)
+	}
+}
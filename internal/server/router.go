@@ -0,0 +1,217 @@
+// Package server builds the HTTP API: CORS, authentication middleware and
+// every route group, wired to the handlers and the services behind them.
+package server
+
+import (
+	"time"
+
+	"billing-app/internal/handler"
+	"billing-app/internal/middleware"
+	"billing-app/internal/service"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// Deps are the services the routes are built on
+type Deps struct {
+	Services *service.Services
+	Bills    *InFlight // Optional; counts billing writes so shutdown can drain them
+}
+
+// NewRouter registers every route group with its middleware
+func NewRouter(deps Deps) *gin.Engine {
+	svc := deps.Services
+	r := gin.Default()
+
+	// CORS Configuration
+	r.Use(cors.New(cors.Config{
+		AllowAllOrigins:  true,
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		ExposeHeaders:    []string{"Content-Length"},
+		AllowCredentials: true,
+		MaxAge:           12 * time.Hour,
+	}))
+
+	// Routes
+	authHandler := handler.NewAuthHandler(svc.Users)
+	authRoutes := r.Group("/api/v1/auth")
+	{
+		authRoutes.POST("/login", authHandler.Login)
+	}
+
+	userRoutes := r.Group("/api/v1/user")
+	userRoutes.Use(middleware.AuthMiddleware())
+	{
+		userRoutes.PUT("/password", authHandler.ChangePassword)
+	}
+
+	adminHandler := handler.NewAdminHandler(svc.Users)
+	storeHandler := handler.NewStoreHandler(svc.Stores)
+	adminRoutes := r.Group("/api/v1/admin")
+	adminRoutes.Use(middleware.AuthMiddleware("admin"))
+	{
+		adminRoutes.POST("/employees", adminHandler.CreateEmployee)
+		adminRoutes.GET("/employees", adminHandler.ListEmployees)
+		adminRoutes.PUT("/employees/:id", adminHandler.UpdateEmployee)
+		adminRoutes.PUT("/employees/:id/role", adminHandler.UpdateEmployeeRole)
+		adminRoutes.PUT("/employees/:id/status", adminHandler.UpdateEmployeeStatus)
+		adminRoutes.PUT("/employees/:id/password", adminHandler.ResetEmployeePassword)
+		adminRoutes.PUT("/employees/:id/store", adminHandler.UpdateEmployeeStore)
+		adminRoutes.POST("/stores", storeHandler.CreateStore)
+		adminRoutes.PUT("/stores/:id", storeHandler.UpdateStore)
+		adminRoutes.GET("/login-history", adminHandler.GetLoginHistory)
+		adminRoutes.GET("/dashboard", adminHandler.GetDashboardStats)
+	}
+
+	inventoryHandler := handler.NewInventoryHandler(svc.Catalog, svc.Inventory)
+	purchaseHandler := handler.NewPurchaseHandler(svc.Purchases)
+	stockTakeHandler := handler.NewStockTakeHandler(svc.StockTakes, svc.Inventory)
+	transferHandler := handler.NewTransferHandler(svc.Transfers)
+
+	// Public Read (Authenticated)
+	r.GET("/api/v1/inventory/products", middleware.AuthMiddleware(), inventoryHandler.ListProducts)
+	r.GET("/api/v1/inventory/brands", middleware.AuthMiddleware(), inventoryHandler.ListBrands)
+	r.GET("/api/v1/inventory/categories", middleware.AuthMiddleware(), inventoryHandler.ListCategories) // Added
+	r.GET("/api/v1/inventory/products/barcode/:code", middleware.AuthMiddleware(), inventoryHandler.LookupBarcode)
+	r.GET("/api/v1/stores", middleware.AuthMiddleware(), storeHandler.ListStores)
+
+	// Protected Inventory Ops
+	invRoutes := r.Group("/api/v1/inventory")
+	invRoutes.Use(middleware.AuthMiddleware("admin", "manager", "inventory"))
+	{
+		invRoutes.POST("/products", inventoryHandler.CreateProduct)
+		invRoutes.POST("/products/import", inventoryHandler.ImportProducts)
+		invRoutes.GET("/products/export", inventoryHandler.ExportProducts)
+		invRoutes.GET("/products/:id", inventoryHandler.GetProduct)
+		invRoutes.PUT("/products/:id", inventoryHandler.UpdateProduct)
+		invRoutes.PATCH("/products/:id", inventoryHandler.UpdateProduct)
+		invRoutes.GET("/products/:id/price-history", inventoryHandler.GetPriceHistory)
+		invRoutes.POST("/products/:id/variants", inventoryHandler.AddVariant)
+		invRoutes.POST("/products/:id/barcodes", inventoryHandler.AddBarcode)
+		invRoutes.DELETE("/products/:id/barcodes/:barcodeId", inventoryHandler.RemoveBarcode)
+		invRoutes.POST("/barcodes/generate", inventoryHandler.GenerateBarcodes)
+		invRoutes.POST("/barcodes/labels", inventoryHandler.PrintLabels)
+		invRoutes.POST("/stock", inventoryHandler.AddStock)
+		invRoutes.GET("/alerts", inventoryHandler.GetLowStockAlerts)
+		invRoutes.GET("/alerts/expiry", inventoryHandler.GetExpiryAlerts)
+		invRoutes.GET("/alerts/expired", inventoryHandler.GetExpiredStock)
+		invRoutes.GET("/products/:id/batches", inventoryHandler.ListBatches)
+		invRoutes.GET("/products/:id/movements", inventoryHandler.ListStockMovements)
+		invRoutes.GET("/products/:id/stock", inventoryHandler.GetStockAsOf)
+		invRoutes.GET("/stock/consistency", inventoryHandler.CheckStockConsistency)
+		invRoutes.GET("/stock/by-store", storeHandler.ListStoreStock)
+		invRoutes.POST("/categories", inventoryHandler.CreateCategory) // Added
+		invRoutes.PUT("/categories/:id", inventoryHandler.UpdateCategory)
+		invRoutes.POST("/brands", inventoryHandler.CreateBrand)
+		invRoutes.PUT("/brands/:id", inventoryHandler.UpdateBrand)
+
+		invRoutes.POST("/suppliers", purchaseHandler.CreateSupplier)
+		invRoutes.GET("/suppliers", purchaseHandler.ListSuppliers)
+		invRoutes.PUT("/suppliers/:id", purchaseHandler.UpdateSupplier)
+		invRoutes.POST("/purchase-orders", purchaseHandler.CreatePurchaseOrder)
+		invRoutes.GET("/purchase-orders", purchaseHandler.ListPurchaseOrders)
+		invRoutes.GET("/purchase-orders/:id", purchaseHandler.GetPurchaseOrder)
+		invRoutes.PUT("/purchase-orders/:id", purchaseHandler.UpdatePurchaseOrder)
+		invRoutes.POST("/purchase-orders/:id/order", purchaseHandler.PlacePurchaseOrder)
+		invRoutes.POST("/purchase-orders/:id/cancel", purchaseHandler.CancelPurchaseOrder)
+		invRoutes.POST("/purchase-orders/:id/receive", purchaseHandler.ReceiveGoods)
+		invRoutes.GET("/reorder/suggestions", purchaseHandler.GetReorderSuggestions)
+		invRoutes.POST("/reorder/drafts", purchaseHandler.CreateReorderDrafts)
+
+		invRoutes.POST("/stock-takes", stockTakeHandler.CreateStockTake)
+		invRoutes.GET("/stock-takes", stockTakeHandler.ListStockTakes)
+		invRoutes.GET("/stock-takes/:id", stockTakeHandler.GetStockTake)
+		invRoutes.POST("/stock-takes/:id/counts", stockTakeHandler.RecordCounts)
+		invRoutes.POST("/stock-takes/:id/submit", stockTakeHandler.SubmitStockTake)
+
+		invRoutes.POST("/transfers", transferHandler.CreateTransfer)
+		invRoutes.GET("/transfers", transferHandler.ListTransfers)
+		invRoutes.GET("/transfers/:id", transferHandler.GetTransfer)
+		invRoutes.POST("/transfers/:id/receive", transferHandler.ReceiveTransfer)
+	}
+
+	// Stock corrections need a manager
+	invManagerRoutes := r.Group("/api/v1/inventory")
+	invManagerRoutes.Use(middleware.AuthMiddleware("admin", "manager"))
+	{
+		invManagerRoutes.DELETE("/products/:id", inventoryHandler.DeleteProduct)
+		invManagerRoutes.DELETE("/categories/:id", inventoryHandler.DeleteCategory)
+		invManagerRoutes.POST("/categories/:id/merge", inventoryHandler.MergeCategory)
+		invManagerRoutes.DELETE("/brands/:id", inventoryHandler.DeleteBrand)
+		invManagerRoutes.POST("/brands/:id/merge", inventoryHandler.MergeBrand)
+		invManagerRoutes.POST("/adjustments", stockTakeHandler.AdjustStock)
+		invManagerRoutes.POST("/stock-takes/:id/approve", stockTakeHandler.ApproveStockTake)
+		invManagerRoutes.POST("/stock-takes/:id/cancel", stockTakeHandler.CancelStockTake)
+		invManagerRoutes.POST("/transfers/:id/cancel", transferHandler.CancelTransfer)
+	}
+
+	managerHandler := handler.NewManagerHandler(svc.Reports, svc.Billing, svc.Orders)
+
+	billingHandler := handler.NewBillingHandler(svc.Billing)
+	shiftHandler := handler.NewShiftHandler(svc.Shifts)
+	billingRoutes := r.Group("/api/v1/billing")
+	billingRoutes.Use(middleware.AuthMiddleware("biller", "manager", "admin"), deps.Bills.Track())
+	{
+		billingRoutes.POST("/bills", billingHandler.CreateBill)
+		billingRoutes.POST("/quote", billingHandler.QuoteBill)
+		billingRoutes.GET("/bills", billingHandler.ListBills)
+		billingRoutes.POST("/bills/:id/cancel", billingHandler.CancelBill)
+		billingRoutes.POST("/bills/:id/returns", billingHandler.ReturnBillItems)
+		billingRoutes.GET("/bills/:id/credit-notes", billingHandler.ListCreditNotes)
+		billingRoutes.GET("/bills/:id/print", billingHandler.PrintBill)
+		billingRoutes.GET("/next-bill-no", billingHandler.GetNextBillNo)
+		billingRoutes.POST("/customers", billingHandler.CreateCustomer)
+		billingRoutes.GET("/customers", billingHandler.SearchCustomers)
+
+		billingRoutes.GET("/my-sales", billingHandler.MyTodaySales)
+		billingRoutes.GET("/discount", billingHandler.GetGlobalDiscount)
+		billingRoutes.GET("/discount-rules", billingHandler.GetDiscountRules)
+
+		billingRoutes.POST("/shifts", shiftHandler.OpenShift)
+		billingRoutes.GET("/shifts/current", shiftHandler.GetCurrentShift)
+		billingRoutes.POST("/shifts/current/cash-movements", shiftHandler.AddCashMovement)
+		billingRoutes.POST("/shifts/current/close", shiftHandler.CloseShift)
+
+		// Shared Order Management for Billers
+		billingRoutes.GET("/orders", managerHandler.ListCustomerOrders)
+		billingRoutes.PUT("/orders/:id/status", managerHandler.UpdateOrderStatus)
+	}
+
+	managerRoutes := r.Group("/api/v1/manager")
+	managerRoutes.Use(middleware.AuthMiddleware("manager", "admin"))
+	{
+		managerRoutes.GET("/reports/sales", managerHandler.GetSalesReport)
+		managerRoutes.GET("/reports/sales/export", managerHandler.ExportSalesReport)
+		managerRoutes.GET("/reports/stores", managerHandler.GetStoreReport)
+		managerRoutes.GET("/reports/categories", managerHandler.GetCategoryReport)
+		managerRoutes.GET("/orders", managerHandler.ListCustomerOrders)
+		managerRoutes.PUT("/orders/:id/status", managerHandler.UpdateOrderStatus)
+		managerRoutes.POST("/settings/discount", managerHandler.SetGlobalDiscount)
+		managerRoutes.GET("/settings/discount", managerHandler.GetGlobalDiscount)
+		managerRoutes.PUT("/customers/:id/discount", managerHandler.UpdateCustomerDiscount)
+		managerRoutes.GET("/customers", managerHandler.GetCustomers)
+		managerRoutes.GET("/dashboard", managerHandler.GetDashboardStats) // Added
+		managerRoutes.GET("/shifts", shiftHandler.ListShifts)
+		managerRoutes.GET("/shifts/:id", shiftHandler.GetShift)
+		managerRoutes.POST("/shifts/:id/approve", shiftHandler.ApproveShift)
+	}
+
+	publicHandler := handler.NewPublicHandler(svc.Catalog, svc.Orders)
+	publicRoutes := r.Group("/api/v1/public")
+	{
+		publicRoutes.GET("/config", publicHandler.GetPublicConfig)
+		publicRoutes.GET("/products", publicHandler.ListPublicProducts)
+		publicRoutes.POST("/orders", publicHandler.SubmitOrder)
+		publicRoutes.GET("/site-info", publicHandler.GetSiteInfo)
+	}
+
+	r.GET("/ping", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"message": "pong",
+		})
+	})
+
+	return r
+}
@@ -0,0 +1,488 @@
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"billing-app/config"
+	"billing-app/internal/models"
+	"billing-app/internal/pricing"
+	"billing-app/internal/server/servertest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newHarness(t *testing.T) *servertest.Harness {
+	t.Helper()
+	h, err := servertest.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Close() })
+	return h
+}
+
+func roleToken(t *testing.T, h *servertest.Harness, role string) string {
+	t.Helper()
+	token, err := h.Token(role)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+type billResponse struct {
+	BillID    uint              `json:"bill_id"`
+	BillNo    string            `json:"bill_no"`
+	Breakdown pricing.Breakdown `json:"breakdown"`
+}
+
+func createBill(t *testing.T, h *servertest.Harness, token string, body gin.H) billResponse {
+	t.Helper()
+	rec := h.Do(http.MethodPost, "/api/v1/billing/bills", token, body)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("bill: %d %s", rec.Code, rec.Body.String())
+	}
+	var bill billResponse
+	if err := servertest.Decode(rec, &bill); err != nil {
+		t.Fatal(err)
+	}
+	return bill
+}
+
+func TestLogin(t *testing.T) {
+	h := newHarness(t)
+	admin := config.AppConfig.Defaults
+
+	roleToken(t, h, "biller")
+	var biller models.User
+	h.DB.Joins("Role").Where("Role.name = ?", "biller").First(&biller)
+	h.DB.Model(&models.User{}).Where("id = ?", biller.ID).Update("is_active", false)
+
+	tests := []struct {
+		name     string
+		body     gin.H
+		want     int
+		wantRole string
+	}{
+		{"valid credentials", gin.H{"employee_id": admin.AdminEmployeeID, "password": admin.AdminPassword}, http.StatusOK, "admin"},
+		{"wrong password", gin.H{"employee_id": admin.AdminEmployeeID, "password": "not-the-password"}, http.StatusUnauthorized, ""},
+		{"unknown employee", gin.H{"employee_id": "NOBODY", "password": admin.AdminPassword}, http.StatusUnauthorized, ""},
+		{"inactive employee", gin.H{"employee_id": biller.EmployeeID, "password": "biller-password"}, http.StatusForbidden, ""},
+		{"missing password", gin.H{"employee_id": admin.AdminEmployeeID}, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := h.Do(http.MethodPost, "/api/v1/auth/login", "", tt.body)
+			if rec.Code != tt.want {
+				t.Fatalf("login: %d %s, want %d", rec.Code, rec.Body.String(), tt.want)
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+			var resp struct {
+				Token string `json:"token"`
+				Role  string `json:"role"`
+			}
+			if err := servertest.Decode(rec, &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Token == "" || resp.Role != tt.wantRole {
+				t.Errorf("token %q, role %q; want a token and role %q", resp.Token, resp.Role, tt.wantRole)
+			}
+			if rec := h.Do(http.MethodGet, "/api/v1/stores", resp.Token, nil); rec.Code != http.StatusOK {
+				t.Errorf("token not accepted: %d %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+// TestRoleEnforcement sends one request to every route group as each role and
+// as nobody. A role the group admits must get past the middleware; the
+// handler's own answer (often 400 for an empty body) does not matter here.
+func TestRoleEnforcement(t *testing.T) {
+	h := newHarness(t)
+	roles := []string{"admin", "manager", "inventory", "biller"}
+	tokens := map[string]string{"": ""}
+	for _, role := range roles {
+		tokens[role] = roleToken(t, h, role)
+	}
+
+	tests := []struct {
+		group   string
+		method  string
+		path    string
+		allowed []string // "" is an anonymous request
+	}{
+		{"auth", http.MethodPost, "/api/v1/auth/login", []string{"", "admin", "manager", "inventory", "biller"}},
+		{"user", http.MethodPut, "/api/v1/user/password", []string{"admin", "manager", "inventory", "biller"}},
+		{"admin", http.MethodGet, "/api/v1/admin/employees", []string{"admin"}},
+		{"admin stores", http.MethodPost, "/api/v1/admin/stores", []string{"admin"}},
+		{"authenticated read", http.MethodGet, "/api/v1/inventory/products", []string{"admin", "manager", "inventory", "biller"}},
+		{"store list", http.MethodGet, "/api/v1/stores", []string{"admin", "manager", "inventory", "biller"}},
+		{"inventory", http.MethodGet, "/api/v1/inventory/alerts", []string{"admin", "manager", "inventory"}},
+		{"inventory writes", http.MethodPost, "/api/v1/inventory/products", []string{"admin", "manager", "inventory"}},
+		{"stock corrections", http.MethodPost, "/api/v1/inventory/adjustments", []string{"admin", "manager"}},
+		{"billing", http.MethodGet, "/api/v1/billing/bills", []string{"admin", "manager", "biller"}},
+		{"billing writes", http.MethodPost, "/api/v1/billing/bills", []string{"admin", "manager", "biller"}},
+		{"manager", http.MethodGet, "/api/v1/manager/reports/sales", []string{"admin", "manager"}},
+		{"manager settings", http.MethodPost, "/api/v1/manager/settings/discount", []string{"admin", "manager"}},
+		{"public", http.MethodGet, "/api/v1/public/products", []string{"", "admin", "manager", "inventory", "biller"}},
+	}
+	for _, tt := range tests {
+		allowed := map[string]bool{}
+		for _, role := range tt.allowed {
+			allowed[role] = true
+		}
+		for _, role := range append([]string{""}, roles...) {
+			name := role
+			if name == "" {
+				name = "anonymous"
+			}
+			t.Run(tt.group+"/"+name, func(t *testing.T) {
+				rec := h.Do(tt.method, tt.path, tokens[role], nil)
+				denied := rec.Code == http.StatusUnauthorized || rec.Code == http.StatusForbidden
+				switch {
+				case allowed[role] && denied:
+					t.Errorf("%s %s: %d %s, want it let through", tt.method, tt.path, rec.Code, rec.Body.String())
+				case !allowed[role] && role == "" && rec.Code != http.StatusUnauthorized:
+					t.Errorf("%s %s: %d, want %d", tt.method, tt.path, rec.Code, http.StatusUnauthorized)
+				case !allowed[role] && role != "" && rec.Code != http.StatusForbidden:
+					t.Errorf("%s %s: %d, want %d", tt.method, tt.path, rec.Code, http.StatusForbidden)
+				}
+			})
+		}
+	}
+}
+
+// TestCreateBillDeductsStock bills one product in turn; each bill takes its
+// quantity off the product, its store and the ledger, or fails and takes nothing.
+func TestCreateBillDeductsStock(t *testing.T) {
+	h := newHarness(t)
+	product, err := h.Product("Rice 1kg", 60, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := h.Product("Dal 1kg", 90, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	biller := roleToken(t, h, "biller")
+
+	stockOf := func(id uint) (current, atStore, ledger int) {
+		var p models.Product
+		if err := h.DB.First(&p, id).Error; err != nil {
+			t.Fatal(err)
+		}
+		h.DB.Model(&models.StoreStock{}).Select("quantity").
+			Where("store_id = ? AND product_id = ?", h.Store.ID, id).Scan(&atStore)
+		h.DB.Model(&models.StockMovement{}).Where("product_id = ?", id).
+			Select("COALESCE(SUM(quantity), 0)").Scan(&ledger)
+		return p.CurrentStock, atStore, ledger
+	}
+
+	tests := []struct {
+		name      string
+		items     []gin.H
+		want      int
+		wantErr   string // In the error body of a refused bill
+		wantStock int
+		wantSold  float64 // Total of every bill so far
+	}{
+		{"sells part of the stock", []gin.H{{"product_id": product.ID, "quantity": 3}}, http.StatusCreated, "", 7, 180},
+		{"sells more than is left", []gin.H{{"product_id": product.ID, "quantity": 8}}, http.StatusConflict, "Insufficient stock", 7, 180},
+		{"rejects a zero quantity", []gin.H{{"product_id": product.ID, "quantity": 0}}, http.StatusBadRequest, "Items[0].Quantity", 7, 180},
+		{"rejects a negative quantity", []gin.H{{"product_id": product.ID, "quantity": -2}}, http.StatusBadRequest, "Items[0].Quantity", 7, 180},
+		{
+			"rejects a negative line beside a positive one",
+			[]gin.H{{"product_id": product.ID, "quantity": 2}, {"product_id": other.ID, "quantity": -1}},
+			http.StatusBadRequest, "Items[1].Quantity", 7, 180,
+		},
+		{"sells the rest", []gin.H{{"product_id": product.ID, "quantity": 7}}, http.StatusCreated, "", 0, 600},
+		{"sells from empty stock", []gin.H{{"product_id": product.ID, "quantity": 1}}, http.StatusConflict, "Insufficient stock", 0, 600},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := h.Do(http.MethodPost, "/api/v1/billing/bills", biller, gin.H{
+				"payment_mode": "CASH",
+				"items":        tt.items,
+			})
+			if rec.Code != tt.want {
+				t.Fatalf("bill: %d %s, want %d", rec.Code, rec.Body.String(), tt.want)
+			}
+			if tt.wantErr != "" && !strings.Contains(rec.Body.String(), tt.wantErr) {
+				t.Errorf("bill: %s, want an error about %q", rec.Body.String(), tt.wantErr)
+			}
+
+			if current, atStore, ledger := stockOf(product.ID); current != tt.wantStock || atStore != tt.wantStock || ledger != tt.wantStock {
+				t.Errorf("current_stock %d, store stock %d, ledger %d; want %d", current, atStore, ledger, tt.wantStock)
+			}
+			if current, atStore, ledger := stockOf(other.ID); current != 10 || atStore != 10 || ledger != 10 {
+				t.Errorf("%s: current_stock %d, store stock %d, ledger %d; want 10", other.Name, current, atStore, ledger)
+			}
+			var sold float64
+			h.DB.Model(&models.Bill{}).Select("COALESCE(SUM(total_amount), 0)").Scan(&sold)
+			if sold != tt.wantSold {
+				t.Errorf("bills total %.2f, want %.2f", sold, tt.wantSold)
+			}
+		})
+	}
+
+	var sales int64
+	h.DB.Model(&models.StockMovement{}).Where("product_id = ? AND type = ?", product.ID, models.MovementSale).Count(&sales)
+	if sales != 2 {
+		t.Errorf("%d sale movements, want 2", sales)
+	}
+}
+
+func TestSubmitPublicOrder(t *testing.T) {
+	h := newHarness(t)
+	product, err := h.Product("Tea 250g", 120, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		body      gin.H
+		want      int
+		wantTotal float64
+	}{
+		{
+			"new customer",
+			gin.H{"customer_name": "Asha", "customer_mobile": "9876543210", "address": "12 Main Road",
+				"items": []gin.H{{"product_id": product.ID, "quantity": 2}}},
+			http.StatusCreated, 240,
+		},
+		{
+			"returning customer",
+			gin.H{"customer_name": "Asha", "customer_mobile": "9876543210",
+				"items": []gin.H{{"product_id": product.ID, "quantity": 1}}},
+			http.StatusCreated, 120,
+		},
+		{
+			"unknown product",
+			gin.H{"customer_name": "Ravi", "customer_mobile": "9123456780",
+				"items": []gin.H{{"product_id": product.ID + 100, "quantity": 1}}},
+			http.StatusBadRequest, 0,
+		},
+		{
+			"missing customer name",
+			gin.H{"customer_mobile": "9123456780", "items": []gin.H{{"product_id": product.ID, "quantity": 1}}},
+			http.StatusBadRequest, 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := h.Do(http.MethodPost, "/api/v1/public/orders", "", tt.body)
+			if rec.Code != tt.want {
+				t.Fatalf("order: %d %s, want %d", rec.Code, rec.Body.String(), tt.want)
+			}
+			if tt.want != http.StatusCreated {
+				return
+			}
+
+			var resp struct {
+				OrderNo     string `json:"order_no"`
+				WhatsappURL string `json:"whatsapp_url"`
+			}
+			if err := servertest.Decode(rec, &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.WhatsappURL == "" {
+				t.Error("no WhatsApp link")
+			}
+
+			var order models.CustomerOrder
+			if err := h.DB.Preload("Items").Where("order_no = ?", resp.OrderNo).First(&order).Error; err != nil {
+				t.Fatalf("order %q not saved: %v", resp.OrderNo, err)
+			}
+			if order.Status != "PENDING" || order.TotalEstimated != tt.wantTotal || len(order.Items) != 1 {
+				t.Errorf("order %s, total %.2f, %d items; want PENDING, %.2f, 1 item",
+					order.Status, order.TotalEstimated, len(order.Items), tt.wantTotal)
+			}
+			if order.StoreID == nil || *order.StoreID != h.Store.ID {
+				t.Errorf("order store %v, want the default store %d", order.StoreID, h.Store.ID)
+			}
+		})
+	}
+
+	var customers int64
+	h.DB.Model(&models.Customer{}).Where("mobile = ?", "9876543210").Count(&customers)
+	if customers != 1 {
+		t.Errorf("%d customers for one mobile, want 1", customers)
+	}
+	// Orders are fulfilled through billing; placing one takes no stock
+	if err := h.DB.First(&product, product.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if product.CurrentStock != 5 {
+		t.Errorf("current_stock = %d, want 5", product.CurrentStock)
+	}
+}
+
+// TestDiscounts sets a global discount and a customer's own discount through
+// the manager routes; a bill takes the larger of the two.
+func TestDiscounts(t *testing.T) {
+	h := newHarness(t)
+	product, err := h.Product("Oil 1L", 200, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager := roleToken(t, h, "manager")
+	biller := roleToken(t, h, "biller")
+
+	customer := func(name, mobile string) uint {
+		rec := h.Do(http.MethodPost, "/api/v1/billing/customers", biller, gin.H{"name": name, "mobile": mobile})
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("customer: %d %s", rec.Code, rec.Body.String())
+		}
+		var c models.Customer
+		if err := servertest.Decode(rec, &c); err != nil {
+			t.Fatal(err)
+		}
+		return c.ID
+	}
+	regular := customer("Regular", "9000000001")
+	loyal := customer("Loyal", "9000000002")
+	modest := customer("Modest", "9000000003")
+
+	if rec := h.Do(http.MethodPost, "/api/v1/manager/settings/discount", manager, gin.H{"percentage": 10}); rec.Code != http.StatusOK {
+		t.Fatalf("global discount: %d %s", rec.Code, rec.Body.String())
+	}
+	for id, percent := range map[uint]float64{loyal: 15, modest: 5} {
+		rec := h.Do(http.MethodPut, fmt.Sprintf("/api/v1/manager/customers/%d/discount", id), manager, gin.H{"discount_percent": percent})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("customer discount: %d %s", rec.Code, rec.Body.String())
+		}
+	}
+	rec := h.Do(http.MethodPut, fmt.Sprintf("/api/v1/manager/customers/%d/discount", regular), manager, gin.H{"discount_percent": 150})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("150%% customer discount: %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	rec = h.Do(http.MethodGet, "/api/v1/billing/discount", biller, nil)
+	var global struct {
+		Percentage float64 `json:"percentage"`
+	}
+	if err := servertest.Decode(rec, &global); err != nil {
+		t.Fatal(err)
+	}
+	if global.Percentage != 10 {
+		t.Errorf("billing sees a %.2f%% global discount, want 10", global.Percentage)
+	}
+
+	tests := []struct {
+		name        string
+		customerID  *uint
+		wantPercent float64
+		wantSource  string
+	}{
+		{"walk-in gets the global discount", nil, 10, pricing.SourceGlobal},
+		{"customer without a discount gets the global one", &regular, 10, pricing.SourceGlobal},
+		{"larger customer discount wins", &loyal, 15, pricing.SourceCustomer},
+		{"smaller customer discount loses", &modest, 10, pricing.SourceGlobal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bill := createBill(t, h, biller, gin.H{
+				"customer_id":  tt.customerID,
+				"payment_mode": "CASH",
+				"items":        []gin.H{{"product_id": product.ID, "quantity": 1}},
+			})
+			b := bill.Breakdown
+			if b.DiscountPercent != tt.wantPercent || b.DiscountSource != tt.wantSource {
+				t.Errorf("discount %.2f%% from %s, want %.2f%% from %s", b.DiscountPercent, b.DiscountSource, tt.wantPercent, tt.wantSource)
+			}
+			if want := pricing.Round2(b.TotalAmount * tt.wantPercent / 100); b.DiscountAmount != want {
+				t.Errorf("discount amount %.2f, want %.2f", b.DiscountAmount, want)
+			}
+
+			var saved models.Bill
+			if err := h.DB.First(&saved, bill.BillID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if saved.DiscountAmount != b.DiscountAmount || saved.NetPayable != b.NetPayable {
+				t.Errorf("saved discount %.2f, net %.2f; quoted %.2f, %.2f",
+					saved.DiscountAmount, saved.NetPayable, b.DiscountAmount, b.NetPayable)
+			}
+		})
+	}
+}
+
+// TestSalesReport bills at the default store and reads the sales report back
+// over a date range and per store.
+func TestSalesReport(t *testing.T) {
+	h := newHarness(t)
+	product, err := h.Product("Soap", 40, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := models.Store{Code: "BR2", Name: "Branch 2"}
+	if err := h.DB.Create(&other).Error; err != nil {
+		t.Fatal(err)
+	}
+	biller := roleToken(t, h, "biller")
+	manager := roleToken(t, h, "manager")
+
+	var revenue float64
+	for _, quantity := range []int{1, 2, 3} {
+		bill := createBill(t, h, biller, gin.H{
+			"payment_mode": "CASH",
+			"items":        []gin.H{{"product_id": product.ID, "quantity": quantity}},
+		})
+		revenue += bill.Breakdown.NetPayable
+	}
+	revenue = pricing.Round2(revenue)
+
+	today := time.Now().Format("2006-01-02")
+
+	tests := []struct {
+		name      string
+		query     string
+		wantBills int
+		wantSold  int
+		wantTotal float64
+	}{
+		{"all time", "", 3, 6, revenue},
+		{"today", "?start_date=" + today + "&end_date=" + today, 3, 6, revenue},
+		{"before any sale", "?start_date=2000-01-01&end_date=2000-01-31", 0, 0, 0},
+		{"default store", fmt.Sprintf("?store_id=%d", h.Store.ID), 3, 6, revenue},
+		{"store without sales", fmt.Sprintf("?store_id=%d", other.ID), 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := h.Do(http.MethodGet, "/api/v1/manager/reports/sales"+tt.query, manager, nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("report: %d %s", rec.Code, rec.Body.String())
+			}
+			var report struct {
+				Summary struct {
+					TotalRevenue      float64 `json:"total_revenue"`
+					TotalTransactions int     `json:"total_transactions"`
+					ProductsSold      int     `json:"products_sold"`
+				} `json:"summary"`
+				Tenders map[string]float64 `json:"tenders"`
+			}
+			if err := servertest.Decode(rec, &report); err != nil {
+				t.Fatal(err)
+			}
+			s := report.Summary
+			if s.TotalTransactions != tt.wantBills || s.ProductsSold != tt.wantSold || pricing.Round2(s.TotalRevenue) != tt.wantTotal {
+				t.Errorf("%d bills, %d sold, revenue %.2f; want %d, %d, %.2f",
+					s.TotalTransactions, s.ProductsSold, s.TotalRevenue, tt.wantBills, tt.wantSold, tt.wantTotal)
+			}
+			if pricing.Round2(report.Tenders["CASH"]) != tt.wantTotal {
+				t.Errorf("cash tendered %.2f, want %.2f", report.Tenders["CASH"], tt.wantTotal)
+			}
+		})
+	}
+
+	rec := h.Do(http.MethodGet, "/api/v1/manager/reports/stores", manager, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("store report: %d %s", rec.Code, rec.Body.String())
+	}
+}
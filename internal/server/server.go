@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"billing-app/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Options configure the HTTP listener
+type Options struct {
+	Addr            string // e.g. ":8080"
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	ShutdownTimeout time.Duration // Run's limit on waiting for open requests; 0 waits indefinitely
+}
+
+// OptionsFromConfig listens on the configured port with the configured timeouts
+func OptionsFromConfig(cfg config.ServerConfig) Options {
+	return Options{
+		Addr:            ":" + cfg.Port,
+		ReadTimeout:     cfg.ReadTimeout,
+		WriteTimeout:    cfg.WriteTimeout,
+		ShutdownTimeout: cfg.ShutdownTimeout,
+	}
+}
+
+// InFlight counts requests that must run to completion before the process
+// exits, like a bill being saved. A nil InFlight tracks nothing.
+type InFlight struct {
+	wg sync.WaitGroup
+	n  atomic.Int64
+}
+
+// Track counts the writes (anything but GET) passing through it
+func (f *InFlight) Track() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if f == nil || c.Request.Method == http.MethodGet {
+			c.Next()
+			return
+		}
+		f.wg.Add(1)
+		f.n.Add(1)
+		defer func() {
+			f.n.Add(-1)
+			f.wg.Done()
+		}()
+		c.Next()
+	}
+}
+
+// Count is the number of tracked requests still running
+func (f *InFlight) Count() int64 {
+	return f.n.Load()
+}
+
+// Wait blocks until every tracked request has finished
+func (f *InFlight) Wait() {
+	f.wg.Wait()
+}
+
+// Server serves the API built by NewRouter
+type Server struct {
+	Router *gin.Engine
+	Bills  *InFlight // Billing writes drained on shutdown
+
+	http *http.Server
+	opts Options
+}
+
+func New(deps Deps, opts Options) *Server {
+	if deps.Bills == nil {
+		deps.Bills = &InFlight{}
+	}
+	router := NewRouter(deps)
+	return &Server{
+		Router: router,
+		Bills:  deps.Bills,
+		http: &http.Server{
+			Addr:         opts.Addr,
+			Handler:      router,
+			ReadTimeout:  opts.ReadTimeout,
+			WriteTimeout: opts.WriteTimeout,
+		},
+		opts: opts,
+	}
+}
+
+// Start listens until Shutdown; it returns nil once shut down
+func (s *Server) Start() error {
+	if err := s.http.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting connections and waits for open requests until ctx
+// is done. Bills being saved are always waited for, even past ctx, so a
+// customer is never charged without their bill being answered.
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.http.Shutdown(ctx)
+	if n := s.Bills.Count(); n > 0 {
+		log.Printf("Waiting for %d bill(s) in progress", n)
+	}
+	s.Bills.Wait()
+	return err
+}
+
+// Run starts the server and shuts it down gracefully on SIGINT or SIGTERM
+func (s *Server) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() { errc <- s.Start() }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+	log.Println("Shutting down, draining open requests")
+
+	shutdownCtx := context.Background()
+	if s.opts.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, s.opts.ShutdownTimeout)
+		defer cancel()
+	}
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	log.Println("Server stopped")
+	return <-errc
+}
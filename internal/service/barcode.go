@@ -0,0 +1,156 @@
+package service
+
+import (
+	"strings"
+
+	"billing-app/config"
+	"billing-app/internal/barcode"
+	"billing-app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type AddBarcodeRequest struct {
+	Code    string `json:"code" binding:"required"`
+	PackQty int    `json:"pack_qty" binding:"omitempty,gt=0"`
+	Label   string `json:"label"`
+}
+
+type GenerateBarcodesRequest struct {
+	ProductIDs []uint `json:"product_ids"` // Empty means every active product without a barcode
+}
+
+// maxLabels caps the labels one request renders; each copy is drawn into the PDF
+const maxLabels = 2000
+
+type LabelItem struct {
+	ProductID uint `json:"product_id" binding:"required"`
+	Copies    int  `json:"copies" binding:"omitempty,gt=0,lte=500"`
+}
+
+type LabelRequest struct {
+	Items []LabelItem `json:"items" binding:"required,min=1,max=500,dive"`
+}
+
+func (s *catalogService) LookupBarcode(code string) (models.Product, int, error) {
+	return lookupBarcode(s.db, code)
+}
+
+// lookupBarcode finds the active product a scanned code belongs to, by its
+// main barcode or an extra one, and how many units one scan of it counts for
+func lookupBarcode(db *gorm.DB, code string) (models.Product, int, error) {
+	code = strings.TrimSpace(code)
+
+	var product models.Product
+	packQty := 1
+	err := db.Preload("Brand").Preload("Category").Where("barcode = ? AND is_active = ?", code, true).First(&product).Error
+	if err != nil {
+		var extra models.ProductBarcode
+		if db.Where("code = ?", code).First(&extra).Error != nil ||
+			db.Preload("Brand").Preload("Category").Where("id = ? AND is_active = ?", extra.ProductID, true).First(&product).Error != nil {
+			return product, 0, notFound("No product with this barcode")
+		}
+		packQty = extra.PackQty
+	}
+	return product, packQty, nil
+}
+
+func (s *catalogService) AddBarcode(productID uint, req AddBarcodeRequest) (models.ProductBarcode, error) {
+	var product models.Product
+	if err := s.db.First(&product, productID).Error; err != nil {
+		return models.ProductBarcode{}, notFound("Product not found")
+	}
+
+	code := strings.TrimSpace(req.Code)
+	if err := CheckBarcode(s.db, code, product.ID); err != nil {
+		return models.ProductBarcode{}, codeError(err)
+	}
+	if code == product.Barcode {
+		return models.ProductBarcode{}, conflict("This is already the product's main barcode")
+	}
+
+	if req.PackQty == 0 {
+		req.PackQty = 1
+	}
+	extra := models.ProductBarcode{
+		ProductID: product.ID,
+		Code:      code,
+		PackQty:   req.PackQty,
+		Label:     req.Label,
+	}
+	if err := s.db.Create(&extra).Error; err != nil {
+		return extra, conflict("Failed to add barcode (it may already exist)")
+	}
+	return extra, nil
+}
+
+func (s *catalogService) RemoveBarcode(productID, barcodeID uint) error {
+	res := s.db.Where("id = ? AND product_id = ?", barcodeID, productID).Delete(&models.ProductBarcode{})
+	if res.Error != nil {
+		return failed("Failed to remove barcode")
+	}
+	if res.RowsAffected == 0 {
+		return notFound("Barcode not found")
+	}
+	return nil
+}
+
+func (s *catalogService) GenerateBarcodes(req GenerateBarcodesRequest) (map[uint]string, error) {
+	var products []models.Product
+	query := s.db.Where("(barcode = '' OR barcode IS NULL) AND is_active = ? AND has_variants = ?", true, false)
+	if len(req.ProductIDs) > 0 {
+		query = query.Where("id IN ?", req.ProductIDs)
+	}
+	if err := query.Find(&products).Error; err != nil {
+		return nil, failed("Failed to fetch products")
+	}
+
+	assigned := map[uint]string{}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, p := range products {
+			code, err := barcode.Internal(config.AppConfig.Inventory.BarcodePrefix, p.ID)
+			if err != nil {
+				return failed("%s", err.Error())
+			}
+			if err := CheckBarcode(tx, code, p.ID); err != nil {
+				e := codeError(err)
+				return newError(e.Kind, "Product %d: %s", p.ID, e.Message)
+			}
+			if err := tx.Model(&models.Product{}).Where("id = ?", p.ID).Update("barcode", code).Error; err != nil {
+				return productWriteError(err, "Failed to assign barcode")
+			}
+			assigned[p.ID] = code
+		}
+		return nil
+	})
+	return assigned, err
+}
+
+func (s *catalogService) Labels(req LabelRequest) ([]barcode.Label, error) {
+	total := 0
+	for _, item := range req.Items {
+		total += max(item.Copies, 1)
+	}
+	if total > maxLabels {
+		return nil, invalid("%d labels requested; print at most %d at a time", total, maxLabels)
+	}
+
+	labels := make([]barcode.Label, 0, total)
+	for _, item := range req.Items {
+		var product models.Product
+		if err := s.db.First(&product, item.ProductID).Error; err != nil {
+			return nil, invalid("Product ID %d not found", item.ProductID)
+		}
+		if product.Barcode == "" {
+			return nil, invalid("Product %s has no barcode; generate one first", product.Name)
+		}
+		copies := item.Copies
+		if copies == 0 {
+			copies = 1
+		}
+		for i := 0; i < copies; i++ {
+			labels = append(labels, barcode.Label{Name: product.Name, Price: product.UnitPrice, Code: product.Barcode})
+		}
+	}
+	return labels, nil
+}
@@ -0,0 +1,96 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"billing-app/internal/models"
+	"billing-app/internal/service"
+	"billing-app/internal/service/servicetest"
+
+	"gorm.io/gorm"
+)
+
+// The unique index backs CheckBarcode up when two saves race: it rejects a
+// live product's barcode but not empty barcodes or a deleted product's
+func TestProductBarcodeUniqueIndex(t *testing.T) {
+	env, err := servicetest.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+
+	first, err := env.Product("First", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := env.Product("Second", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assigned, err := env.Catalog.GenerateBarcodes(service.GenerateBarcodesRequest{ProductIDs: []uint{first.ID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := assigned[first.ID]
+
+	setBarcode := func() error {
+		return env.DB.Model(&models.Product{}).Where("id = ?", second.ID).Update("barcode", code).Error
+	}
+	if err := setBarcode(); !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Errorf("second product took %s: %v, want ErrDuplicatedKey", code, err)
+	}
+
+	if err := env.Catalog.DeleteProduct(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := setBarcode(); err != nil {
+		t.Errorf("reusing a deleted product's barcode: %v", err)
+	}
+}
+
+func TestLabelsCapped(t *testing.T) {
+	env, err := servicetest.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+
+	product, err := env.Product("Labelled", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.Catalog.GenerateBarcodes(service.GenerateBarcodesRequest{ProductIDs: []uint{product.ID}}); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(copies ...int) service.LabelRequest {
+		var req service.LabelRequest
+		for _, n := range copies {
+			req.Items = append(req.Items, service.LabelItem{ProductID: product.ID, Copies: n})
+		}
+		return req
+	}
+
+	tests := []struct {
+		name   string
+		copies []int
+		labels int
+		kind   service.Kind
+	}{
+		{"default one copy", []int{0}, 1, 0},
+		{"at the cap", []int{500, 500, 500, 500}, 2000, 0},
+		{"over the cap", []int{500, 500, 500, 500, 1}, 0, service.KindInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels, err := env.Catalog.Labels(request(tt.copies...))
+			if errorKind(err) != tt.kind {
+				t.Fatalf("Labels: %v, want kind %d", err, tt.kind)
+			}
+			if len(labels) != tt.labels {
+				t.Errorf("%d labels, want %d", len(labels), tt.labels)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"time"
+
+	"billing-app/internal/models"
+)
+
+// BatchAlert is a batch in stock that is expired or about to expire
+type BatchAlert struct {
+	models.StockBatch
+	DaysToExpiry int     `json:"days_to_expiry"` // Negative once expired
+	StockValue   float64 `json:"stock_value"`    // At cost
+}
+
+func batchAlerts(batches []models.StockBatch, today time.Time) []BatchAlert {
+	alerts := make([]BatchAlert, 0, len(batches))
+	for _, b := range batches {
+		expiry := time.Date(b.ExpiryDate.Year(), b.ExpiryDate.Month(), b.ExpiryDate.Day(), 0, 0, 0, 0, today.Location())
+		alerts = append(alerts, BatchAlert{
+			StockBatch:   b,
+			DaysToExpiry: int(expiry.Sub(today).Hours() / 24),
+			StockValue:   float64(b.QuantityRemaining) * b.CostPrice,
+		})
+	}
+	return alerts
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func (s *inventoryService) LowStockAlerts(storeID uint) ([]models.Product, error) {
+	var products []models.Product
+	// Parents hold no stock; each variant is alerted on its own threshold
+	query := s.db.Preload("Brand").Preload("Category").Preload("Parent").
+		Where("products.is_active = ? AND products.has_variants = ?", true, false)
+
+	if storeID != 0 {
+		query = query.Joins("LEFT JOIN store_stocks ON store_stocks.product_id = products.id AND store_stocks.store_id = ?", storeID).
+			Where("COALESCE(store_stocks.quantity, 0) <= products.low_stock_threshold")
+	} else {
+		query = query.Where("products.current_stock <= products.low_stock_threshold")
+	}
+	if err := query.Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	if storeID != 0 {
+		quantities := storeQuantities(s.db, storeID)
+		for i := range products {
+			products[i].CurrentStock = quantities[products[i].ID]
+		}
+	}
+	return products, nil
+}
+
+func (s *inventoryService) Batches(productID, storeID uint, all bool) ([]models.StockBatch, error) {
+	batches := []models.StockBatch{}
+	query := s.db.Preload("Store").Where("product_id = ?", productID)
+	if storeID != 0 {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if !all {
+		query = query.Where("quantity_remaining > 0")
+	}
+	err := query.Order("CASE WHEN expiry_date IS NULL THEN 1 ELSE 0 END, expiry_date, id").Find(&batches).Error
+	return batches, err
+}
+
+func (s *inventoryService) ExpiryAlerts(days int, storeID uint) ([]BatchAlert, error) {
+	today := startOfDay(time.Now())
+
+	var batches []models.StockBatch
+	query := s.db.Preload("Product").Preload("Store").
+		Where("quantity_remaining > 0 AND expiry_date >= ? AND expiry_date <= ?", today.Format("2006-01-02"), today.AddDate(0, 0, days).Format("2006-01-02"))
+	if storeID != 0 {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if err := query.Order("expiry_date").Find(&batches).Error; err != nil {
+		return nil, err
+	}
+	return batchAlerts(batches, today), nil
+}
+
+func (s *inventoryService) ExpiredStock(storeID uint) ([]BatchAlert, error) {
+	today := startOfDay(time.Now())
+
+	var batches []models.StockBatch
+	query := s.db.Preload("Product").Preload("Store").
+		Where("quantity_remaining > 0 AND expiry_date < ?", today.Format("2006-01-02"))
+	if storeID != 0 {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if err := query.Order("expiry_date").Find(&batches).Error; err != nil {
+		return nil, err
+	}
+	return batchAlerts(batches, today), nil
+}
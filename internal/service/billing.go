@@ -0,0 +1,463 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"billing-app/config"
+	"billing-app/internal/models"
+	"billing-app/internal/pricing"
+	"billing-app/internal/sequence"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BillingService prices, saves and refunds counter sales
+type BillingService interface {
+	// Quote prices a cart without saving it, with any disagreements with the
+	// figures the client sent
+	Quote(req CreateBillRequest, userID uint) (pricing.Breakdown, []pricing.Mismatch, error)
+	// CreateBill prices the cart on the server, takes payment and deducts stock
+	// at the user's store
+	CreateBill(req CreateBillRequest, userID uint) (models.Bill, pricing.Breakdown, error)
+	ListBills(filter BillFilter) ([]models.Bill, int64, error)
+	// GetBill loads a bill with everything its invoice prints
+	GetBill(id uint) (models.Bill, error)
+	// NextBillNo previews the next bill number without reserving it
+	NextBillNo() string
+	// TodaySales lists the user's bills since midnight, newest first
+	TodaySales(userID uint) ([]models.Bill, error)
+
+	// CancelBill voids a bill in full, restoring all outstanding quantities to stock
+	CancelBill(id uint, req CancelBillRequest, userID uint) (models.CreditNote, error)
+	// ReturnItems takes back part of a bill; the bill stays PAID with a reduced revenue
+	ReturnItems(id uint, req ReturnBillRequest, userID uint) (models.CreditNote, error)
+	CreditNotes(billID uint) ([]models.CreditNote, error)
+
+	CreateCustomer(req CreateCustomerRequest) (models.Customer, error)
+	SearchCustomers(q string) ([]models.Customer, error)
+	// GlobalDiscount is the active store-wide discount percentage, 0 when none
+	GlobalDiscount() float64
+	// SetGlobalDiscount replaces the active store-wide discount
+	SetGlobalDiscount(percentage float64) error
+	UpdateCustomerDiscount(customerID uint, percent float64) error
+	DiscountRules() ([]models.DiscountRule, error)
+}
+
+type BillItemRequest struct {
+	ProductID uint     `json:"product_id" binding:"required"`
+	Quantity  int      `json:"quantity" binding:"required,gt=0"`
+	UnitPrice *float64 `json:"unit_price"` // Client figure, verified against Product.UnitPrice
+	Total     *float64 `json:"total"`
+}
+
+type CreateBillRequest struct {
+	CustomerID     *uint             `json:"customer_id"`
+	TotalAmount    *float64          `json:"total_amount"`
+	DiscountAmount *float64          `json:"discount_amount"`
+	GSTAmount      *float64          `json:"gst_amount"`
+	NetPayable     *float64          `json:"net_payable"`
+	PaymentMode    string            `json:"payment_mode"` // Single tender shorthand when Payments is empty
+	Payments       []PaymentRequest  `json:"payments" binding:"dive"`
+	Items          []BillItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type PaymentRequest struct {
+	TenderType  string  `json:"tender_type" binding:"required"`
+	Amount      float64 `json:"amount" binding:"required,gt=0"` // Amount handed over; cash may exceed the bill
+	ReferenceNo string  `json:"reference_no"`
+	CardLast4   string  `json:"card_last4" binding:"omitempty,len=4,numeric"`
+}
+
+// tenders returns the requested payments, falling back to a single
+// payment_mode tender for the whole net payable
+func (req CreateBillRequest) tenders(netPayable float64) []pricing.Tender {
+	if len(req.Payments) == 0 {
+		if req.PaymentMode == "" {
+			return nil
+		}
+		return []pricing.Tender{{TenderType: req.PaymentMode, Tendered: netPayable}}
+	}
+
+	tenders := make([]pricing.Tender, 0, len(req.Payments))
+	for _, p := range req.Payments {
+		tenders = append(tenders, pricing.Tender{
+			TenderType:  p.TenderType,
+			Tendered:    p.Amount,
+			ReferenceNo: p.ReferenceNo,
+			CardLast4:   p.CardLast4,
+		})
+	}
+	return tenders
+}
+
+// clientFigures extracts the totals the POS client claims so they can be verified
+func (req CreateBillRequest) clientFigures() pricing.ClientFigures {
+	figures := pricing.ClientFigures{
+		TotalAmount:    req.TotalAmount,
+		DiscountAmount: req.DiscountAmount,
+		GSTAmount:      req.GSTAmount,
+		NetPayable:     req.NetPayable,
+	}
+	for _, item := range req.Items {
+		figures.Lines = append(figures.Lines, pricing.ClientLine{
+			ProductID: item.ProductID,
+			UnitPrice: item.UnitPrice,
+			Total:     item.Total,
+		})
+	}
+	return figures
+}
+
+type CreateCustomerRequest struct {
+	Name            string  `json:"name" binding:"required"`
+	Mobile          string  `json:"mobile" binding:"required"`
+	Address         string  `json:"address"`
+	State           string  `json:"state"`
+	WhatsappOptIn   bool    `json:"whatsapp_opt_in"`
+	DiscountPercent float64 `json:"discount_percent"`
+}
+
+// BillFilter selects a page of bills, newest first. StoreID 0 is every store.
+type BillFilter struct {
+	StoreID uint
+	Limit   int
+	Offset  int
+}
+
+type billingService struct {
+	db *gorm.DB
+}
+
+func NewBillingService(db *gorm.DB) BillingService {
+	return &billingService{db: db}
+}
+
+// priceBill loads products, customer and discount settings through db and
+// computes the server-side breakdown for a sale at store
+func priceBill(db *gorm.DB, req CreateBillRequest, store models.Store) (pricing.Breakdown, error) {
+	storeState := config.AppConfig.Billing.StoreState
+	if store.State != "" {
+		storeState = store.State
+	}
+	input := pricing.Input{
+		PlaceOfSupply: storeState,
+		RoundTo:       config.AppConfig.Billing.RoundTo,
+	}
+
+	for _, itemReq := range req.Items {
+		// Checked here as well as in binding: a negative line would price below
+		// catalogue and put stock back on the shelf
+		if itemReq.Quantity <= 0 {
+			return pricing.Breakdown{}, invalid("Quantity for product ID %d must be greater than 0", itemReq.ProductID)
+		}
+		var product models.Product
+		if err := db.Preload("Category").Where("id = ? AND is_active = ?", itemReq.ProductID, true).First(&product).Error; err != nil {
+			return pricing.Breakdown{}, invalid("Product ID %d not found", itemReq.ProductID)
+		}
+		if product.HasVariants {
+			return pricing.Breakdown{}, invalid("Product %s has variants; bill a specific size or colour", product.Name)
+		}
+
+		hsn, rate := models.ResolveTax(product, config.AppConfig.Billing.GSTPercent)
+		input.Lines = append(input.Lines, pricing.Line{Product: product, Quantity: itemReq.Quantity, HSNCode: hsn, GSTRate: rate})
+	}
+
+	if req.CustomerID != nil {
+		var customer models.Customer
+		if err := db.First(&customer, *req.CustomerID).Error; err != nil {
+			return pricing.Breakdown{}, invalid("Customer ID %d not found", *req.CustomerID)
+		}
+		input.CustomerDiscountPercent = customer.DiscountPercent
+		if customer.State != "" {
+			input.PlaceOfSupply = customer.State
+			input.InterState = !strings.EqualFold(strings.TrimSpace(customer.State), strings.TrimSpace(storeState))
+		}
+	}
+
+	var discount models.Discount
+	if err := db.Where("is_active = ?", true).Last(&discount).Error; err == nil {
+		input.GlobalDiscountPercent = discount.Percentage
+	}
+
+	db.Where("is_active = ?", true).Find(&input.Rules)
+
+	return pricing.Calculate(input), nil
+}
+
+// takeAllocations carves qty units off the front of a sale's batch allocations
+// for one bill line. allocs holds negative quantities, as MoveStock returns them.
+func takeAllocations(allocs *[]models.BatchAllocation, qty int) []models.BillItemBatch {
+	var rows []models.BillItemBatch
+	for qty > 0 && len(*allocs) > 0 {
+		a := &(*allocs)[0]
+		take := min(qty, -a.Quantity)
+		rows = append(rows, models.BillItemBatch{BatchID: a.BatchID, Quantity: take})
+		a.Quantity += take
+		qty -= take
+		if a.Quantity == 0 {
+			*allocs = (*allocs)[1:]
+		}
+	}
+	return rows
+}
+
+func (s *billingService) Quote(req CreateBillRequest, userID uint) (pricing.Breakdown, []pricing.Mismatch, error) {
+	store, err := billingStore(s.db, userID)
+	if err != nil {
+		return pricing.Breakdown{}, nil, storeError(err)
+	}
+
+	breakdown, err := priceBill(s.db, req, store)
+	if err != nil {
+		return pricing.Breakdown{}, nil, err
+	}
+	return breakdown, breakdown.Verify(req.clientFigures(), config.AppConfig.Billing.PriceTolerance), nil
+}
+
+func (s *billingService) CreateBill(req CreateBillRequest, userID uint) (models.Bill, pricing.Breakdown, error) {
+	now := time.Now()
+
+	shiftID := OpenShiftID(s.db, userID)
+	if shiftID == nil && config.AppConfig.Billing.RequireShift {
+		return models.Bill{}, pricing.Breakdown{}, conflict("Open a shift before billing")
+	}
+
+	store, err := billingStore(s.db, userID)
+	if err != nil {
+		return models.Bill{}, pricing.Breakdown{}, storeError(err)
+	}
+
+	var bill models.Bill
+	var breakdown pricing.Breakdown
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// The shift row stays locked until commit, so CloseShift cannot freeze
+		// the drawer between this check and the bill landing on the shift
+		if shiftID != nil {
+			var shift models.Shift
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+				Where("id = ? AND status = ?", *shiftID, "OPEN").First(&shift).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return conflict("Your shift was closed; open a new shift to bill")
+				}
+				return failed("Failed to check shift")
+			}
+		}
+
+		var err error
+		breakdown, err = priceBill(tx, req, store)
+		if err != nil {
+			return err
+		}
+
+		if mismatches := breakdown.Verify(req.clientFigures(), config.AppConfig.Billing.PriceTolerance); len(mismatches) > 0 {
+			return newError(KindUnprocessable, "Bill figures do not match server pricing").with(map[string]interface{}{
+				"mismatches": mismatches,
+				"breakdown":  breakdown,
+			})
+		}
+
+		payments, paymentMode, err := pricing.Settle(breakdown.NetPayable, req.tenders(breakdown.NetPayable))
+		if err != nil {
+			return invalid("%s", err.Error()).with(map[string]interface{}{"net_payable": breakdown.NetPayable})
+		}
+
+		// Assigned after pricing so a rejected bill never locks the sequence row
+		billNo, err := sequence.Next(tx, sequence.Bill(), now)
+		if err != nil {
+			return failed("Failed to assign bill number")
+		}
+
+		bill = models.Bill{
+			BillNo:         billNo,
+			BillDate:       now,
+			CustomerID:     req.CustomerID,
+			UserID:         userID,
+			ShiftID:        shiftID,
+			StoreID:        &store.ID,
+			TotalAmount:    breakdown.TotalAmount,
+			DiscountAmount: breakdown.DiscountAmount,
+			GSTAmount:      breakdown.GSTAmount,
+			CGSTAmount:     breakdown.CGSTAmount,
+			SGSTAmount:     breakdown.SGSTAmount,
+			IGSTAmount:     breakdown.IGSTAmount,
+			PlaceOfSupply:  breakdown.PlaceOfSupply,
+			InterState:     breakdown.InterState,
+			RoundOff:       breakdown.RoundOff,
+			NetPayable:     breakdown.NetPayable,
+			PaymentMode:    paymentMode,
+			Status:         "PAID",
+			Payments:       payments,
+		}
+
+		if err := tx.Create(&bill).Error; err != nil {
+			return failed("Failed to create bill record")
+		}
+
+		// Deduct Stock in product ID order so concurrent bills lock rows in the same order
+		deductions := map[uint]int{}
+		for _, itemReq := range req.Items {
+			deductions[itemReq.ProductID] += itemReq.Quantity
+		}
+		productIDs := make([]uint, 0, len(deductions))
+		for id := range deductions {
+			productIDs = append(productIDs, id)
+		}
+		sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+
+		// Batches taken per product (FEFO), handed out to the bill lines below
+		allocations := map[uint][]models.BatchAllocation{}
+		for _, productID := range productIDs {
+			movement, err := MoveStock(tx, StockChange{
+				ProductID: productID,
+				Quantity:  -deductions[productID],
+				Type:      models.MovementSale,
+				RefType:   "BILL",
+				RefID:     &bill.ID,
+				RefNo:     billNo,
+				UserID:    userID,
+				StoreID:   store.ID,
+			})
+			if err != nil {
+				var short *StockConflictError
+				if errors.As(err, &short) {
+					return stockError(err)
+				}
+				return failed("Failed to update stock")
+			}
+			allocations[productID] = movement.Batches
+		}
+
+		for i, itemReq := range req.Items {
+			// Add Bill Item
+			line := breakdown.Items[i]
+			billItem := models.BillItem{
+				BillID:         bill.ID,
+				ProductID:      itemReq.ProductID,
+				Quantity:       itemReq.Quantity,
+				UnitPrice:      line.UnitPrice,
+				Total:          line.Total,
+				HSNCode:        line.HSNCode,
+				DiscountAmount: line.DiscountAmount,
+				TaxableValue:   line.TaxableValue,
+				GSTRate:        line.GSTRate,
+				CGSTAmount:     line.CGSTAmount,
+				SGSTAmount:     line.SGSTAmount,
+				IGSTAmount:     line.IGSTAmount,
+			}
+			productAllocations := allocations[itemReq.ProductID]
+			billItem.Batches = takeAllocations(&productAllocations, itemReq.Quantity)
+			allocations[itemReq.ProductID] = productAllocations
+			if err := tx.Create(&billItem).Error; err != nil {
+				return failed("Failed to add bill item")
+			}
+		}
+		return nil
+	})
+	return bill, breakdown, err
+}
+
+func (s *billingService) ListBills(filter BillFilter) ([]models.Bill, int64, error) {
+	var bills []models.Bill
+	var total int64
+
+	query := s.db.Model(&models.Bill{})
+	if filter.StoreID != 0 {
+		query = query.Where("store_id = ?", filter.StoreID)
+	}
+	query.Session(&gorm.Session{}).Count(&total)
+
+	err := query.Preload("Customer").Preload("User").Preload("Store").Preload("Items").Preload("Items.Product").Preload("Payments").
+		Order("bill_date desc").Limit(filter.Limit).Offset(filter.Offset).Find(&bills).Error
+	return bills, total, err
+}
+
+func (s *billingService) GetBill(id uint) (models.Bill, error) {
+	var bill models.Bill
+	if err := s.db.Preload("Customer").Preload("User").Preload("Store").Preload("Items").Preload("Items.Product").Preload("Payments").
+		First(&bill, id).Error; err != nil {
+		return bill, notFound("Bill not found")
+	}
+	return bill, nil
+}
+
+func (s *billingService) NextBillNo() string {
+	return sequence.Peek(s.db, sequence.Bill(), time.Now())
+}
+
+func (s *billingService) TodaySales(userID uint) ([]models.Bill, error) {
+	now := time.Now()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	endOfDay := startOfDay.Add(24 * time.Hour)
+
+	var bills []models.Bill
+	err := s.db.Preload("Payments").Preload("CreditNotes").Where("user_id = ? AND bill_date >= ? AND bill_date < ?", userID, startOfDay, endOfDay).
+		Order("bill_date desc").Find(&bills).Error
+	return bills, err
+}
+
+func (s *billingService) CreateCustomer(req CreateCustomerRequest) (models.Customer, error) {
+	customer := models.Customer{
+		Name:            req.Name,
+		Mobile:          req.Mobile,
+		Address:         req.Address,
+		State:           req.State,
+		WhatsappOptIn:   req.WhatsappOptIn,
+		DiscountPercent: req.DiscountPercent,
+	}
+	if err := s.db.Create(&customer).Error; err != nil {
+		return customer, failed("Failed to create customer (Mobile might be duplicate)")
+	}
+	return customer, nil
+}
+
+// SearchCustomers matches name or mobile; an empty query returns the first 20
+func (s *billingService) SearchCustomers(q string) ([]models.Customer, error) {
+	customers := []models.Customer{}
+	var err error
+	if q == "" {
+		err = s.db.Limit(20).Find(&customers).Error
+	} else {
+		err = s.db.Where("LOWER(name) LIKE ? OR mobile LIKE ?", "%"+strings.ToLower(q)+"%", "%"+q+"%").Find(&customers).Error
+	}
+	return customers, err
+}
+
+func (s *billingService) GlobalDiscount() float64 {
+	var discount models.Discount
+	if err := s.db.Where("is_active = ?", true).Last(&discount).Error; err != nil {
+		return 0
+	}
+	return discount.Percentage
+}
+
+func (s *billingService) SetGlobalDiscount(percentage float64) error {
+	// Disable previous active discounts
+	s.db.Model(&models.Discount{}).Where("is_active = ?", true).Update("is_active", false)
+
+	discount := models.Discount{
+		Name:       "Standard Manager Discount",
+		Percentage: percentage,
+		IsActive:   true,
+	}
+	if err := s.db.Create(&discount).Error; err != nil {
+		return failed("Failed to set discount")
+	}
+	return nil
+}
+
+func (s *billingService) UpdateCustomerDiscount(customerID uint, percent float64) error {
+	if err := s.db.Model(&models.Customer{}).Where("id = ?", customerID).Update("discount_percent", percent).Error; err != nil {
+		return failed("Failed to update customer discount")
+	}
+	return nil
+}
+
+func (s *billingService) DiscountRules() ([]models.DiscountRule, error) {
+	var rules []models.DiscountRule
+	err := s.db.Where("is_active = ?", true).Find(&rules).Error
+	return rules, err
+}
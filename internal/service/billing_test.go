@@ -0,0 +1,68 @@
+package service_test
+
+import (
+	"testing"
+
+	"billing-app/internal/models"
+	"billing-app/internal/service"
+	"billing-app/internal/service/servicetest"
+)
+
+// A line with a negative quantity would price the bill below catalogue and put
+// stock back on the shelf, so the service refuses it even if binding is skipped
+func TestBillRejectsNonPositiveQuantities(t *testing.T) {
+	env, err := servicetest.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+
+	a, err := env.Product("Product A", 60, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := env.Product("Product B", 20, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		items []service.BillItemRequest
+	}{
+		{"zero quantity", []service.BillItemRequest{{ProductID: a.ID, Quantity: 0}}},
+		{"negative quantity", []service.BillItemRequest{{ProductID: a.ID, Quantity: -1}}},
+		{"mixed cart", []service.BillItemRequest{{ProductID: a.ID, Quantity: 1}, {ProductID: b.ID, Quantity: -2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := service.CreateBillRequest{PaymentMode: "CASH", Items: tt.items}
+			if _, _, err := env.Billing.Quote(req, env.Admin.ID); errorKind(err) != service.KindInvalid {
+				t.Errorf("Quote: %v, want invalid", err)
+			}
+			if _, _, err := env.Billing.CreateBill(req, env.Admin.ID); errorKind(err) != service.KindInvalid {
+				t.Errorf("CreateBill: %v, want invalid", err)
+			}
+			order := service.SubmitOrderRequest{CustomerName: "Asha", CustomerMobile: "9876543210", Items: tt.items}
+			if _, _, err := env.Orders.SubmitOrder(order); errorKind(err) != service.KindInvalid {
+				t.Errorf("SubmitOrder: %v, want invalid", err)
+			}
+		})
+	}
+
+	var bills, orders, customers int64
+	env.DB.Model(&models.Bill{}).Count(&bills)
+	env.DB.Model(&models.CustomerOrder{}).Count(&orders)
+	env.DB.Model(&models.Customer{}).Count(&customers)
+	if bills != 0 || orders != 0 || customers != 0 {
+		t.Errorf("%d bills, %d orders, %d customers saved; want none", bills, orders, customers)
+	}
+	for _, p := range []models.Product{a, b} {
+		if err := env.DB.First(&p, p.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if p.CurrentStock != 10 {
+			t.Errorf("%s current_stock = %d, want 10", p.Name, p.CurrentStock)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"strings"
+
+	"billing-app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type BrandRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type MergeRequest struct {
+	IntoID uint `json:"into_id" binding:"required"`
+}
+
+func (s *catalogService) ListBrands() ([]models.Brand, error) {
+	var brands []models.Brand
+	err := s.db.Order("name").Find(&brands).Error
+	return brands, err
+}
+
+func (s *catalogService) CreateBrand(req BrandRequest) (models.Brand, error) {
+	brand := models.Brand{Name: strings.TrimSpace(req.Name)}
+	if err := s.db.Create(&brand).Error; err != nil {
+		return brand, failed("Failed to create brand (Name might be duplicate)")
+	}
+	return brand, nil
+}
+
+func (s *catalogService) UpdateBrand(id uint, req BrandRequest) (models.Brand, error) {
+	var brand models.Brand
+	if err := s.db.First(&brand, id).Error; err != nil {
+		return brand, notFound("Brand not found")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	var count int64
+	s.db.Model(&models.Brand{}).Where("name = ? AND id <> ?", name, brand.ID).Count(&count)
+	if count > 0 {
+		return brand, conflict("Another brand has this name; merge them instead")
+	}
+
+	if err := s.db.Model(&brand).Update("name", name).Error; err != nil {
+		return brand, failed("Failed to update brand")
+	}
+	return brand, nil
+}
+
+func (s *catalogService) DeleteBrand(id uint) error {
+	var brand models.Brand
+	if err := s.db.First(&brand, id).Error; err != nil {
+		return notFound("Brand not found")
+	}
+
+	var products int64
+	s.db.Unscoped().Model(&models.Product{}).Where("brand_id = ?", brand.ID).Count(&products)
+	if products > 0 {
+		return conflict("Brand is in use; merge it into another brand instead").with(map[string]interface{}{"products": products})
+	}
+
+	if err := s.db.Delete(&brand).Error; err != nil {
+		return failed("Failed to delete brand")
+	}
+	return nil
+}
+
+// MergeBrand folds e.g. spelling variants created by FirstOrCreate into one brand
+func (s *catalogService) MergeBrand(id, intoID uint) (int64, error) {
+	var source, target models.Brand
+	if err := s.db.First(&source, id).Error; err != nil {
+		return 0, notFound("Brand not found")
+	}
+	if err := s.db.First(&target, intoID).Error; err != nil {
+		return 0, invalid("Target brand not found")
+	}
+	if source.ID == target.ID {
+		return 0, invalid("Cannot merge a brand into itself")
+	}
+
+	var moved int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Unscoped().Model(&models.Product{}).Where("brand_id = ?", source.ID).Update("brand_id", target.ID)
+		if res.Error != nil {
+			return failed("Failed to move products")
+		}
+		moved = res.RowsAffected
+		if err := tx.Delete(&source).Error; err != nil {
+			return failed("Failed to delete merged brand")
+		}
+		return nil
+	})
+	return moved, err
+}
@@ -0,0 +1,443 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"billing-app/internal/barcode"
+	"billing-app/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CatalogService maintains the product catalogue: products, brands,
+// categories, barcodes and catalogue import/export. Stock is not edited here;
+// it moves through InventoryService.
+type CatalogService interface {
+	// ListProducts returns active top-level products with their variants and the total matching the filter
+	ListProducts(filter ProductFilter) ([]models.Product, int64, error)
+	GetProduct(id uint) (models.Product, error)
+	// UpdateProduct applies the non-nil fields. Price changes and (de)activation
+	// are restricted to managers; a price change closes the current price
+	// history row. Brand, category, supplier, tax and active changes on a parent
+	// carry down to its variants.
+	UpdateProduct(id uint, req UpdateProductRequest, actor Actor) (models.Product, error)
+	// DeleteProduct soft deletes a product and, for a parent, its variants
+	DeleteProduct(id uint) error
+	// PriceHistory lists a product's prices, newest first
+	PriceHistory(productID uint) ([]models.ProductPriceHistory, error)
+	// PriceAt is the price row that applied to a product at t
+	PriceAt(productID uint, t time.Time) (models.ProductPriceHistory, error)
+
+	ListBrands() ([]models.Brand, error)
+	CreateBrand(req BrandRequest) (models.Brand, error)
+	// UpdateBrand renames a brand. Renaming onto another brand's name is a merge.
+	UpdateBrand(id uint, req BrandRequest) (models.Brand, error)
+	// DeleteBrand removes a brand no product uses, including deleted products
+	DeleteBrand(id uint) error
+	// MergeBrand moves every product of a brand to another brand and deletes
+	// it, returning the number of products moved
+	MergeBrand(id, intoID uint) (int64, error)
+
+	// ListCategories returns all categories in tree order
+	ListCategories() ([]models.Category, error)
+	CreateCategory(req CreateCategoryRequest) (models.Category, error)
+	// UpdateCategory edits a category and can move it, with its
+	// subcategories, under another parent
+	UpdateCategory(id uint, req UpdateCategoryRequest) (models.Category, error)
+	// DeleteCategory removes an unused category. One with products,
+	// subcategories or stock takes has to be merged into another instead.
+	DeleteCategory(id uint) error
+	// MergeCategory moves a category's products, stock takes and
+	// subcategories into another category and deletes it
+	MergeCategory(id, intoID uint) (CategoryMerge, error)
+
+	// LookupBarcode resolves a scanned code to an active product and the
+	// quantity one scan adds (pack_qty), so outer packs bill correctly
+	LookupBarcode(code string) (models.Product, int, error)
+	AddBarcode(productID uint, req AddBarcodeRequest) (models.ProductBarcode, error)
+	RemoveBarcode(productID, barcodeID uint) error
+	// GenerateBarcodes assigns in-store EAN-13 codes to products that have
+	// none, returning the codes by product ID
+	GenerateBarcodes(req GenerateBarcodesRequest) (map[uint]string, error)
+	// Labels lists one label per copy requested, for RenderLabels
+	Labels(req LabelRequest) ([]barcode.Label, error)
+
+	// ImportProducts creates or updates products from catalogue rows, the
+	// first being the header. It applies all rows or none.
+	ImportProducts(rows [][]string, opts ImportOptions, actor Actor) (ImportSummary, error)
+	// ExportRows is the catalogue in the import layout, header first
+	ExportRows() ([][]string, error)
+}
+
+// ProductFilter selects products for ListProducts:
+//
+//	Q                     text in name, SKU, barcode (a variant's too, or an exact pack barcode) or description
+//	BrandID               one brand
+//	CategoryID            a category and its subcategories
+//	MinPrice, MaxPrice    unit price range
+//	InStock               products, or parents with a variant, that have stock
+type ProductFilter struct {
+	Q          string
+	BrandID    *uint
+	CategoryID *uint
+	MinPrice   *float64
+	MaxPrice   *float64
+	InStock    bool
+	Sort       string // A productSorts key; empty sorts by name
+	Limit      int
+	Offset     int
+}
+
+// UpdateProductRequest carries only the fields to change; nil fields are left as they are.
+// Stock is not editable here, it moves through the ledger.
+type UpdateProductRequest struct {
+	Name              *string  `json:"name"`
+	BrandName         *string  `json:"brand_name"`
+	CategoryID        *uint    `json:"category_id"`
+	ClearCategory     bool     `json:"clear_category"`
+	SupplierID        *uint    `json:"supplier_id"`
+	ClearSupplier     bool     `json:"clear_supplier"`
+	Description       *string  `json:"description"`
+	UnitPrice         *float64 `json:"unit_price" binding:"omitempty,gt=0"`
+	PriceReason       string   `json:"price_reason"`
+	LowStockThreshold *int     `json:"low_stock_threshold" binding:"omitempty,gte=0"`
+	SKU               *string  `json:"sku"`
+	Size              *string  `json:"size"`   // Variants only
+	Colour            *string  `json:"colour"` // Variants only
+	Barcode           *string  `json:"barcode"`
+	HSNCode           *string  `json:"hsn_code"`
+	GSTRate           *float64 `json:"gst_rate"`
+	IsActive          *bool    `json:"is_active"`
+	TrackBatches      *bool    `json:"track_batches"`
+}
+
+type catalogService struct {
+	db *gorm.DB
+}
+
+func NewCatalogService(db *gorm.DB) CatalogService {
+	return &catalogService{db: db}
+}
+
+// productSorts maps sort values to ORDER BY clauses; id breaks ties so pages are stable
+var productSorts = map[string]string{
+	"name":   "products.name, products.id",
+	"-name":  "products.name desc, products.id",
+	"price":  "products.unit_price, products.id",
+	"-price": "products.unit_price desc, products.id",
+	"stock":  "products.current_stock, products.id",
+	"-stock": "products.current_stock desc, products.id",
+	"newest": "products.created_at desc, products.id desc",
+	"oldest": "products.created_at, products.id",
+}
+
+// filterProducts applies a ProductFilter's conditions to query
+func filterProducts(db, query *gorm.DB, filter ProductFilter) (*gorm.DB, error) {
+	if q := strings.TrimSpace(filter.Q); q != "" {
+		// LOWER keeps the search case-insensitive on Postgres as well
+		like := "%" + strings.ToLower(q) + "%"
+		query = query.Where(
+			"LOWER(products.name) LIKE ? OR LOWER(products.sku) LIKE ? OR LOWER(products.barcode) LIKE ? OR LOWER(products.description) LIKE ? OR "+
+				"products.id IN (SELECT v.parent_id FROM products v WHERE v.parent_id IS NOT NULL AND v.deleted_at IS NULL AND (LOWER(v.name) LIKE ? OR LOWER(v.sku) LIKE ? OR LOWER(v.barcode) LIKE ?)) OR "+
+				"products.id IN (SELECT product_id FROM product_barcodes WHERE code = ?)",
+			like, like, like, like, like, like, like, q)
+	}
+	if filter.BrandID != nil {
+		query = query.Where("products.brand_id = ?", *filter.BrandID)
+	}
+	if filter.CategoryID != nil {
+		subtree, err := categorySubtree(db, *filter.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("products.category_id IN (?)", subtree)
+	}
+	if filter.MinPrice != nil {
+		query = query.Where("products.unit_price >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query = query.Where("products.unit_price <= ?", *filter.MaxPrice)
+	}
+	if filter.InStock {
+		query = query.Where("products.current_stock > 0 OR products.id IN (SELECT v.parent_id FROM products v WHERE v.parent_id IS NOT NULL AND v.deleted_at IS NULL AND v.is_active = ? AND v.current_stock > 0)", true)
+	}
+	return query, nil
+}
+
+func (s *catalogService) ListProducts(filter ProductFilter) ([]models.Product, int64, error) {
+	if filter.Sort == "" {
+		filter.Sort = "name"
+	}
+	order, ok := productSorts[filter.Sort]
+	if !ok {
+		return nil, 0, invalid("Unknown sort %q", filter.Sort)
+	}
+
+	query, err := filterProducts(s.db, s.db.Model(&models.Product{}).Where("products.parent_id IS NULL AND products.is_active = ?", true), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, failed("Failed to fetch products")
+	}
+
+	products := []models.Product{}
+	if err := query.Preload("Brand").Preload("Category").Preload("Variants", "is_active = ?", true).
+		Order(order).Limit(filter.Limit).Offset(filter.Offset).Find(&products).Error; err != nil {
+		return nil, 0, failed("Failed to fetch products")
+	}
+	return products, total, nil
+}
+
+func (s *catalogService) GetProduct(id uint) (models.Product, error) {
+	var product models.Product
+	if err := s.db.Preload("Brand").Preload("Category").Preload("Barcodes").Preload("Parent").Preload("Variants").Preload("Variants.Barcodes").
+		First(&product, id).Error; err != nil {
+		return product, notFound("Product not found")
+	}
+	return product, nil
+}
+
+func (s *catalogService) UpdateProduct(id uint, req UpdateProductRequest, actor Actor) (models.Product, error) {
+	var product models.Product
+	if (req.UnitPrice != nil || req.IsActive != nil) && !actor.IsManager() {
+		return product, newError(KindForbidden, "Only a manager can change prices or deactivate products")
+	}
+	if req.GSTRate != nil && !models.IsValidGSTSlab(*req.GSTRate) {
+		return product, invalid("GST rate must be one of the slabs 0, 5, 12, 18 or 28")
+	}
+
+	if err := s.db.First(&product, id).Error; err != nil {
+		return product, notFound("Product not found")
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		if *req.Name == "" {
+			return product, invalid("Name cannot be empty")
+		}
+		updates["name"] = *req.Name
+	}
+	if req.BrandName != nil {
+		var brand models.Brand
+		if err := s.db.FirstOrCreate(&brand, models.Brand{Name: *req.BrandName}).Error; err != nil {
+			return product, failed("Failed to process brand")
+		}
+		updates["brand_id"] = brand.ID
+	}
+	if req.ClearCategory {
+		updates["category_id"] = nil
+	} else if req.CategoryID != nil {
+		var count int64
+		s.db.Model(&models.Category{}).Where("id = ?", *req.CategoryID).Count(&count)
+		if count == 0 {
+			return product, invalid("Category not found")
+		}
+		updates["category_id"] = *req.CategoryID
+	}
+	if req.ClearSupplier {
+		updates["supplier_id"] = nil
+	} else if req.SupplierID != nil {
+		var count int64
+		s.db.Model(&models.Supplier{}).Where("id = ?", *req.SupplierID).Count(&count)
+		if count == 0 {
+			return product, invalid("Supplier not found")
+		}
+		updates["supplier_id"] = *req.SupplierID
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.LowStockThreshold != nil {
+		updates["low_stock_threshold"] = *req.LowStockThreshold
+	}
+	if req.SKU != nil {
+		sku := strings.TrimSpace(*req.SKU)
+		if sku != "" {
+			if err := CheckSKU(s.db, sku, product.ID); err != nil {
+				return product, conflict("%s", err.Error())
+			}
+		}
+		updates["sku"] = sku
+	}
+	if req.Size != nil || req.Colour != nil {
+		if product.ParentID == nil {
+			return product, invalid("Size and colour apply to variants only")
+		}
+		size, colour := product.Size, product.Colour
+		if req.Size != nil {
+			size = strings.TrimSpace(*req.Size)
+		}
+		if req.Colour != nil {
+			colour = strings.TrimSpace(*req.Colour)
+		}
+		updates["size"], updates["colour"] = size, colour
+		if req.Name == nil {
+			var parent models.Product
+			if err := s.db.Select("id", "name").First(&parent, *product.ParentID).Error; err == nil {
+				updates["name"] = VariantName(parent.Name, size, colour)
+			}
+		}
+	}
+	if req.Barcode != nil {
+		code := strings.TrimSpace(*req.Barcode)
+		if code != "" && product.HasVariants {
+			return product, invalid("Barcodes belong to the variants of a parent product")
+		}
+		if code != "" {
+			if err := CheckBarcode(s.db, code, product.ID); err != nil {
+				return product, codeError(err)
+			}
+		}
+		updates["barcode"] = code
+	}
+	if req.HSNCode != nil {
+		updates["hsn_code"] = *req.HSNCode
+	}
+	if req.GSTRate != nil {
+		updates["gst_rate"] = *req.GSTRate
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+
+	trackingChanged := req.TrackBatches != nil && *req.TrackBatches != product.TrackBatches
+	if trackingChanged {
+		updates["track_batches"] = *req.TrackBatches
+	}
+
+	priceChanged := req.UnitPrice != nil && *req.UnitPrice != product.UnitPrice
+	if priceChanged {
+		updates["unit_price"] = *req.UnitPrice
+	}
+	if len(updates) == 0 {
+		return product, nil
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&product).Updates(updates).Error; err != nil {
+			return productWriteError(err, "Failed to update product")
+		}
+
+		if trackingChanged && !product.HasVariants {
+			if err := setBatchTracking(tx, product, *req.TrackBatches); err != nil {
+				return failed("Failed to update batch tracking")
+			}
+		}
+
+		if priceChanged {
+			if err := RecordPriceChange(tx, product.ID, *req.UnitPrice, req.PriceReason, actor.UserID, time.Now()); err != nil {
+				return failed("Failed to record price history")
+			}
+		}
+
+		if product.HasVariants {
+			inherited := map[string]interface{}{}
+			for _, col := range []string{"brand_id", "category_id", "supplier_id", "hsn_code", "gst_rate", "is_active"} {
+				if v, ok := updates[col]; ok {
+					inherited[col] = v
+				}
+			}
+			if len(inherited) > 0 {
+				if err := tx.Model(&models.Product{}).Where("parent_id = ?", product.ID).Updates(inherited).Error; err != nil {
+					return failed("Failed to update variants")
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return product, err
+	}
+
+	s.db.Preload("Brand").Preload("Category").Preload("Variants").First(&product, product.ID)
+	return product, nil
+}
+
+// setBatchTracking keeps batches in step with store stock when tracking is
+// switched: existing stock starts in each store's UNBATCHED batch, and
+// switching off empties the batches (the ledger keeps their history)
+func setBatchTracking(tx *gorm.DB, product models.Product, on bool) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "current_stock").First(&product, product.ID).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.StockBatch{}).Where("product_id = ?", product.ID).Update("quantity_remaining", 0).Error; err != nil {
+		return err
+	}
+	if !on {
+		return nil
+	}
+
+	var stocks []models.StoreStock
+	if err := tx.Where("product_id = ? AND quantity > 0", product.ID).Find(&stocks).Error; err != nil {
+		return err
+	}
+	for _, s := range stocks {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "store_id"}, {Name: "product_id"}, {Name: "batch_no"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"quantity_remaining": s.Quantity}),
+		}).Create(&models.StockBatch{
+			StoreID:           s.StoreID,
+			ProductID:         product.ID,
+			BatchNo:           models.UnbatchedBatchNo,
+			QuantityReceived:  s.Quantity,
+			QuantityRemaining: s.Quantity,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteProduct only soft deletes: bills and the stock ledger keep referring
+// to the product, so the row itself is never removed
+func (s *catalogService) DeleteProduct(id uint) error {
+	var product models.Product
+	if err := s.db.First(&product, id).Error; err != nil {
+		return notFound("Product not found")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Product{}).Where("id = ? OR parent_id = ?", product.ID, product.ID).Update("is_active", false).Error; err != nil {
+			return failed("Failed to delete product")
+		}
+		if err := tx.Where("id = ? OR parent_id = ?", product.ID, product.ID).Delete(&models.Product{}).Error; err != nil {
+			return failed("Failed to delete product")
+		}
+		return nil
+	})
+}
+
+func (s *catalogService) PriceHistory(productID uint) ([]models.ProductPriceHistory, error) {
+	history := []models.ProductPriceHistory{}
+	err := s.db.Where("product_id = ?", productID).Preload("User").Order("effective_from desc").Find(&history).Error
+	return history, err
+}
+
+func (s *catalogService) PriceAt(productID uint, t time.Time) (models.ProductPriceHistory, error) {
+	var history models.ProductPriceHistory
+	if err := s.db.Where("product_id = ?", productID).
+		Where("effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)", t, t).
+		Order("effective_from desc").First(&history).Error; err != nil {
+		return history, notFound("No price recorded for that date")
+	}
+	return history, nil
+}
+
+func sameUint(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameFloat(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"billing-app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CatalogColumns is the product import/export layout. Export writes every
+// column so its file imports back unchanged; imports need name, brand and
+// unit_price and may give the columns in any order.
+var CatalogColumns = []string{
+	"id", "name", "brand", "category", "unit_price", "sku", "barcode",
+	"hsn_code", "gst_rate", "low_stock_threshold", "opening_stock", "description",
+}
+
+// ImportOptions control a catalogue import
+type ImportOptions struct {
+	StoreID *uint // Where opening stock goes; defaults to the actor's store
+	DryRun  bool  // Validate every row but save nothing
+}
+
+// ImportRowError is a validation failure on one data row (row 2 is the first after the header)
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ImportSummary reports an import; nothing was saved if Errors is not empty
+type ImportSummary struct {
+	DryRun    bool             `json:"dry_run"`
+	Rows      int              `json:"rows"`
+	Created   int              `json:"created"`
+	Updated   int              `json:"updated"`
+	Unchanged int              `json:"unchanged"`
+	Errors    []ImportRowError `json:"errors"`
+}
+
+// ImportProducts matches rows to existing products by id and name, then SKU,
+// then barcode, so an export imports into another instance too; opening_stock
+// only applies to new products. The whole file is
+// applied in one transaction, and nothing is saved if any row fails or on a
+// dry run.
+func (s *catalogService) ImportProducts(rows [][]string, opts ImportOptions, actor Actor) (ImportSummary, error) {
+	summary := ImportSummary{DryRun: opts.DryRun, Errors: []ImportRowError{}}
+	if len(rows) < 2 {
+		return summary, invalid("File has no product rows")
+	}
+
+	header := map[string]int{}
+	for i, name := range rows[0] {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		header[name] = i
+	}
+	for _, required := range []string{"name", "brand", "unit_price"} {
+		if _, ok := header[required]; !ok {
+			return summary, invalid("Missing column %s", required)
+		}
+	}
+
+	storeID, err := RequestStoreID(s.db, opts.StoreID, actor)
+	if err != nil {
+		return summary, storeError(err)
+	}
+
+	imp := productImport{
+		tx:      s.db.Begin(),
+		storeID: storeID,
+		userID:  actor.UserID,
+		manager: actor.IsManager(),
+		now:     time.Now(),
+		summary: summary,
+		header:  header,
+	}
+
+	for i, row := range rows[1:] {
+		if isBlankRow(row) {
+			continue
+		}
+		imp.summary.Rows++
+		// A savepoint keeps the transaction usable after a failed row
+		imp.tx.SavePoint("import_row")
+		if err := imp.apply(row); err != nil {
+			imp.tx.RollbackTo("import_row")
+			imp.summary.Errors = append(imp.summary.Errors, ImportRowError{Row: i + 2, Error: err.Error()})
+		}
+	}
+
+	if len(imp.summary.Errors) > 0 || imp.summary.DryRun {
+		imp.tx.Rollback()
+		return imp.summary, nil
+	}
+	if err := imp.tx.Commit().Error; err != nil {
+		return imp.summary, failed("Failed to save import")
+	}
+	return imp.summary, nil
+}
+
+func isBlankRow(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+type productImport struct {
+	tx      *gorm.DB
+	storeID uint
+	userID  uint
+	manager bool
+	now     time.Time
+	summary ImportSummary
+	header  map[string]int
+}
+
+func (imp *productImport) field(row []string, column string) string {
+	i, ok := imp.header[column]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// apply validates one row and creates or updates its product
+func (imp *productImport) apply(row []string) error {
+	tx := imp.tx
+	name := imp.field(row, "name")
+	brandName := imp.field(row, "brand")
+	if name == "" || brandName == "" {
+		return errors.New("name and brand are required")
+	}
+
+	price, err := strconv.ParseFloat(imp.field(row, "unit_price"), 64)
+	if err != nil || price <= 0 {
+		return errors.New("unit_price must be a number greater than 0")
+	}
+
+	var threshold, opening int
+	if v := imp.field(row, "low_stock_threshold"); v != "" {
+		if threshold, err = strconv.Atoi(v); err != nil || threshold < 0 {
+			return errors.New("low_stock_threshold must be a whole number, 0 or more")
+		}
+	}
+	if v := imp.field(row, "opening_stock"); v != "" {
+		if opening, err = strconv.Atoi(v); err != nil || opening < 0 {
+			return errors.New("opening_stock must be a whole number, 0 or more")
+		}
+	}
+
+	var gstRate *float64
+	if v := imp.field(row, "gst_rate"); v != "" {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil || !models.IsValidGSTSlab(rate) {
+			return errors.New("GST rate must be one of the slabs 0, 5, 12, 18 or 28")
+		}
+		gstRate = &rate
+	}
+
+	var categoryID *uint
+	if v := imp.field(row, "category"); v != "" {
+		var category models.Category
+		if err := tx.Select("id").Where("name = ?", v).First(&category).Error; err != nil {
+			return fmt.Errorf("category %q not found", v)
+		}
+		categoryID = &category.ID
+	}
+
+	// Find or Create Brand
+	var brand models.Brand
+	if err := tx.FirstOrCreate(&brand, models.Brand{Name: brandName}).Error; err != nil {
+		return errors.New("failed to process brand")
+	}
+	brandID := brand.ID
+
+	sku := imp.field(row, "sku")
+	code := imp.field(row, "barcode")
+
+	existing, err := imp.match(imp.field(row, "id"), name, sku, code)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		product := models.Product{
+			Name:              name,
+			BrandID:           brandID,
+			CategoryID:        categoryID,
+			Description:       imp.field(row, "description"),
+			UnitPrice:         price,
+			LowStockThreshold: threshold,
+			SKU:               sku,
+			Barcode:           code,
+			HSNCode:           imp.field(row, "hsn_code"),
+			GSTRate:           gstRate,
+			IsActive:          true,
+		}
+		if err := InsertProduct(tx, &product, opening, imp.storeID, imp.userID); err != nil {
+			return err
+		}
+		imp.summary.Created++
+		return nil
+	}
+
+	p := *existing
+	if p.HasVariants {
+		return errors.New("parent products cannot be imported; import their variants")
+	}
+	if sku != "" && sku != p.SKU {
+		if err := CheckSKU(tx, sku, p.ID); err != nil {
+			return err
+		}
+	}
+	if code != "" && code != p.Barcode {
+		if err := CheckBarcode(tx, code, p.ID); err != nil {
+			return err
+		}
+	}
+
+	updates := map[string]interface{}{}
+	set := func(column string, changed bool, value interface{}) {
+		if changed {
+			updates[column] = value
+		}
+	}
+	set("name", name != p.Name, name)
+	set("brand_id", brandID != p.BrandID, brandID)
+	set("category_id", !sameUint(categoryID, p.CategoryID), categoryID)
+	set("description", imp.field(row, "description") != p.Description, imp.field(row, "description"))
+	set("low_stock_threshold", threshold != p.LowStockThreshold, threshold)
+	set("sku", sku != p.SKU, sku)
+	set("barcode", code != p.Barcode, code)
+	set("hsn_code", imp.field(row, "hsn_code") != p.HSNCode, imp.field(row, "hsn_code"))
+	set("gst_rate", !sameFloat(gstRate, p.GSTRate), gstRate)
+
+	priceChanged := price != p.UnitPrice
+	if priceChanged {
+		if !imp.manager {
+			return errors.New("only a manager can change prices")
+		}
+		updates["unit_price"] = price
+	}
+	if len(updates) == 0 {
+		imp.summary.Unchanged++
+		return nil
+	}
+
+	if err := tx.Model(&p).Updates(updates).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrBarcodeInUse
+		}
+		return errors.New("failed to update product")
+	}
+	if priceChanged {
+		if err := RecordPriceChange(tx, p.ID, price, "Catalogue import", imp.userID, imp.now); err != nil {
+			return errors.New("failed to record price history")
+		}
+	}
+	imp.summary.Updated++
+	return nil
+}
+
+// match finds the product a row refers to, or nil for a new product. The id
+// only counts when the product by that id has the row's name: in a file from
+// another instance the same id is a different product, and the SKU or barcode
+// identifies it instead.
+func (imp *productImport) match(id, name, sku, code string) (*models.Product, error) {
+	var product models.Product
+	if id != "" {
+		if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+			return nil, fmt.Errorf("id %q must be a whole number", id)
+		}
+		if imp.tx.First(&product, "id = ?", id).Error == nil && strings.EqualFold(product.Name, name) {
+			return &product, nil
+		}
+		product = models.Product{}
+	}
+	if sku != "" && imp.tx.Where("sku = ?", sku).First(&product).Error == nil {
+		return &product, nil
+	}
+	if code != "" && imp.tx.Where("barcode = ?", code).First(&product).Error == nil {
+		return &product, nil
+	}
+	return nil, nil
+}
+
+// ExportRows carries current stock in opening_stock, which re-import leaves
+// alone for existing products
+func (s *catalogService) ExportRows() ([][]string, error) {
+	var products []models.Product
+	if err := s.db.Preload("Brand").Preload("Category").
+		Where("has_variants = ?", false).Order("id").Find(&products).Error; err != nil {
+		return nil, failed("Failed to fetch products")
+	}
+
+	rows := [][]string{CatalogColumns}
+	for _, p := range products {
+		category, gstRate := "", ""
+		if p.Category != nil {
+			category = p.Category.Name
+		}
+		if p.GSTRate != nil {
+			gstRate = strconv.FormatFloat(*p.GSTRate, 'f', -1, 64)
+		}
+		rows = append(rows, []string{
+			strconv.FormatUint(uint64(p.ID), 10), p.Name, p.Brand.Name, category,
+			strconv.FormatFloat(p.UnitPrice, 'f', 2, 64), p.SKU, p.Barcode, p.HSNCode, gstRate,
+			strconv.Itoa(p.LowStockThreshold), strconv.Itoa(p.CurrentStock), p.Description,
+		})
+	}
+	return rows, nil
+}
@@ -0,0 +1,117 @@
+package service_test
+
+import (
+	"testing"
+
+	"billing-app/internal/models"
+	"billing-app/internal/service"
+	"billing-app/internal/service/servicetest"
+)
+
+// An export imports back unchanged into the instance it came from, and into
+// another one where its product ids mean nothing
+func TestImportExportedCatalog(t *testing.T) {
+	source, err := servicetest.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	admin := service.Actor{UserID: source.Admin.ID, Role: "admin"}
+	for _, req := range []service.CreateProductRequest{
+		{Name: "Tea", BrandName: "Estate", UnitPrice: 120, SKU: "TEA-1", OpeningStock: 5},
+		{Name: "Coffee", BrandName: "Estate", UnitPrice: 250, Barcode: "4006381333931", OpeningStock: 3},
+	} {
+		if _, err := source.Inventory.CreateProduct(req, admin); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rows, err := source.Catalog.ExportRows()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		setup         func(env *servicetest.Env) error // Prepares a fresh instance; nil imports into the source
+		wantCreated   int
+		wantUnchanged int
+		wantUpdated   int
+	}{
+		{"same instance", nil, 0, 2, 0},
+		{"fresh instance", func(*servicetest.Env) error { return nil }, 2, 0, 0},
+		{"branch holding the products under other ids", func(env *servicetest.Env) error {
+			actor := service.Actor{UserID: env.Admin.ID, Role: "admin"}
+			if _, err := env.Inventory.CreateProduct(service.CreateProductRequest{Name: "Filler A", BrandName: "Other", UnitPrice: 1}, actor); err != nil {
+				return err
+			}
+			if _, err := env.Inventory.CreateProduct(service.CreateProductRequest{Name: "Filler B", BrandName: "Other", UnitPrice: 1}, actor); err != nil {
+				return err
+			}
+			_, err := env.Inventory.CreateProduct(service.CreateProductRequest{Name: "Tea", BrandName: "Estate", UnitPrice: 110, SKU: "TEA-1"}, actor)
+			return err
+		}, 1, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := source
+			if tt.setup != nil {
+				if env, err = servicetest.Open(t.TempDir()); err != nil {
+					t.Fatal(err)
+				}
+				defer env.Close()
+				if err := tt.setup(env); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			summary, err := env.Catalog.ImportProducts(rows, service.ImportOptions{}, service.Actor{UserID: env.Admin.ID, Role: "admin"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(summary.Errors) > 0 {
+				t.Fatalf("import errors: %+v", summary.Errors)
+			}
+			if summary.Created != tt.wantCreated || summary.Unchanged != tt.wantUnchanged || summary.Updated != tt.wantUpdated {
+				t.Errorf("created %d, unchanged %d, updated %d; want %d, %d, %d",
+					summary.Created, summary.Unchanged, summary.Updated, tt.wantCreated, tt.wantUnchanged, tt.wantUpdated)
+			}
+
+			for sku, price := range map[string]float64{"TEA-1": 120} {
+				var p models.Product
+				if err := env.DB.Where("sku = ?", sku).First(&p).Error; err != nil {
+					t.Fatal(err)
+				}
+				if p.UnitPrice != price {
+					t.Errorf("%s priced %.2f, want %.2f", sku, p.UnitPrice, price)
+				}
+			}
+			var coffee int64
+			env.DB.Model(&models.Product{}).Where("barcode = ?", "4006381333931").Count(&coffee)
+			if coffee != 1 {
+				t.Errorf("%d products with the coffee barcode, want 1", coffee)
+			}
+		})
+	}
+
+	// A rename in the file still finds its product, by SKU
+	renamed := make([][]string, len(rows))
+	for i, row := range rows {
+		renamed[i] = append([]string(nil), row...)
+		if row[1] == "Tea" {
+			renamed[i][1] = "Green Tea"
+		}
+	}
+	summary, err := source.Catalog.ImportProducts(renamed, service.ImportOptions{}, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Errors) > 0 || summary.Updated != 1 || summary.Created != 0 {
+		t.Errorf("rename: %+v, want one update", summary)
+	}
+	var tea models.Product
+	source.DB.Where("sku = ?", "TEA-1").First(&tea)
+	if tea.Name != "Green Tea" {
+		t.Errorf("TEA-1 is named %q, want Green Tea", tea.Name)
+	}
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"billing-app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type CreateCategoryRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	ParentID    *uint    `json:"parent_id"`
+	Description string   `json:"description"`
+	HSNCode     string   `json:"hsn_code"`
+	GSTRate     *float64 `json:"gst_rate"`
+}
+
+// UpdateCategoryRequest carries only the fields to change
+type UpdateCategoryRequest struct {
+	Name        *string  `json:"name"`
+	ParentID    *uint    `json:"parent_id"`
+	MakeRoot    bool     `json:"make_root"` // Moves the category to the top level
+	Description *string  `json:"description"`
+	HSNCode     *string  `json:"hsn_code"`
+	GSTRate     *float64 `json:"gst_rate"`
+	ClearGST    bool     `json:"clear_gst"`
+}
+
+// CategoryMerge counts what MergeCategory moved
+type CategoryMerge struct {
+	ProductsMoved      int64
+	SubcategoriesMoved int
+}
+
+func (s *catalogService) ListCategories() ([]models.Category, error) {
+	var categories []models.Category
+	err := s.db.Order("path").Find(&categories).Error
+	return categories, err
+}
+
+// CategoryTree nests categories under their parents, starting at parentID (nil for the top level)
+func CategoryTree(categories []models.Category, parentID *uint) []models.Category {
+	nodes := []models.Category{}
+	for _, cat := range categories {
+		if sameUint(cat.ParentID, parentID) {
+			cat.Children = CategoryTree(categories, &cat.ID)
+			nodes = append(nodes, cat)
+		}
+	}
+	return nodes
+}
+
+func categoryPath(parent *models.Category, id uint) string {
+	if parent == nil {
+		return fmt.Sprintf("/%d/", id)
+	}
+	return fmt.Sprintf("%s%d/", parent.Path, id)
+}
+
+// categorySubtree is a subquery of the IDs of a category and its descendants,
+// for filters like "category_id IN (?)"
+func categorySubtree(db *gorm.DB, categoryID uint) (*gorm.DB, error) {
+	var category models.Category
+	if err := db.Select("id", "path").First(&category, categoryID).Error; err != nil {
+		return nil, invalid("Category not found")
+	}
+	return db.Model(&models.Category{}).Select("id").Where("path LIKE ?", category.SubtreePattern()), nil
+}
+
+var errCategoryCycle = errors.New("a category cannot move under itself or its own subcategory")
+
+// moveCategory re-parents a category and rewrites the paths of its subtree
+func moveCategory(tx *gorm.DB, category models.Category, parent *models.Category) error {
+	if parent != nil && strings.HasPrefix(parent.Path, category.Path) {
+		return errCategoryCycle
+	}
+	var parentID *uint
+	if parent != nil {
+		parentID = &parent.ID
+	}
+	if err := tx.Model(&category).Update("parent_id", parentID).Error; err != nil {
+		return err
+	}
+
+	newPath := categoryPath(parent, category.ID)
+	var subtree []models.Category
+	if err := tx.Select("id", "path").Where("path LIKE ?", category.SubtreePattern()).Find(&subtree).Error; err != nil {
+		return err
+	}
+	for _, node := range subtree {
+		path := newPath + strings.TrimPrefix(node.Path, category.Path)
+		if err := tx.Model(&models.Category{}).Where("id = ?", node.ID).Update("path", path).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *catalogService) CreateCategory(req CreateCategoryRequest) (models.Category, error) {
+	var category models.Category
+	if req.GSTRate != nil && !models.IsValidGSTSlab(*req.GSTRate) {
+		return category, invalid("GST rate must be one of the slabs 0, 5, 12, 18 or 28")
+	}
+
+	var parent *models.Category
+	if req.ParentID != nil {
+		parent = &models.Category{}
+		if err := s.db.First(parent, *req.ParentID).Error; err != nil {
+			return category, invalid("Parent category not found")
+		}
+	}
+
+	category = models.Category{
+		Name:        strings.TrimSpace(req.Name),
+		ParentID:    req.ParentID,
+		Description: req.Description,
+		HSNCode:     req.HSNCode,
+		GSTRate:     req.GSTRate,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&category).Error; err != nil {
+			return failed("Failed to create category (Name might be duplicate)")
+		}
+		// The path needs the new ID
+		category.Path = categoryPath(parent, category.ID)
+		if err := tx.Model(&category).Update("path", category.Path).Error; err != nil {
+			return failed("Failed to create category")
+		}
+		return nil
+	})
+	return category, err
+}
+
+func (s *catalogService) UpdateCategory(id uint, req UpdateCategoryRequest) (models.Category, error) {
+	var category models.Category
+	if req.GSTRate != nil && !models.IsValidGSTSlab(*req.GSTRate) {
+		return category, invalid("GST rate must be one of the slabs 0, 5, 12, 18 or 28")
+	}
+
+	if err := s.db.First(&category, id).Error; err != nil {
+		return category, notFound("Category not found")
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return category, invalid("Name cannot be empty")
+		}
+		var count int64
+		s.db.Model(&models.Category{}).Where("name = ? AND id <> ?", name, category.ID).Count(&count)
+		if count > 0 {
+			return category, conflict("Another category has this name; merge them instead")
+		}
+		updates["name"] = name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.HSNCode != nil {
+		updates["hsn_code"] = *req.HSNCode
+	}
+	if req.ClearGST {
+		updates["gst_rate"] = nil
+	} else if req.GSTRate != nil {
+		updates["gst_rate"] = *req.GSTRate
+	}
+
+	var parent *models.Category
+	moving := req.MakeRoot || (req.ParentID != nil && !sameUint(req.ParentID, category.ParentID))
+	if moving && !req.MakeRoot {
+		parent = &models.Category{}
+		if err := s.db.First(parent, *req.ParentID).Error; err != nil {
+			return category, invalid("Parent category not found")
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			if err := tx.Model(&category).Updates(updates).Error; err != nil {
+				return failed("Failed to update category")
+			}
+		}
+		if moving {
+			if err := moveCategory(tx, category, parent); err != nil {
+				return invalid("%s", err.Error())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return category, err
+	}
+
+	s.db.First(&category, category.ID)
+	return category, nil
+}
+
+func (s *catalogService) DeleteCategory(id uint) error {
+	var category models.Category
+	if err := s.db.First(&category, id).Error; err != nil {
+		return notFound("Category not found")
+	}
+
+	var products, children, takes int64
+	s.db.Unscoped().Model(&models.Product{}).Where("category_id = ?", category.ID).Count(&products)
+	s.db.Model(&models.Category{}).Where("parent_id = ?", category.ID).Count(&children)
+	s.db.Model(&models.StockTake{}).Where("category_id = ?", category.ID).Count(&takes)
+	if products > 0 || children > 0 || takes > 0 {
+		return conflict("Category is in use; merge it into another category instead").with(map[string]interface{}{
+			"products":      products,
+			"subcategories": children,
+			"stock_takes":   takes,
+		})
+	}
+
+	if err := s.db.Delete(&category).Error; err != nil {
+		return failed("Failed to delete category")
+	}
+	return nil
+}
+
+func (s *catalogService) MergeCategory(id, intoID uint) (CategoryMerge, error) {
+	var merge CategoryMerge
+	var source, target models.Category
+	if err := s.db.First(&source, id).Error; err != nil {
+		return merge, notFound("Category not found")
+	}
+	if err := s.db.First(&target, intoID).Error; err != nil {
+		return merge, invalid("Target category not found")
+	}
+	if strings.HasPrefix(target.Path, source.Path) {
+		return merge, invalid("Cannot merge a category into itself or its own subcategory")
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Unscoped().Model(&models.Product{}).Where("category_id = ?", source.ID).Update("category_id", target.ID)
+		if res.Error != nil {
+			return failed("Failed to move products")
+		}
+		merge.ProductsMoved = res.RowsAffected
+
+		if err := tx.Model(&models.StockTake{}).Where("category_id = ?", source.ID).Update("category_id", target.ID).Error; err != nil {
+			return failed("Failed to move stock takes")
+		}
+
+		var children []models.Category
+		tx.Where("parent_id = ?", source.ID).Find(&children)
+		for _, child := range children {
+			if err := moveCategory(tx, child, &target); err != nil {
+				return failed("Failed to move subcategories")
+			}
+		}
+		merge.SubcategoriesMoved = len(children)
+
+		if err := tx.Delete(&source).Error; err != nil {
+			return failed("Failed to delete merged category")
+		}
+		return nil
+	})
+	return merge, err
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"billing-app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// InventoryService creates products and changes their stock. Every change goes
+// through the stock ledger (MoveStock).
+type InventoryService interface {
+	// CreateProduct adds a product, or a parent with its variants, with any
+	// opening stock at the request's store (the actor's by default)
+	CreateProduct(req CreateProductRequest, actor Actor) (models.Product, error)
+	// AddVariant adds a size/colour to a parent product. A plain product can
+	// become a parent only while it has no stock history of its own.
+	AddVariant(parentID uint, req CreateVariantRequest, userID uint) (models.Product, error)
+	// AddStock receives stock outside a purchase order
+	AddStock(req AddStockRequest, actor Actor) (models.StockMovement, error)
+	// AdjustStock posts a one-off correction (damage, theft, count error)
+	AdjustStock(req AdjustStockRequest, actor Actor) (models.StockMovement, error)
+
+	// LowStockAlerts lists products at or below their threshold. With a
+	// storeID the check, and the CurrentStock returned, is the store's stock.
+	LowStockAlerts(storeID uint) ([]models.Product, error)
+	// Batches lists a product's batches, earliest expiry first; all includes
+	// empty ones and a zero storeID matches every store
+	Batches(productID, storeID uint, all bool) ([]models.StockBatch, error)
+	// ExpiryAlerts lists batches in stock expiring within days
+	ExpiryAlerts(days int, storeID uint) ([]BatchAlert, error)
+	// ExpiredStock lists expired batches still in stock. Billing will not
+	// sell them; they are written off with an EXPIRED adjustment.
+	ExpiredStock(storeID uint) ([]BatchAlert, error)
+	// Movements returns a product's ledger, oldest first
+	Movements(filter MovementFilter) ([]models.StockMovement, error)
+	// StockAsOf reconstructs a product's stock before the end time from the
+	// ledger, at one store or, with storeID 0, across all stores
+	StockAsOf(productID, storeID uint, end time.Time) (StockLevel, error)
+	// CheckConsistency compares the ledger, batch and store stock sums with
+	// CurrentStock for every product
+	CheckConsistency() (StockCheck, error)
+}
+
+type CreateProductRequest struct {
+	Name              string                 `json:"name" binding:"required"`
+	BrandName         string                 `json:"brand_name" binding:"required"`
+	CategoryID        *uint                  `json:"category_id"`
+	SupplierID        *uint                  `json:"supplier_id"`
+	Description       string                 `json:"description"`
+	UnitPrice         float64                `json:"unit_price" binding:"required,gt=0"`
+	LowStockThreshold int                    `json:"low_stock_threshold"`
+	SKU               string                 `json:"sku"`
+	Barcode           string                 `json:"barcode"`
+	HSNCode           string                 `json:"hsn_code"`
+	GSTRate           *float64               `json:"gst_rate"`
+	OpeningStock      int                    `json:"opening_stock"`
+	TrackBatches      bool                   `json:"track_batches"`                     // Opening stock goes to the UNBATCHED batch
+	StoreID           *uint                  `json:"store_id"`                          // Where opening stock is held; defaults to the user's store
+	Variants          []CreateVariantRequest `json:"variants" binding:"omitempty,dive"` // Makes this a parent product
+}
+
+// CreateVariantRequest describes one size/colour of a parent product. Zero
+// price and threshold inherit the parent's.
+type CreateVariantRequest struct {
+	Size              string  `json:"size"`
+	Colour            string  `json:"colour"`
+	SKU               string  `json:"sku"`
+	Barcode           string  `json:"barcode"`
+	UnitPrice         float64 `json:"unit_price" binding:"gte=0"`
+	LowStockThreshold int     `json:"low_stock_threshold"`
+	OpeningStock      int     `json:"opening_stock" binding:"gte=0"`
+}
+
+// BatchRequest identifies the batch received stock belongs to
+type BatchRequest struct {
+	BatchNo    string `json:"batch_no"`
+	MfgDate    string `json:"mfg_date"`    // YYYY-MM-DD
+	ExpiryDate string `json:"expiry_date"` // YYYY-MM-DD
+}
+
+// ToBatch returns nil when no batch number was given
+func (r BatchRequest) ToBatch(costPrice float64) (*models.StockBatch, error) {
+	batchNo := strings.TrimSpace(r.BatchNo)
+	if batchNo == "" {
+		return nil, nil
+	}
+	mfg, err := ParseOptionalDate(r.MfgDate)
+	if err != nil {
+		return nil, err
+	}
+	expiry, err := ParseOptionalDate(r.ExpiryDate)
+	if err != nil {
+		return nil, err
+	}
+	return &models.StockBatch{BatchNo: batchNo, MfgDate: mfg, ExpiryDate: expiry, CostPrice: costPrice}, nil
+}
+
+func ParseOptionalDate(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid date %q, expected YYYY-MM-DD", s)
+	}
+	return &t, nil
+}
+
+type AddStockRequest struct {
+	ProductID    int     `json:"product_id" binding:"required"`
+	Quantity     int     `json:"quantity" binding:"required,gt=0"` // Reductions go through adjustments
+	CostPrice    float64 `json:"cost_price" binding:"gte=0"`
+	StoreID      *uint   `json:"store_id"` // Defaults to the user's store
+	BatchRequest         // Required for batch-tracked products
+}
+
+type AdjustStockRequest struct {
+	ProductID  uint   `json:"product_id" binding:"required"`
+	Quantity   int    `json:"quantity" binding:"required,ne=0"` // Signed change
+	ReasonCode string `json:"reason_code" binding:"required"`
+	Note       string `json:"note"`
+	BatchID    *uint  `json:"batch_id"` // Batch-tracked products; reductions default to FEFO
+	StoreID    *uint  `json:"store_id"` // Defaults to the user's store
+}
+
+type inventoryService struct {
+	db *gorm.DB
+}
+
+func NewInventoryService(db *gorm.DB) InventoryService {
+	return &inventoryService{db: db}
+}
+
+func (s *inventoryService) CreateProduct(req CreateProductRequest, actor Actor) (models.Product, error) {
+	storeID, err := RequestStoreID(s.db, req.StoreID, actor)
+	if err != nil {
+		return models.Product{}, storeError(err)
+	}
+	userID := actor.UserID
+
+	if req.GSTRate != nil && !models.IsValidGSTSlab(*req.GSTRate) {
+		return models.Product{}, invalid("GST rate must be one of the slabs 0, 5, 12, 18 or 28")
+	}
+	if len(req.Variants) > 0 && (req.OpeningStock != 0 || req.Barcode != "") {
+		return models.Product{}, invalid("Stock and barcodes belong to the variants of a parent product")
+	}
+
+	// Find or Create Brand
+	var brand models.Brand
+	if err := s.db.FirstOrCreate(&brand, models.Brand{Name: req.BrandName}).Error; err != nil {
+		return models.Product{}, failed("Failed to process brand")
+	}
+
+	product := models.Product{
+		Name:              req.Name,
+		BrandID:           brand.ID,
+		CategoryID:        req.CategoryID,
+		SupplierID:        req.SupplierID,
+		Description:       req.Description,
+		UnitPrice:         req.UnitPrice,
+		LowStockThreshold: req.LowStockThreshold,
+		SKU:               strings.TrimSpace(req.SKU),
+		Barcode:           strings.TrimSpace(req.Barcode),
+		HSNCode:           req.HSNCode,
+		GSTRate:           req.GSTRate,
+		HasVariants:       len(req.Variants) > 0,
+		TrackBatches:      req.TrackBatches,
+		IsActive:          true,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := InsertProduct(tx, &product, req.OpeningStock, storeID, userID); err != nil {
+			return err
+		}
+		for _, v := range req.Variants {
+			variant := newVariant(product, v)
+			if err := InsertProduct(tx, &variant, v.OpeningStock, storeID, userID); err != nil {
+				var e *Error
+				if errors.As(err, &e) {
+					return newError(e.Kind, "Variant %s: %s", variant.Name, e.Message)
+				}
+				return err
+			}
+			product.Variants = append(product.Variants, variant)
+		}
+		return nil
+	})
+	return product, err
+}
+
+func (s *inventoryService) AddVariant(parentID uint, req CreateVariantRequest, userID uint) (models.Product, error) {
+	var parent models.Product
+	if err := s.db.First(&parent, parentID).Error; err != nil {
+		return models.Product{}, notFound("Product not found")
+	}
+	if parent.ParentID != nil {
+		return models.Product{}, invalid("A variant cannot have variants of its own")
+	}
+	if !parent.HasVariants {
+		var movements int64
+		s.db.Model(&models.StockMovement{}).Where("product_id = ?", parent.ID).Count(&movements)
+		if movements > 0 || parent.CurrentStock != 0 {
+			return models.Product{}, conflict("Product already has stock; create a new parent product for its variants")
+		}
+	}
+
+	storeID, err := UserStoreID(s.db, userID)
+	if err != nil {
+		return models.Product{}, storeError(err)
+	}
+
+	var variant models.Product
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if !parent.HasVariants {
+			if err := tx.Model(&parent).Updates(map[string]interface{}{"has_variants": true, "barcode": ""}).Error; err != nil {
+				return failed("Failed to update product")
+			}
+			if parent.Barcode != "" && req.Barcode == "" {
+				// The parent is no longer sellable, so its barcode moves to the first variant
+				req.Barcode = parent.Barcode
+				parent.Barcode = ""
+			}
+		}
+
+		variant = newVariant(parent, req)
+		return InsertProduct(tx, &variant, req.OpeningStock, storeID, userID)
+	})
+	return variant, err
+}
+
+func (s *inventoryService) AddStock(req AddStockRequest, actor Actor) (models.StockMovement, error) {
+	storeID, err := RequestStoreID(s.db, req.StoreID, actor)
+	if err != nil {
+		return models.StockMovement{}, storeError(err)
+	}
+	userID := actor.UserID
+
+	var product models.Product
+	if err := s.db.Select("id", "track_batches").First(&product, req.ProductID).Error; err != nil {
+		return models.StockMovement{}, notFound("Product not found")
+	}
+	batch, err := req.ToBatch(req.CostPrice)
+	if err != nil {
+		return models.StockMovement{}, invalid("%s", err.Error())
+	}
+	if product.TrackBatches && batch == nil {
+		return models.StockMovement{}, invalid("batch_no is required for batch-tracked products")
+	}
+
+	var movement models.StockMovement
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		entry := models.StockEntry{
+			ProductID:     uint(req.ProductID),
+			QuantityAdded: req.Quantity,
+			CostPrice:     req.CostPrice,
+			StoreID:       &storeID,
+			AddedBy:       userID,
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return failed("Failed to log stock entry")
+		}
+
+		// Update Product Stock
+		var err error
+		movement, err = MoveStock(tx, StockChange{
+			ProductID: uint(req.ProductID),
+			Quantity:  req.Quantity,
+			Type:      models.MovementPurchase,
+			RefType:   "STOCK_ENTRY",
+			RefID:     &entry.ID,
+			UserID:    userID,
+			StoreID:   storeID,
+			Batch:     batch,
+		})
+		if err != nil {
+			return stockError(err)
+		}
+		if movement.BatchID != nil {
+			if err := tx.Model(&entry).Update("batch_id", *movement.BatchID).Error; err != nil {
+				return failed("Failed to log stock entry")
+			}
+		}
+		return nil
+	})
+	return movement, err
+}
+
+func (s *inventoryService) AdjustStock(req AdjustStockRequest, actor Actor) (models.StockMovement, error) {
+	storeID, err := RequestStoreID(s.db, req.StoreID, actor)
+	if err != nil {
+		return models.StockMovement{}, storeError(err)
+	}
+	userID := actor.UserID
+
+	if !models.IsValidAdjustmentReason(req.ReasonCode) {
+		return models.StockMovement{}, invalid("Invalid reason code").with(map[string]interface{}{"allowed": models.AdjustmentReasons})
+	}
+
+	var movement models.StockMovement
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		movement, err = MoveStock(tx, StockChange{
+			ProductID: req.ProductID,
+			Quantity:  req.Quantity,
+			Type:      models.MovementTypeForReason(req.ReasonCode),
+			RefType:   "ADJUSTMENT",
+			RefNo:     req.ReasonCode,
+			Note:      req.Note,
+			UserID:    userID,
+			StoreID:   storeID,
+			BatchID:   req.BatchID,
+		})
+		if err != nil {
+			var short *StockConflictError
+			if errors.As(err, &short) {
+				return conflict("%s", short.Error())
+			}
+			// Unknown products and batches are the caller's mistake here
+			return invalid("%s", err.Error())
+		}
+		return nil
+	})
+	return movement, err
+}
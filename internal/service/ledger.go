@@ -0,0 +1,128 @@
+package service
+
+import (
+	"time"
+
+	"billing-app/internal/models"
+)
+
+// MovementFilter selects ledger entries for one product; zero fields match all
+type MovementFilter struct {
+	ProductID uint
+	StoreID   uint
+	From      time.Time // Inclusive
+	To        time.Time // Exclusive
+}
+
+// StockLevel is a product's stock rebuilt from the ledger
+type StockLevel struct {
+	Product   models.Product
+	Stock     int
+	Movements int64
+}
+
+// StockDiscrepancy is a product whose ledger (or, for batch-tracked products,
+// whose batches) do not add up to CurrentStock
+type StockDiscrepancy struct {
+	ProductID    uint   `json:"product_id"`
+	Name         string `json:"name"`
+	CurrentStock int    `json:"current_stock"`
+	LedgerStock  int    `json:"ledger_stock"`
+	Difference   int    `json:"difference"`
+	BatchStock   *int   `json:"batch_stock,omitempty"`
+	StoreStock   *int   `json:"store_stock,omitempty"`
+}
+
+// StockCheck is the result of CheckConsistency
+type StockCheck struct {
+	ProductsChecked int
+	Discrepancies   []StockDiscrepancy
+}
+
+func (s *inventoryService) Movements(filter MovementFilter) ([]models.StockMovement, error) {
+	movements := []models.StockMovement{}
+	query := s.db.Preload("User").Where("product_id = ?", filter.ProductID).Order("id")
+	if filter.StoreID != 0 {
+		query = query.Where("store_id = ?", filter.StoreID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	err := query.Find(&movements).Error
+	return movements, err
+}
+
+func (s *inventoryService) StockAsOf(productID, storeID uint, end time.Time) (StockLevel, error) {
+	var level StockLevel
+	if err := s.db.Unscoped().First(&level.Product, productID).Error; err != nil {
+		return level, notFound("Product not found")
+	}
+
+	var result struct {
+		Stock     int
+		Movements int64
+	}
+	query := s.db.Model(&models.StockMovement{}).Where("product_id = ? AND created_at < ?", productID, end)
+	if storeID != 0 {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if err := query.Select("COALESCE(SUM(quantity), 0) AS stock, COUNT(*) AS movements").Scan(&result).Error; err != nil {
+		return level, failed("Failed to read the stock ledger")
+	}
+
+	level.Stock, level.Movements = result.Stock, result.Movements
+	return level, nil
+}
+
+// productTotals sums a column per product
+func (s *inventoryService) productTotals(model interface{}, column string) map[uint]int {
+	var sums []struct {
+		ProductID uint
+		Total     int
+	}
+	s.db.Model(model).Select("product_id, SUM(" + column + ") AS total").Group("product_id").Scan(&sums)
+	totals := map[uint]int{}
+	for _, row := range sums {
+		totals[row.ProductID] = row.Total
+	}
+	return totals
+}
+
+func (s *inventoryService) CheckConsistency() (StockCheck, error) {
+	var products []models.Product
+	if err := s.db.Select("id", "name", "current_stock", "track_batches").Find(&products).Error; err != nil {
+		return StockCheck{}, failed("Failed to fetch products")
+	}
+
+	ledger := s.productTotals(&models.StockMovement{}, "quantity")
+	batches := s.productTotals(&models.StockBatch{}, "quantity_remaining")
+	stores := s.productTotals(&models.StoreStock{}, "quantity")
+
+	check := StockCheck{ProductsChecked: len(products), Discrepancies: []StockDiscrepancy{}}
+	for _, p := range products {
+		batchMismatch := p.TrackBatches && batches[p.ID] != p.CurrentStock
+		storeMismatch := stores[p.ID] != p.CurrentStock
+		if ledger[p.ID] != p.CurrentStock || batchMismatch || storeMismatch {
+			d := StockDiscrepancy{
+				ProductID:    p.ID,
+				Name:         p.Name,
+				CurrentStock: p.CurrentStock,
+				LedgerStock:  ledger[p.ID],
+				Difference:   p.CurrentStock - ledger[p.ID],
+			}
+			if batchMismatch {
+				batchStock := batches[p.ID]
+				d.BatchStock = &batchStock
+			}
+			if storeMismatch {
+				storeStock := stores[p.ID]
+				d.StoreStock = &storeStock
+			}
+			check.Discrepancies = append(check.Discrepancies, d)
+		}
+	}
+	return check, nil
+}
@@ -0,0 +1,58 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"billing-app/internal/models"
+	"billing-app/internal/service"
+	"billing-app/internal/service/servicetest"
+)
+
+func TestStockAsOfByStore(t *testing.T) {
+	env, err := servicetest.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+
+	branch := models.Store{Code: "BR2", Name: "Branch", IsActive: true}
+	if err := env.DB.Create(&branch).Error; err != nil {
+		t.Fatal(err)
+	}
+	product, err := env.Product("Ledgered", 20, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.Inventory.AddStock(service.AddStockRequest{
+		ProductID: int(product.ID),
+		Quantity:  4,
+		StoreID:   &branch.ID,
+	}, service.Actor{UserID: env.Admin.ID, Role: "admin"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		storeID   uint
+		end       time.Time
+		stock     int
+		movements int64
+	}{
+		{"all stores", 0, time.Now().Add(time.Hour), 11, 2},
+		{"default store", env.Store.ID, time.Now().Add(time.Hour), 7, 1},
+		{"branch", branch.ID, time.Now().Add(time.Hour), 4, 1},
+		{"before any movement", 0, time.Now().AddDate(0, 0, -1), 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, err := env.Inventory.StockAsOf(product.ID, tt.storeID, tt.end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if level.Stock != tt.stock || level.Movements != tt.movements {
+				t.Errorf("stock %d over %d movements, want %d over %d", level.Stock, level.Movements, tt.stock, tt.movements)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"billing-app/internal/models"
+	"billing-app/internal/sequence"
+
+	"gorm.io/gorm"
+)
+
+// OrderService takes orders from the public storefront for a store to fulfil
+type OrderService interface {
+	// SubmitOrder records an order for the customer, creating or updating them by
+	// mobile, and returns a wa.me link confirming it to them on WhatsApp
+	SubmitOrder(req SubmitOrderRequest) (models.CustomerOrder, string, error)
+	// ListOrders returns orders newest first; empty status and zero storeID match all
+	ListOrders(status string, storeID uint) ([]models.CustomerOrder, error)
+	UpdateStatus(id uint, status string) error
+}
+
+type SubmitOrderRequest struct {
+	CustomerMobile string            `json:"customer_mobile" binding:"required"`
+	CustomerName   string            `json:"customer_name" binding:"required"`
+	Address        string            `json:"address"`
+	Items          []BillItemRequest `json:"items" binding:"required,min=1,dive"` // Reuse BillItemRequest structure
+	StoreID        uint              `json:"store_id"`                            // Fulfilling store; defaults to the default store
+}
+
+type orderService struct {
+	db *gorm.DB
+}
+
+func NewOrderService(db *gorm.DB) OrderService {
+	return &orderService{db: db}
+}
+
+func (s *orderService) SubmitOrder(req SubmitOrderRequest) (models.CustomerOrder, string, error) {
+	if len(req.Items) == 0 {
+		return models.CustomerOrder{}, "", invalid("Order has no items")
+	}
+	for _, itemReq := range req.Items {
+		if itemReq.Quantity <= 0 {
+			return models.CustomerOrder{}, "", invalid("Quantity for product ID %d must be greater than 0", itemReq.ProductID)
+		}
+	}
+
+	storeID, err := ResolveStoreID(s.db, req.StoreID)
+	if err != nil {
+		return models.CustomerOrder{}, "", invalid("%s", err.Error())
+	}
+
+	// Find or Create Customer
+	var customer models.Customer
+	if err := s.db.Where("mobile = ?", req.CustomerMobile).First(&customer).Error; err != nil {
+		// New Customer
+		customer = models.Customer{
+			Name:    req.CustomerName,
+			Mobile:  req.CustomerMobile,
+			Address: req.Address,
+		}
+		if err := s.db.Create(&customer).Error; err != nil {
+			return models.CustomerOrder{}, "", failed("Failed to process customer info")
+		}
+	} else {
+		// Existing Customer - Update details if changed
+		if customer.Name != req.CustomerName || customer.Address != req.Address {
+			customer.Name = req.CustomerName
+			customer.Address = req.Address
+			s.db.Save(&customer)
+		}
+	}
+
+	// Build detailed message
+	var msgBuilder strings.Builder
+
+	var order models.CustomerOrder
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		orderNo, err := sequence.Next(tx, sequence.Order(), now)
+		if err != nil {
+			return failed("Failed to assign order number")
+		}
+
+		order = models.CustomerOrder{
+			OrderNo:    orderNo,
+			CustomerID: customer.ID,
+			StoreID:    &storeID,
+			Status:     "PENDING",
+			OrderDate:  now,
+		}
+
+		if err := tx.Create(&order).Error; err != nil {
+			return failed("Failed to create order")
+		}
+
+		// Initial message header
+		msgBuilder.WriteString(fmt.Sprintf("Hello *%s*, your order *%s* is placed successfully! 🛒\n\n*Items Ordered:*\n", customer.Name, order.OrderNo))
+
+		for _, itemReq := range req.Items {
+			// Verify Product Price/Existence
+			var product models.Product
+			if err := tx.First(&product, itemReq.ProductID).Error; err != nil {
+				return invalid("Invalid product")
+			}
+
+			itemTotal := product.UnitPrice * float64(itemReq.Quantity)
+			order.TotalEstimated += itemTotal
+
+			// Add item to message
+			msgBuilder.WriteString(fmt.Sprintf("• %s x %d - ₹%.2f\n", product.Name, itemReq.Quantity, itemTotal))
+
+			orderItem := models.OrderItem{
+				OrderID:   order.ID,
+				ProductID: itemReq.ProductID,
+				Quantity:  itemReq.Quantity,
+			}
+			if err := tx.Create(&orderItem).Error; err != nil {
+				return failed("Failed to add order item")
+			}
+		}
+
+		// Update total
+		return tx.Model(&order).Update("total_estimated", order.TotalEstimated).Error
+	})
+	if err != nil {
+		return order, "", err
+	}
+
+	// Finalize WhatsApp Message
+	msgBuilder.WriteString(fmt.Sprintf("\n*Total Amount:* ₹%.2f\n", order.TotalEstimated))
+	if customer.Address != "" {
+		msgBuilder.WriteString(fmt.Sprintf("*Delivery Address:* %s\n", customer.Address))
+	}
+	msgBuilder.WriteString("\nThank you for shopping with us! 🙏")
+
+	// URL Encode the message
+	encodedMsg := url.QueryEscape(msgBuilder.String())
+
+	// Format mobile number (ensure 91 prefix for India if 10 digits)
+	targetMobile := customer.Mobile
+	if len(targetMobile) == 10 {
+		targetMobile = "91" + targetMobile
+	}
+
+	return order, fmt.Sprintf("https://wa.me/%s?text=%s", targetMobile, encodedMsg), nil
+}
+
+func (s *orderService) ListOrders(status string, storeID uint) ([]models.CustomerOrder, error) {
+	var orders []models.CustomerOrder
+
+	// Explicitly preload Product then Brand to ensure deep nesting works
+	query := s.db.Preload("Customer").Preload("Items.Product").Preload("Items.Product.Brand").Order("order_date desc")
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if storeID != 0 {
+		query = query.Where("store_id = ?", storeID)
+	}
+
+	err := query.Find(&orders).Error
+	return orders, err
+}
+
+func (s *orderService) UpdateStatus(id uint, status string) error {
+	return s.db.Model(&models.CustomerOrder{}).Where("id = ?", id).Update("status", status).Error
+}
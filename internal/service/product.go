@@ -0,0 +1,180 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"billing-app/internal/barcode"
+	"billing-app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBarcodeInUse = errors.New("barcode is already assigned to another product")
+	ErrSKUInUse     = errors.New("SKU is already assigned to another product")
+)
+
+// CheckBarcode validates the check digit and that no other product, by its main
+// barcode or an extra one, already uses the code
+func CheckBarcode(db *gorm.DB, code string, productID uint) error {
+	if err := barcode.Validate(code); err != nil {
+		return err
+	}
+	var count int64
+	db.Model(&models.Product{}).Where("barcode = ? AND id <> ?", code, productID).Count(&count)
+	if count > 0 {
+		return ErrBarcodeInUse
+	}
+	db.Model(&models.ProductBarcode{}).Where("code = ? AND product_id <> ?", code, productID).Count(&count)
+	if count > 0 {
+		return ErrBarcodeInUse
+	}
+	return nil
+}
+
+func CheckSKU(db *gorm.DB, sku string, productID uint) error {
+	var count int64
+	db.Model(&models.Product{}).Where("sku = ? AND id <> ?", sku, productID).Count(&count)
+	if count > 0 {
+		return ErrSKUInUse
+	}
+	return nil
+}
+
+// codeError classifies a CheckBarcode or CheckSKU failure
+func codeError(err error) *Error {
+	if errors.Is(err, ErrBarcodeInUse) || errors.Is(err, ErrSKUInUse) {
+		return conflict("%s", err.Error())
+	}
+	return invalid("%s", err.Error())
+}
+
+// productWriteError reports a failed product insert or update. The unique
+// barcode index catches a code taken by a concurrent write after CheckBarcode.
+func productWriteError(err error, fallback string) *Error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return conflict("%s", ErrBarcodeInUse.Error())
+	}
+	return failed("%s", fallback)
+}
+
+// InsertProduct adds a product with its first price history row and any
+// opening stock at storeID. It must run inside tx.
+func InsertProduct(tx *gorm.DB, product *models.Product, openingStock int, storeID, userID uint) error {
+	if product.Barcode != "" {
+		if err := CheckBarcode(tx, product.Barcode, 0); err != nil {
+			return codeError(err)
+		}
+	}
+	if product.SKU != "" {
+		if err := CheckSKU(tx, product.SKU, 0); err != nil {
+			return codeError(err)
+		}
+	}
+
+	if err := tx.Create(product).Error; err != nil {
+		return productWriteError(err, "Failed to create product")
+	}
+
+	if err := tx.Create(&models.ProductPriceHistory{
+		ProductID:     product.ID,
+		UnitPrice:     product.UnitPrice,
+		EffectiveFrom: product.CreatedAt,
+		ChangedBy:     userID,
+	}).Error; err != nil {
+		return failed("Failed to record price")
+	}
+
+	// Create Stock Entry if Opening Stock is provided
+	if openingStock > 0 {
+		entry := models.StockEntry{
+			ProductID:     product.ID,
+			QuantityAdded: openingStock,
+			Source:        "OPENING",
+			StoreID:       &storeID,
+			AddedBy:       userID,
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return failed("Failed to log opening stock")
+		}
+
+		// Set initial stock through the ledger
+		movement, err := MoveStock(tx, StockChange{
+			ProductID: product.ID,
+			Quantity:  openingStock,
+			Type:      models.MovementOpening,
+			RefType:   "STOCK_ENTRY",
+			RefID:     &entry.ID,
+			UserID:    userID,
+			StoreID:   storeID,
+		})
+		if err != nil {
+			return failed("Failed to log opening stock")
+		}
+		product.CurrentStock = movement.BalanceAfter
+	}
+	return nil
+}
+
+// RecordPriceChange closes the open history row and opens a new one from now
+func RecordPriceChange(tx *gorm.DB, productID uint, price float64, reason string, userID uint, now time.Time) error {
+	if err := tx.Model(&models.ProductPriceHistory{}).
+		Where("product_id = ? AND effective_to IS NULL", productID).
+		Update("effective_to", now).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.ProductPriceHistory{
+		ProductID:     productID,
+		UnitPrice:     price,
+		EffectiveFrom: now,
+		Reason:        reason,
+		ChangedBy:     userID,
+	}).Error
+}
+
+// VariantName labels a variant after its parent, e.g. "Polo T-Shirt (M / Red)"
+func VariantName(parent string, size, colour string) string {
+	var attrs []string
+	for _, a := range []string{size, colour} {
+		if a = strings.TrimSpace(a); a != "" {
+			attrs = append(attrs, a)
+		}
+	}
+	if len(attrs) == 0 {
+		return parent
+	}
+	return fmt.Sprintf("%s (%s)", parent, strings.Join(attrs, " / "))
+}
+
+// newVariant builds a variant that inherits brand, category and tax from its parent
+func newVariant(parent models.Product, v CreateVariantRequest) models.Product {
+	price := v.UnitPrice
+	if price == 0 {
+		price = parent.UnitPrice
+	}
+	threshold := v.LowStockThreshold
+	if threshold == 0 {
+		threshold = parent.LowStockThreshold
+	}
+	return models.Product{
+		Name:              VariantName(parent.Name, v.Size, v.Colour),
+		ParentID:          &parent.ID,
+		BrandID:           parent.BrandID,
+		CategoryID:        parent.CategoryID,
+		SupplierID:        parent.SupplierID,
+		Description:       parent.Description,
+		UnitPrice:         price,
+		LowStockThreshold: threshold,
+		SKU:               strings.TrimSpace(v.SKU),
+		Size:              strings.TrimSpace(v.Size),
+		Colour:            strings.TrimSpace(v.Colour),
+		Barcode:           strings.TrimSpace(v.Barcode),
+		HSNCode:           parent.HSNCode,
+		GSTRate:           parent.GSTRate,
+		TrackBatches:      parent.TrackBatches,
+		IsActive:          true,
+	}
+}
@@ -0,0 +1,462 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"billing-app/internal/models"
+	"billing-app/internal/pricing"
+	"billing-app/internal/sequence"
+
+	"gorm.io/gorm"
+)
+
+// PurchaseService manages suppliers and purchase orders, from draft through
+// goods receipt, and the reorder suggestions that feed them
+type PurchaseService interface {
+	CreateSupplier(req SupplierRequest) (models.Supplier, error)
+	// ListSuppliers matches q against the name or mobile; empty lists all
+	ListSuppliers(q string) ([]models.Supplier, error)
+	UpdateSupplier(id uint, req SupplierRequest) error
+
+	// CreatePurchaseOrder saves a DRAFT purchase order
+	CreatePurchaseOrder(req PurchaseOrderRequest, actor Actor) (models.PurchaseOrder, error)
+	// UpdatePurchaseOrder replaces the lines of a DRAFT purchase order
+	UpdatePurchaseOrder(id uint, req PurchaseOrderRequest, actor Actor) error
+	// ListPurchaseOrders filters by status and supplier; zero values match all
+	ListPurchaseOrders(status string, supplierID uint) ([]models.PurchaseOrder, error)
+	// GetPurchaseOrder loads a purchase order with the goods receipts booked against it
+	GetPurchaseOrder(id uint) (models.PurchaseOrder, []models.StockEntry, error)
+	// PlacePurchaseOrder marks a draft as sent to the supplier
+	PlacePurchaseOrder(id uint) error
+	CancelPurchaseOrder(id uint) error
+	// ReceiveGoods books a goods receipt and returns the order's new status
+	ReceiveGoods(id uint, req ReceiveGoodsRequest, userID uint) (string, error)
+
+	// ReorderSuggestions works out what a store (default the actor's) should
+	// reorder, grouped per supplier or, with byBrand, per supplier and brand
+	ReorderSuggestions(storeID *uint, byBrand bool, actor Actor) (ReorderReport, error)
+	// CreateReorderDrafts turns reviewed suggestions into DRAFT purchase
+	// orders and returns them with the suggestions skipped for want of a supplier
+	CreateReorderDrafts(req CreateReorderDraftsRequest, actor Actor) ([]models.PurchaseOrder, []ReorderSuggestion, error)
+}
+
+type SupplierRequest struct {
+	Name          string `json:"name" binding:"required"`
+	ContactPerson string `json:"contact_person"`
+	Mobile        string `json:"mobile"`
+	Email         string `json:"email"`
+	Address       string `json:"address"`
+	GSTIN         string `json:"gstin"`
+	State         string `json:"state"`
+	LeadTimeDays  int    `json:"lead_time_days" binding:"gte=0"`
+	IsActive      *bool  `json:"is_active"`
+}
+
+type PurchaseOrderItemRequest struct {
+	ProductID uint    `json:"product_id" binding:"required"`
+	Quantity  int     `json:"quantity" binding:"required,gt=0"`
+	CostPrice float64 `json:"cost_price" binding:"gte=0"`
+}
+
+type PurchaseOrderRequest struct {
+	SupplierID   uint                       `json:"supplier_id" binding:"required"`
+	StoreID      *uint                      `json:"store_id"`      // Delivery location; defaults to the user's store
+	ExpectedDate string                     `json:"expected_date"` // YYYY-MM-DD
+	Notes        string                     `json:"notes"`
+	Items        []PurchaseOrderItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type ReceiveItemRequest struct {
+	PurchaseOrderItemID uint     `json:"purchase_order_item_id" binding:"required"`
+	Quantity            int      `json:"quantity" binding:"required,gt=0"`
+	CostPrice           *float64 `json:"cost_price"` // Invoice price if it differs from the PO
+	BatchRequest                 // Required for batch-tracked products
+}
+
+type ReceiveGoodsRequest struct {
+	Reference string               `json:"reference"` // Supplier invoice / delivery challan number
+	Items     []ReceiveItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+var errOverReceipt = errors.New("received quantity exceeds ordered quantity")
+
+type purchaseService struct {
+	db *gorm.DB
+}
+
+func NewPurchaseService(db *gorm.DB) PurchaseService {
+	return &purchaseService{db: db}
+}
+
+func (s *purchaseService) CreateSupplier(req SupplierRequest) (models.Supplier, error) {
+	supplier := models.Supplier{
+		Name:          req.Name,
+		ContactPerson: req.ContactPerson,
+		Mobile:        req.Mobile,
+		Email:         req.Email,
+		Address:       req.Address,
+		GSTIN:         req.GSTIN,
+		State:         req.State,
+		LeadTimeDays:  req.LeadTimeDays,
+		IsActive:      true,
+	}
+	if err := s.db.Create(&supplier).Error; err != nil {
+		return supplier, failed("Failed to create supplier (Name might be duplicate)")
+	}
+	return supplier, nil
+}
+
+func (s *purchaseService) ListSuppliers(q string) ([]models.Supplier, error) {
+	suppliers := []models.Supplier{}
+	query := s.db.Order("name")
+	if q != "" {
+		query = query.Where("LOWER(name) LIKE ? OR mobile LIKE ?", "%"+strings.ToLower(q)+"%", "%"+q+"%")
+	}
+	err := query.Find(&suppliers).Error
+	return suppliers, err
+}
+
+func (s *purchaseService) UpdateSupplier(id uint, req SupplierRequest) error {
+	var supplier models.Supplier
+	if err := s.db.Select("id").First(&supplier, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return notFound("Supplier not found")
+		}
+		return failed("Failed to fetch supplier")
+	}
+
+	updates := map[string]interface{}{
+		"name":           req.Name,
+		"contact_person": req.ContactPerson,
+		"mobile":         req.Mobile,
+		"email":          req.Email,
+		"address":        req.Address,
+		"gstin":          req.GSTIN,
+		"state":          req.State,
+		"lead_time_days": req.LeadTimeDays,
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+
+	if err := s.db.Model(&supplier).Updates(updates).Error; err != nil {
+		return failed("Failed to update supplier")
+	}
+	return nil
+}
+
+// buildPOItems validates products and prices the lines of a purchase order
+func buildPOItems(db *gorm.DB, reqItems []PurchaseOrderItemRequest) ([]models.PurchaseOrderItem, float64, error) {
+	items := make([]models.PurchaseOrderItem, 0, len(reqItems))
+	var total float64
+	for _, r := range reqItems {
+		var count int64
+		db.Model(&models.Product{}).Where("id = ? AND has_variants = ?", r.ProductID, false).Count(&count)
+		if count == 0 {
+			return nil, 0, invalid("Product ID %d not found or has variants", r.ProductID)
+		}
+		lineTotal := pricing.Round2(r.CostPrice * float64(r.Quantity))
+		items = append(items, models.PurchaseOrderItem{
+			ProductID: r.ProductID,
+			Quantity:  r.Quantity,
+			CostPrice: r.CostPrice,
+			Total:     lineTotal,
+		})
+		total += lineTotal
+	}
+	return items, pricing.Round2(total), nil
+}
+
+// checkPurchaseOrder validates the parts of a purchase order request shared
+// by create and update
+func (s *purchaseService) checkPurchaseOrder(req PurchaseOrderRequest, actor Actor) (expected *time.Time, items []models.PurchaseOrderItem, total float64, storeID uint, err error) {
+	expected, err = ParseOptionalDate(req.ExpectedDate)
+	if err != nil {
+		return nil, nil, 0, 0, invalid("%s", err.Error())
+	}
+
+	var supplierCount int64
+	s.db.Model(&models.Supplier{}).Where("id = ? AND is_active = ?", req.SupplierID, true).Count(&supplierCount)
+	if supplierCount == 0 {
+		return nil, nil, 0, 0, invalid("Supplier not found")
+	}
+
+	items, total, err = buildPOItems(s.db, req.Items)
+	if err != nil {
+		return nil, nil, 0, 0, err
+	}
+
+	storeID, err = RequestStoreID(s.db, req.StoreID, actor)
+	if err != nil {
+		return nil, nil, 0, 0, storeError(err)
+	}
+	return expected, items, total, storeID, nil
+}
+
+func (s *purchaseService) CreatePurchaseOrder(req PurchaseOrderRequest, actor Actor) (models.PurchaseOrder, error) {
+	var po models.PurchaseOrder
+	expected, items, total, storeID, err := s.checkPurchaseOrder(req, actor)
+	if err != nil {
+		return po, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		poNo, err := sequence.Next(tx, sequence.PurchaseOrder(), time.Now())
+		if err != nil {
+			return failed("Failed to assign PO number")
+		}
+
+		po = models.PurchaseOrder{
+			PONo:         poNo,
+			SupplierID:   req.SupplierID,
+			StoreID:      &storeID,
+			Status:       models.POStatusDraft,
+			ExpectedDate: expected,
+			Notes:        req.Notes,
+			TotalCost:    total,
+			CreatedBy:    actor.UserID,
+			Items:        items,
+		}
+		if err := tx.Create(&po).Error; err != nil {
+			return failed("Failed to create purchase order")
+		}
+		return nil
+	})
+	return po, err
+}
+
+func (s *purchaseService) UpdatePurchaseOrder(id uint, req PurchaseOrderRequest, actor Actor) error {
+	expected, items, total, storeID, err := s.checkPurchaseOrder(req, actor)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var po models.PurchaseOrder
+		if err := tx.First(&po, id).Error; err != nil {
+			return notFound("Purchase order not found")
+		}
+		if po.Status != models.POStatusDraft {
+			return conflict("Only draft purchase orders can be edited")
+		}
+
+		if err := tx.Where("purchase_order_id = ?", po.ID).Delete(&models.PurchaseOrderItem{}).Error; err != nil {
+			return failed("Failed to update purchase order")
+		}
+		for i := range items {
+			items[i].PurchaseOrderID = po.ID
+		}
+		if err := tx.Create(&items).Error; err != nil {
+			return failed("Failed to update purchase order")
+		}
+		if err := tx.Model(&po).Updates(map[string]interface{}{
+			"supplier_id":   req.SupplierID,
+			"store_id":      storeID,
+			"expected_date": expected,
+			"notes":         req.Notes,
+			"total_cost":    total,
+		}).Error; err != nil {
+			return failed("Failed to update purchase order")
+		}
+		return nil
+	})
+}
+
+func (s *purchaseService) ListPurchaseOrders(status string, supplierID uint) ([]models.PurchaseOrder, error) {
+	orders := []models.PurchaseOrder{}
+	query := s.db.Preload("Supplier").Preload("Items").Order("created_at desc")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if supplierID != 0 {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+	err := query.Find(&orders).Error
+	return orders, err
+}
+
+func (s *purchaseService) GetPurchaseOrder(id uint) (models.PurchaseOrder, []models.StockEntry, error) {
+	var po models.PurchaseOrder
+	if err := s.db.Preload("Supplier").Preload("Creator").Preload("Items").Preload("Items.Product").First(&po, id).Error; err != nil {
+		return po, nil, notFound("Purchase order not found")
+	}
+
+	var receipts []models.StockEntry
+	s.db.Preload("User").Where("purchase_order_id = ?", po.ID).Order("entry_date").Find(&receipts)
+	return po, receipts, nil
+}
+
+// transitionPO moves a purchase order between states, guarding the source state in the UPDATE
+func (s *purchaseService) transitionPO(id uint, from []string, to string, extra map[string]interface{}) error {
+	updates := map[string]interface{}{"status": to}
+	for k, v := range extra {
+		updates[k] = v
+	}
+
+	res := s.db.Model(&models.PurchaseOrder{}).Where("id = ? AND status IN ?", id, from).Updates(updates)
+	if res.Error != nil {
+		return failed("Failed to update purchase order")
+	}
+	if res.RowsAffected == 0 {
+		return conflict("Purchase order not found or not in %v", from)
+	}
+	return nil
+}
+
+func (s *purchaseService) PlacePurchaseOrder(id uint) error {
+	return s.transitionPO(id, []string{models.POStatusDraft}, models.POStatusOrdered, map[string]interface{}{"ordered_at": time.Now()})
+}
+
+func (s *purchaseService) CancelPurchaseOrder(id uint) error {
+	return s.transitionPO(id, []string{models.POStatusDraft, models.POStatusOrdered}, models.POStatusCancelled, nil)
+}
+
+// ReceiveGoods books a goods receipt against an ORDERED or PARTIALLY_RECEIVED purchase order.
+// Each received line adds stock and a StockEntry linked to the PO and supplier.
+func (s *purchaseService) ReceiveGoods(id uint, req ReceiveGoodsRequest, userID uint) (string, error) {
+	var status string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var po models.PurchaseOrder
+		if err := tx.Preload("Items").First(&po, id).Error; err != nil {
+			return notFound("Purchase order not found")
+		}
+		if po.Status != models.POStatusOrdered && po.Status != models.POStatusPartiallyReceived {
+			return conflict("Goods can only be received against an ordered purchase order")
+		}
+
+		items := map[uint]models.PurchaseOrderItem{}
+		for _, item := range po.Items {
+			items[item.ID] = item
+		}
+
+		reference := req.Reference
+		if reference == "" {
+			reference = po.PONo
+		}
+
+		// Orders raised before stores existed deliver to the default store
+		var storeID uint
+		if po.StoreID != nil {
+			storeID = *po.StoreID
+		}
+
+		for _, r := range req.Items {
+			item, ok := items[r.PurchaseOrderItemID]
+			if !ok {
+				return invalid("Item %d does not belong to this purchase order", r.PurchaseOrderItemID)
+			}
+
+			res := tx.Model(&models.PurchaseOrderItem{}).
+				Where("id = ? AND received_qty + ? <= quantity", item.ID, r.Quantity).
+				Update("received_qty", gorm.Expr("received_qty + ?", r.Quantity))
+			if res.Error != nil {
+				return failed("Failed to receive goods")
+			}
+			if res.RowsAffected == 0 {
+				return conflict("%v for item %d", errOverReceipt, item.ID)
+			}
+
+			costPrice := item.CostPrice
+			if r.CostPrice != nil {
+				costPrice = *r.CostPrice
+			}
+
+			batch, err := r.ToBatch(costPrice)
+			if err != nil {
+				return invalid("%s", err.Error())
+			}
+			if batch == nil {
+				var tracked int64
+				tx.Model(&models.Product{}).Where("id = ? AND track_batches = ?", item.ProductID, true).Count(&tracked)
+				if tracked > 0 {
+					return invalid("batch_no is required for item %d (batch-tracked product)", item.ID)
+				}
+			}
+
+			poID, supplierID := po.ID, po.SupplierID
+			movement, err := MoveStock(tx, StockChange{
+				ProductID: item.ProductID,
+				Quantity:  r.Quantity,
+				Type:      models.MovementPurchase,
+				RefType:   "PURCHASE_ORDER",
+				RefID:     &poID,
+				RefNo:     reference,
+				UserID:    userID,
+				StoreID:   storeID,
+				Batch:     batch,
+			})
+			if err != nil {
+				return movementError(err)
+			}
+
+			entry := models.StockEntry{
+				ProductID:       item.ProductID,
+				QuantityAdded:   r.Quantity,
+				Source:          "PURCHASE",
+				Reference:       reference,
+				PurchaseOrderID: &poID,
+				SupplierID:      &supplierID,
+				StoreID:         po.StoreID,
+				BatchID:         movement.BatchID,
+				CostPrice:       costPrice,
+				AddedBy:         userID,
+			}
+			if err := tx.Create(&entry).Error; err != nil {
+				return failed("Failed to log stock entry")
+			}
+		}
+
+		// Fully received once no line is short
+		var pending int64
+		tx.Model(&models.PurchaseOrderItem{}).Where("purchase_order_id = ? AND received_qty < quantity", po.ID).Count(&pending)
+		status = models.POStatusReceived
+		if pending > 0 {
+			status = models.POStatusPartiallyReceived
+		}
+		if err := tx.Model(&po).Update("status", status).Error; err != nil {
+			return failed("Failed to update purchase order")
+		}
+		return nil
+	})
+	return status, err
+}
+
+// createReorderPO saves one reorder group as a DRAFT purchase order
+func createReorderPO(tx *gorm.DB, g ReorderGroup, storeID, userID uint, now time.Time) (models.PurchaseOrder, error) {
+	var po models.PurchaseOrder
+	lines := make([]PurchaseOrderItemRequest, len(g.Items))
+	for i, sg := range g.Items {
+		lines[i] = PurchaseOrderItemRequest{ProductID: sg.ProductID, Quantity: sg.SuggestedQty, CostPrice: sg.CostPrice}
+	}
+	items, total, err := buildPOItems(tx, lines)
+	if err != nil {
+		return po, err
+	}
+
+	poNo, err := sequence.Next(tx, sequence.PurchaseOrder(), now)
+	if err != nil {
+		return po, failed("Failed to assign PO number")
+	}
+
+	notes := "Reorder suggestion"
+	if g.BrandName != "" {
+		notes = fmt.Sprintf("Reorder suggestion: %s", g.BrandName)
+	}
+	po = models.PurchaseOrder{
+		PONo:       poNo,
+		SupplierID: *g.SupplierID,
+		StoreID:    &storeID,
+		Status:     models.POStatusDraft,
+		Notes:      notes,
+		TotalCost:  total,
+		CreatedBy:  userID,
+		Items:      items,
+	}
+	if err := tx.Create(&po).Error; err != nil {
+		return po, failed("Failed to create purchase order")
+	}
+	return po, nil
+}
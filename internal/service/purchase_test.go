@@ -0,0 +1,98 @@
+package service_test
+
+import (
+	"testing"
+
+	"billing-app/internal/models"
+	"billing-app/internal/service"
+	"billing-app/internal/service/servicetest"
+)
+
+func TestUpdateSupplierNotFound(t *testing.T) {
+	env, err := servicetest.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+
+	supplier, err := env.Purchases.CreateSupplier(service.SupplierRequest{Name: "Acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id   uint
+		kind service.Kind
+	}{
+		{supplier.ID, 0},
+		{supplier.ID + 1, service.KindNotFound},
+	}
+	for _, tt := range tests {
+		if err := env.Purchases.UpdateSupplier(tt.id, service.SupplierRequest{Name: "Acme Traders"}); errorKind(err) != tt.kind {
+			t.Errorf("UpdateSupplier(%d): %v, want kind %d", tt.id, err, tt.kind)
+		}
+	}
+}
+
+// A product that changed after it was ordered can't take the stock; that is
+// the caller's to fix, not a server failure
+func TestReceiveGoodsRejectedMovement(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(env *servicetest.Env, product models.Product) error
+	}{
+		{"split into variants", func(env *servicetest.Env, product models.Product) error {
+			_, err := env.Inventory.AddVariant(product.ID, service.CreateVariantRequest{Size: "M"}, env.Admin.ID)
+			return err
+		}},
+		{"deleted", func(env *servicetest.Env, product models.Product) error {
+			return env.DB.Delete(&product).Error
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := servicetest.Open(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer env.Close()
+			admin := service.Actor{UserID: env.Admin.ID, Role: "admin"}
+
+			product, err := env.Product("Shirt", 300, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			supplier, err := env.Purchases.CreateSupplier(service.SupplierRequest{Name: "Acme"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			po, err := env.Purchases.CreatePurchaseOrder(service.PurchaseOrderRequest{
+				SupplierID: supplier.ID,
+				Items:      []service.PurchaseOrderItemRequest{{ProductID: product.ID, Quantity: 10, CostPrice: 200}},
+			}, admin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := env.Purchases.PlacePurchaseOrder(po.ID); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.change(env, product); err != nil {
+				t.Fatal(err)
+			}
+
+			var item models.PurchaseOrderItem
+			env.DB.Where("purchase_order_id = ?", po.ID).First(&item)
+			_, err = env.Purchases.ReceiveGoods(po.ID, service.ReceiveGoodsRequest{
+				Items: []service.ReceiveItemRequest{{PurchaseOrderItemID: item.ID, Quantity: 10}},
+			}, env.Admin.ID)
+			if errorKind(err) != service.KindInvalid {
+				t.Errorf("ReceiveGoods: %v, want invalid", err)
+			}
+
+			env.DB.First(&item, item.ID)
+			if item.ReceivedQty != 0 {
+				t.Errorf("received_qty = %d, want 0", item.ReceivedQty)
+			}
+		})
+	}
+}
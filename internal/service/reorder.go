@@ -0,0 +1,319 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"billing-app/config"
+	"billing-app/internal/models"
+	"billing-app/internal/pricing"
+
+	"gorm.io/gorm"
+)
+
+// ReorderSuggestion is one product a store should reorder. The reorder point
+// is lead-time demand plus safety stock (never below LowStockThreshold); an
+// order tops stock plus open purchase orders up to lead time plus cover days
+// of demand, or twice the threshold for slow movers.
+type ReorderSuggestion struct {
+	ProductID     uint    `json:"product_id"`
+	Name          string  `json:"name"`
+	SKU           string  `json:"sku"`
+	BrandID       uint    `json:"brand_id"`
+	BrandName     string  `json:"brand_name"`
+	SupplierID    *uint   `json:"supplier_id"` // Preferred supplier, else the last one ordered from
+	SupplierName  string  `json:"supplier_name"`
+	Stock         int     `json:"stock"`
+	OnOrder       int     `json:"on_order"` // Outstanding on draft and placed purchase orders
+	AvgDailySales float64 `json:"avg_daily_sales"`
+	LeadTimeDays  int     `json:"lead_time_days"`
+	SafetyStock   int     `json:"safety_stock"`
+	ReorderPoint  int     `json:"reorder_point"`
+	SuggestedQty  int     `json:"suggested_qty"`
+	CostPrice     float64 `json:"cost_price"` // From the last purchase order
+}
+
+// ReorderGroup is the suggestions that would go on one draft purchase order
+type ReorderGroup struct {
+	SupplierID   *uint               `json:"supplier_id"`
+	SupplierName string              `json:"supplier_name"`
+	BrandID      *uint               `json:"brand_id,omitempty"` // Set when grouped by brand
+	BrandName    string              `json:"brand_name,omitempty"`
+	TotalCost    float64             `json:"total_cost"`
+	Items        []ReorderSuggestion `json:"items"`
+}
+
+// ReorderReport is a store's grouped suggestions with the settings behind them
+type ReorderReport struct {
+	StoreID         uint           `json:"store_id"`
+	SalesWindowDays int            `json:"sales_window_days"`
+	SafetyDays      int            `json:"safety_days"`
+	CoverDays       int            `json:"cover_days"`
+	Groups          []ReorderGroup `json:"groups"`
+}
+
+type ReorderDraftItem struct {
+	ProductID  uint    `json:"product_id" binding:"required"`
+	SupplierID uint    `json:"supplier_id" binding:"required"`
+	Quantity   int     `json:"quantity" binding:"required,gt=0"`
+	CostPrice  float64 `json:"cost_price" binding:"gte=0"`
+}
+
+type CreateReorderDraftsRequest struct {
+	StoreID *uint              `json:"store_id"` // Defaults to the user's store
+	GroupBy string             `json:"group_by" binding:"omitempty,oneof=supplier brand"`
+	Items   []ReorderDraftItem `json:"items" binding:"omitempty,dive"` // Reviewed lines; empty takes every suggestion with a supplier
+}
+
+// reorderSuggestions works out what storeID should reorder from its sales
+// over the configured window
+func reorderSuggestions(db *gorm.DB, storeID uint, now time.Time) ([]ReorderSuggestion, error) {
+	cfg := config.AppConfig.Inventory
+	window := max(cfg.SalesWindowDays, 1)
+
+	var products []models.Product
+	if err := db.Preload("Brand").Preload("Supplier").
+		Where("is_active = ? AND has_variants = ?", true, false).Order("id").Find(&products).Error; err != nil {
+		return nil, err
+	}
+	stock := storeQuantities(db, storeID)
+
+	var sold []struct {
+		ProductID uint
+		Total     int
+	}
+	if err := db.Model(&models.BillItem{}).
+		Joins("JOIN bills ON bills.id = bill_items.bill_id").
+		Where("bills.store_id = ? AND bills.status <> ? AND bills.bill_date >= ?", storeID, "CANCELLED", now.AddDate(0, 0, -window)).
+		Select("bill_items.product_id, SUM(bill_items.quantity - bill_items.returned_qty) AS total").
+		Group("bill_items.product_id").Scan(&sold).Error; err != nil {
+		return nil, err
+	}
+	sales := map[uint]int{}
+	for _, s := range sold {
+		sales[s.ProductID] = s.Total
+	}
+
+	var open []struct {
+		ProductID uint
+		Total     int
+	}
+	if err := db.Model(&models.PurchaseOrderItem{}).
+		Joins("JOIN purchase_orders ON purchase_orders.id = purchase_order_items.purchase_order_id").
+		Where("purchase_orders.store_id = ? AND purchase_orders.status IN ?", storeID,
+			[]string{models.POStatusDraft, models.POStatusOrdered, models.POStatusPartiallyReceived}).
+		Select("purchase_order_items.product_id, SUM(purchase_order_items.quantity - purchase_order_items.received_qty) AS total").
+		Group("purchase_order_items.product_id").Scan(&open).Error; err != nil {
+		return nil, err
+	}
+	onOrder := map[uint]int{}
+	for _, o := range open {
+		onOrder[o.ProductID] = o.Total
+	}
+
+	// Newest first, so the first line seen per product is its last purchase
+	var history []struct {
+		ProductID  uint
+		SupplierID uint
+		CostPrice  float64
+	}
+	if err := db.Model(&models.PurchaseOrderItem{}).
+		Joins("JOIN purchase_orders ON purchase_orders.id = purchase_order_items.purchase_order_id").
+		Where("purchase_orders.status <> ?", models.POStatusCancelled).
+		Select("purchase_order_items.product_id, purchase_orders.supplier_id, purchase_order_items.cost_price").
+		Order("purchase_orders.created_at desc, purchase_order_items.id desc").Scan(&history).Error; err != nil {
+		return nil, err
+	}
+	type lastPurchase struct {
+		supplierID uint
+		cost       float64
+	}
+	last := map[uint]lastPurchase{}
+	for _, h := range history {
+		if _, seen := last[h.ProductID]; !seen {
+			last[h.ProductID] = lastPurchase{h.SupplierID, h.CostPrice}
+		}
+	}
+
+	var suppliers []models.Supplier
+	db.Where("is_active = ?", true).Find(&suppliers)
+	supplierByID := map[uint]models.Supplier{}
+	for _, s := range suppliers {
+		supplierByID[s.ID] = s
+	}
+
+	suggestions := []ReorderSuggestion{}
+	for _, p := range products {
+		s := ReorderSuggestion{
+			ProductID: p.ID,
+			Name:      p.Name,
+			SKU:       p.SKU,
+			BrandID:   p.BrandID,
+			BrandName: p.Brand.Name,
+			Stock:     stock[p.ID],
+			OnOrder:   onOrder[p.ID],
+			CostPrice: last[p.ID].cost,
+		}
+
+		supplierID := last[p.ID].supplierID
+		if p.SupplierID != nil {
+			supplierID = *p.SupplierID
+		}
+		s.LeadTimeDays = cfg.LeadTimeDays
+		if supplier, ok := supplierByID[supplierID]; ok {
+			s.SupplierID, s.SupplierName = &supplier.ID, supplier.Name
+			if supplier.LeadTimeDays > 0 {
+				s.LeadTimeDays = supplier.LeadTimeDays
+			}
+		}
+
+		avg := float64(max(sales[p.ID], 0)) / float64(window)
+		s.AvgDailySales = math.Round(avg*100) / 100
+		s.SafetyStock = int(math.Ceil(avg * float64(cfg.SafetyDays)))
+		s.ReorderPoint = max(int(math.Ceil(avg*float64(s.LeadTimeDays)))+s.SafetyStock, p.LowStockThreshold)
+
+		position := s.Stock + s.OnOrder
+		if position > s.ReorderPoint {
+			continue
+		}
+		target := max(int(math.Ceil(avg*float64(s.LeadTimeDays+cfg.CoverDays)))+s.SafetyStock, 2*p.LowStockThreshold)
+		s.SuggestedQty = target - position
+		if s.SuggestedQty <= 0 {
+			continue
+		}
+		suggestions = append(suggestions, s)
+	}
+	return suggestions, nil
+}
+
+// groupSuggestions splits suggestions into one group per supplier, or per
+// supplier and brand when byBrand is set
+func groupSuggestions(suggestions []ReorderSuggestion, byBrand bool) []ReorderGroup {
+	type key struct {
+		supplierID uint
+		brandID    uint
+	}
+	index := map[key]int{}
+	groups := []ReorderGroup{}
+	for _, s := range suggestions {
+		k := key{}
+		if s.SupplierID != nil {
+			k.supplierID = *s.SupplierID
+		}
+		if byBrand {
+			k.brandID = s.BrandID
+		}
+		i, ok := index[k]
+		if !ok {
+			g := ReorderGroup{SupplierID: s.SupplierID, SupplierName: s.SupplierName}
+			if byBrand {
+				g.BrandID, g.BrandName = &s.BrandID, s.BrandName
+			}
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, g)
+		}
+		groups[i].Items = append(groups[i].Items, s)
+		groups[i].TotalCost += float64(s.SuggestedQty) * s.CostPrice
+	}
+
+	for i := range groups {
+		groups[i].TotalCost = pricing.Round2(groups[i].TotalCost)
+	}
+	// Suppliers first by name; products without a supplier last
+	sort.SliceStable(groups, func(i, j int) bool {
+		if (groups[i].SupplierID == nil) != (groups[j].SupplierID == nil) {
+			return groups[j].SupplierID == nil
+		}
+		if groups[i].SupplierName != groups[j].SupplierName {
+			return groups[i].SupplierName < groups[j].SupplierName
+		}
+		return groups[i].BrandName < groups[j].BrandName
+	})
+	return groups
+}
+
+func (s *purchaseService) ReorderSuggestions(storeID *uint, byBrand bool, actor Actor) (ReorderReport, error) {
+	sid, err := RequestStoreID(s.db, storeID, actor)
+	if err != nil {
+		return ReorderReport{}, storeError(err)
+	}
+
+	suggestions, err := reorderSuggestions(s.db, sid, time.Now())
+	if err != nil {
+		return ReorderReport{}, failed("Failed to calculate reorder suggestions")
+	}
+
+	cfg := config.AppConfig.Inventory
+	return ReorderReport{
+		StoreID:         sid,
+		SalesWindowDays: cfg.SalesWindowDays,
+		SafetyDays:      cfg.SafetyDays,
+		CoverDays:       cfg.CoverDays,
+		Groups:          groupSuggestions(suggestions, byBrand),
+	}, nil
+}
+
+// CreateReorderDrafts makes one DRAFT purchase order per supplier (and brand
+// with group_by=brand). They are confirmed with the usual purchase order endpoints.
+func (s *purchaseService) CreateReorderDrafts(req CreateReorderDraftsRequest, actor Actor) ([]models.PurchaseOrder, []ReorderSuggestion, error) {
+	storeID, err := RequestStoreID(s.db, req.StoreID, actor)
+	if err != nil {
+		return nil, nil, storeError(err)
+	}
+
+	now := time.Now()
+	var suggestions []ReorderSuggestion
+	skipped := []ReorderSuggestion{}
+	if len(req.Items) == 0 {
+		all, err := reorderSuggestions(s.db, storeID, now)
+		if err != nil {
+			return nil, nil, failed("Failed to calculate reorder suggestions")
+		}
+		for _, sg := range all {
+			if sg.SupplierID == nil {
+				skipped = append(skipped, sg)
+				continue
+			}
+			suggestions = append(suggestions, sg)
+		}
+	} else {
+		for _, item := range req.Items {
+			var product models.Product
+			if err := s.db.Preload("Brand").First(&product, item.ProductID).Error; err != nil {
+				return nil, nil, invalid("Product ID %d not found", item.ProductID)
+			}
+			var supplier models.Supplier
+			if err := s.db.Where("id = ? AND is_active = ?", item.SupplierID, true).First(&supplier).Error; err != nil {
+				return nil, nil, invalid("Supplier ID %d not found", item.SupplierID)
+			}
+			suggestions = append(suggestions, ReorderSuggestion{
+				ProductID:    product.ID,
+				BrandID:      product.BrandID,
+				BrandName:    product.Brand.Name,
+				SupplierID:   &supplier.ID,
+				SupplierName: supplier.Name,
+				SuggestedQty: item.Quantity,
+				CostPrice:    item.CostPrice,
+			})
+		}
+	}
+
+	orders := []models.PurchaseOrder{}
+	if len(suggestions) == 0 {
+		return orders, skipped, nil
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, g := range groupSuggestions(suggestions, req.GroupBy == "brand") {
+			po, err := createReorderPO(tx, g, storeID, actor.UserID, now)
+			if err != nil {
+				return err
+			}
+			orders = append(orders, po)
+		}
+		return nil
+	})
+	return orders, skipped, err
+}
@@ -0,0 +1,564 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"billing-app/internal/models"
+	"billing-app/internal/pricing"
+
+	"gorm.io/gorm"
+)
+
+// ReportService builds the manager's sales, store, category, customer and
+// dashboard reports
+type ReportService interface {
+	// SalesBills loads the bills in the filter's range with everything the
+	// sales report and its CSV export print
+	SalesBills(filter SalesFilter) ([]models.Bill, error)
+	SalesReport(filter SalesFilter) (SalesReport, error)
+	// StoreReport compares stores over the period and totals them
+	StoreReport(filter SalesFilter) ([]StoreReportRow, StoreReportRow, error)
+	// CategoryReport rolls net sales up the category tree; a non-nil
+	// categoryID limits it to that subtree
+	CategoryReport(filter SalesFilter, categoryID *uint) (CategoryReport, error)
+	// Customers ranks customers by net spend
+	Customers() ([]CustomerWithStats, error)
+	Dashboard() DashboardStats
+}
+
+// SalesFilter selects bills by optional start and end dates (YYYY-MM-DD,
+// both required to filter) and store
+type SalesFilter struct {
+	StartDate string
+	EndDate   string
+	StoreID   *uint
+}
+
+type SalesSummary struct {
+	TotalRevenue      float64 `json:"total_revenue"`
+	TotalTransactions int     `json:"total_transactions"`
+	ProductsSold      int     `json:"products_sold"`
+	RefundedAmount    float64 `json:"refunded_amount"`
+	CancelledBills    int     `json:"cancelled_bills"`
+	TaxableValue      float64 `json:"taxable_value"`
+	CGSTAmount        float64 `json:"cgst_amount"`
+	SGSTAmount        float64 `json:"sgst_amount"`
+	IGSTAmount        float64 `json:"igst_amount"`
+	GSTAmount         float64 `json:"gst_amount"`
+}
+
+type SalesReport struct {
+	Summary      SalesSummary       `json:"summary"`
+	TaxSummary   []TaxSummaryRow    `json:"tax_summary"`
+	Tenders      map[string]float64 `json:"tenders"`
+	Transactions []models.Bill      `json:"transactions"`
+}
+
+// TaxSummaryRow is the HSN-wise GST summary used on reports and GSTR-1 style exports.
+// Credit notes are netted off against the line they reverse.
+type TaxSummaryRow struct {
+	HSNCode      string  `json:"hsn_code"`
+	GSTRate      float64 `json:"gst_rate"`
+	Quantity     int     `json:"quantity"`
+	TaxableValue float64 `json:"taxable_value"`
+	CGSTAmount   float64 `json:"cgst_amount"`
+	SGSTAmount   float64 `json:"sgst_amount"`
+	IGSTAmount   float64 `json:"igst_amount"`
+}
+
+// StoreReportRow is one store's sales for the period and its stock now
+type StoreReportRow struct {
+	StoreID        uint    `json:"store_id"`
+	Code           string  `json:"code"`
+	Name           string  `json:"name"`
+	Revenue        float64 `json:"revenue"` // Net of cancellations and returns
+	Bills          int     `json:"bills"`
+	RefundedAmount float64 `json:"refunded_amount"`
+	StockUnits     int     `json:"stock_units"`
+	StockValue     float64 `json:"stock_value"`      // At selling price
+	InTransitUnits int     `json:"in_transit_units"` // Dispatched to this store, not yet received
+}
+
+// CategoryReportRow is a category's net sales for the period: its own products
+// and, in the Total fields, rolled up with all its subcategories
+type CategoryReportRow struct {
+	CategoryID    uint    `json:"category_id"`
+	Name          string  `json:"name"`
+	ParentID      *uint   `json:"parent_id"`
+	Depth         int     `json:"depth"`
+	Quantity      int     `json:"quantity"`
+	TaxableValue  float64 `json:"taxable_value"`
+	TotalQuantity int     `json:"total_quantity"`
+	TotalValue    float64 `json:"total_taxable_value"`
+}
+
+// CategorySales is the net sales of products without a category
+type CategorySales struct {
+	Quantity     int     `json:"quantity"`
+	TaxableValue float64 `json:"taxable_value"`
+}
+
+type CategoryReport struct {
+	Categories    []CategoryReportRow `json:"categories"`
+	Uncategorised CategorySales       `json:"uncategorised"`
+}
+
+type CustomerWithStats struct {
+	models.Customer
+	TotalSpend float64 `json:"total_spend"`
+	Rank       int     `json:"rank"`
+}
+
+// ChartData is a labelled series for the dashboard charts
+type ChartData struct {
+	Labels []string  `json:"labels"`
+	Data   []float64 `json:"data"`
+}
+
+type DashboardMetrics struct {
+	TodayRevenue   float64 `json:"todayRevenue"`
+	InventoryValue float64 `json:"inventoryValue"`
+	TotalSales     float64 `json:"totalSales"`
+	TotalOrders    int64   `json:"totalOrders"`
+	LowStock       int64   `json:"lowStock"`
+	NewCustomers   int64   `json:"newCustomers"`
+}
+
+type DashboardCharts struct {
+	Monthly     ChartData `json:"monthly"` // Last 7 days
+	Category    ChartData `json:"category"`
+	BillerSales ChartData `json:"billerSales"`
+	// Traffic and Weekly can be mocked or calculated similarly if needed
+	Traffic []int `json:"traffic"`
+	Weekly  []int `json:"weekly"`
+}
+
+type DashboardStats struct {
+	Metrics DashboardMetrics `json:"metrics"`
+	Charts  DashboardCharts  `json:"charts"`
+}
+
+type reportService struct {
+	db *gorm.DB
+}
+
+func NewReportService(db *gorm.DB) ReportService {
+	return &reportService{db: db}
+}
+
+func (s *reportService) SalesBills(filter SalesFilter) ([]models.Bill, error) {
+	var bills []models.Bill
+	query := s.db.Preload("Items").Preload("Items.Product").Preload("User").Preload("Customer").
+		Preload("Payments").Preload("CreditNotes").Preload("CreditNotes.Items")
+
+	if filter.StartDate != "" && filter.EndDate != "" {
+		// Parse dates assuming YYYY-MM-DD
+		startDate, _ := time.Parse("2006-01-02", filter.StartDate)
+		endDate, _ := time.Parse("2006-01-02", filter.EndDate)
+		// Set end date to end of day
+		endDate = endDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+
+		query = query.Where("bill_date BETWEEN ? AND ?", startDate, endDate)
+	}
+	if filter.StoreID != nil {
+		query = query.Where("store_id = ?", *filter.StoreID)
+	}
+
+	err := query.Order("bill_date").Find(&bills).Error
+	return bills, err
+}
+
+func summarizeTax(bills []models.Bill) []TaxSummaryRow {
+	type key struct {
+		hsn  string
+		rate float64
+	}
+	index := map[key]int{}
+	rows := []TaxSummaryRow{}
+
+	add := func(hsn string, rate float64, sign float64, qty int, taxable, cgst, sgst, igst float64) {
+		k := key{hsn, rate}
+		i, ok := index[k]
+		if !ok {
+			i = len(rows)
+			index[k] = i
+			rows = append(rows, TaxSummaryRow{HSNCode: hsn, GSTRate: rate})
+		}
+		rows[i].Quantity += int(sign) * qty
+		rows[i].TaxableValue = pricing.Round2(rows[i].TaxableValue + sign*taxable)
+		rows[i].CGSTAmount = pricing.Round2(rows[i].CGSTAmount + sign*cgst)
+		rows[i].SGSTAmount = pricing.Round2(rows[i].SGSTAmount + sign*sgst)
+		rows[i].IGSTAmount = pricing.Round2(rows[i].IGSTAmount + sign*igst)
+	}
+
+	for _, bill := range bills {
+		for _, item := range bill.Items {
+			add(item.HSNCode, item.GSTRate, 1, item.Quantity, item.TaxableValue, item.CGSTAmount, item.SGSTAmount, item.IGSTAmount)
+		}
+		for _, note := range bill.CreditNotes {
+			for _, item := range note.Items {
+				add(item.HSNCode, item.GSTRate, -1, item.Quantity, item.TaxableValue, item.CGSTAmount, item.SGSTAmount, item.IGSTAmount)
+			}
+		}
+	}
+	return rows
+}
+
+// TenderBreakdown sums collected revenue per tender type, less refunds paid out in that tender.
+// Bills saved before split payments existed count under their payment mode.
+func TenderBreakdown(bills []models.Bill) map[string]float64 {
+	totals := map[string]float64{}
+	for _, bill := range bills {
+		if len(bill.Payments) == 0 {
+			totals[bill.PaymentMode] += bill.NetPayable
+		}
+		for _, p := range bill.Payments {
+			totals[p.TenderType] += p.Amount
+		}
+		for _, note := range bill.CreditNotes {
+			totals[note.RefundMode] -= note.RefundAmount
+		}
+	}
+	for k, v := range totals {
+		totals[k] = pricing.Round2(v)
+	}
+	return totals
+}
+
+func (s *reportService) SalesReport(filter SalesFilter) (SalesReport, error) {
+	bills, err := s.SalesBills(filter)
+	if err != nil {
+		return SalesReport{}, failed("Failed to fetch sales report")
+	}
+
+	var sum SalesSummary
+	for _, bill := range bills {
+		// Revenue is net of cancellations and returns (credit notes)
+		sum.TotalRevenue += bill.NetPayable - bill.RefundedAmount
+		sum.RefundedAmount += bill.RefundedAmount
+		if bill.Status == "CANCELLED" {
+			sum.CancelledBills++
+		} else {
+			sum.TotalTransactions++
+		}
+		sum.TaxableValue += bill.TotalAmount - bill.DiscountAmount
+		sum.CGSTAmount += bill.CGSTAmount
+		sum.SGSTAmount += bill.SGSTAmount
+		sum.IGSTAmount += bill.IGSTAmount
+		for _, item := range bill.Items {
+			sum.ProductsSold += item.Quantity - item.ReturnedQty
+		}
+		for _, note := range bill.CreditNotes {
+			sum.TaxableValue -= note.TaxableValue
+			sum.CGSTAmount -= note.CGSTAmount
+			sum.SGSTAmount -= note.SGSTAmount
+			sum.IGSTAmount -= note.IGSTAmount
+		}
+	}
+	sum.GSTAmount = pricing.Round2(sum.CGSTAmount + sum.SGSTAmount + sum.IGSTAmount)
+	sum.TotalRevenue = pricing.Round2(sum.TotalRevenue)
+	sum.RefundedAmount = pricing.Round2(sum.RefundedAmount)
+	sum.TaxableValue = pricing.Round2(sum.TaxableValue)
+	sum.CGSTAmount = pricing.Round2(sum.CGSTAmount)
+	sum.SGSTAmount = pricing.Round2(sum.SGSTAmount)
+	sum.IGSTAmount = pricing.Round2(sum.IGSTAmount)
+
+	return SalesReport{
+		Summary:      sum,
+		TaxSummary:   summarizeTax(bills),
+		Tenders:      TenderBreakdown(bills),
+		Transactions: bills,
+	}, nil
+}
+
+func (s *reportService) StoreReport(filter SalesFilter) ([]StoreReportRow, StoreReportRow, error) {
+	var total StoreReportRow
+	var stores []models.Store
+	if err := s.db.Order("is_default desc, name").Find(&stores).Error; err != nil {
+		return nil, total, failed("Failed to fetch stores")
+	}
+	bills, err := s.SalesBills(filter)
+	if err != nil {
+		return nil, total, failed("Failed to fetch sales report")
+	}
+
+	rows := make([]StoreReportRow, len(stores))
+	index := map[uint]int{}
+	for i, st := range stores {
+		rows[i] = StoreReportRow{StoreID: st.ID, Code: st.Code, Name: st.Name}
+		index[st.ID] = i
+	}
+
+	for _, bill := range bills {
+		if bill.StoreID == nil {
+			continue
+		}
+		i, ok := index[*bill.StoreID]
+		if !ok {
+			continue
+		}
+		rows[i].Revenue += bill.NetPayable - bill.RefundedAmount
+		rows[i].RefundedAmount += bill.RefundedAmount
+		if bill.Status != "CANCELLED" {
+			rows[i].Bills++
+		}
+	}
+
+	var stock []struct {
+		StoreID uint
+		Units   int
+		Value   float64
+	}
+	s.db.Model(&models.StoreStock{}).
+		Joins("JOIN products ON products.id = store_stocks.product_id").
+		Select("store_stocks.store_id, SUM(store_stocks.quantity) AS units, SUM(store_stocks.quantity * products.unit_price) AS value").
+		Group("store_stocks.store_id").Scan(&stock)
+	for _, row := range stock {
+		if i, ok := index[row.StoreID]; ok {
+			rows[i].StockUnits = row.Units
+			rows[i].StockValue = row.Value
+		}
+	}
+
+	var transit []struct {
+		ToStoreID uint
+		Units     int
+	}
+	s.db.Model(&models.StockTransferItem{}).
+		Joins("JOIN stock_transfers ON stock_transfers.id = stock_transfer_items.stock_transfer_id").
+		Where("stock_transfers.status = ?", models.TransferStatusInTransit).
+		Select("stock_transfers.to_store_id, SUM(stock_transfer_items.quantity) AS units").
+		Group("stock_transfers.to_store_id").Scan(&transit)
+	for _, t := range transit {
+		if i, ok := index[t.ToStoreID]; ok {
+			rows[i].InTransitUnits = t.Units
+		}
+	}
+
+	for i := range rows {
+		rows[i].Revenue = pricing.Round2(rows[i].Revenue)
+		rows[i].RefundedAmount = pricing.Round2(rows[i].RefundedAmount)
+		rows[i].StockValue = pricing.Round2(rows[i].StockValue)
+
+		total.Revenue += rows[i].Revenue
+		total.Bills += rows[i].Bills
+		total.RefundedAmount += rows[i].RefundedAmount
+		total.StockUnits += rows[i].StockUnits
+		total.StockValue += rows[i].StockValue
+		total.InTransitUnits += rows[i].InTransitUnits
+	}
+	total.Name = "All stores"
+	total.Revenue = pricing.Round2(total.Revenue)
+	total.RefundedAmount = pricing.Round2(total.RefundedAmount)
+	total.StockValue = pricing.Round2(total.StockValue)
+
+	return rows, total, nil
+}
+
+func (s *reportService) CategoryReport(filter SalesFilter, categoryID *uint) (CategoryReport, error) {
+	var report CategoryReport
+	query := s.db.Order("path")
+	if categoryID != nil {
+		subtree, err := categorySubtree(s.db, *categoryID)
+		if err != nil {
+			return report, err
+		}
+		query = query.Where("id IN (?)", subtree)
+	}
+	var categories []models.Category
+	if err := query.Find(&categories).Error; err != nil {
+		return report, failed("Failed to fetch categories")
+	}
+
+	bills, err := s.SalesBills(filter)
+	if err != nil {
+		return report, failed("Failed to fetch sales report")
+	}
+
+	// Deleted products still carry their sales
+	var products []models.Product
+	s.db.Unscoped().Select("id", "category_id").Find(&products)
+	productCategory := map[uint]uint{}
+	for _, p := range products {
+		if p.CategoryID != nil {
+			productCategory[p.ID] = *p.CategoryID
+		}
+	}
+
+	type sales struct {
+		quantity int
+		value    float64
+	}
+	byCategory := map[uint]*sales{}
+	var uncategorised sales
+	for _, bill := range bills {
+		if bill.Status == "CANCELLED" {
+			continue
+		}
+		returned := map[uint]float64{}
+		for _, note := range bill.CreditNotes {
+			for _, item := range note.Items {
+				returned[item.BillItemID] += item.TaxableValue
+			}
+		}
+		for _, item := range bill.Items {
+			sl := &uncategorised
+			if categoryID, ok := productCategory[item.ProductID]; ok {
+				if byCategory[categoryID] == nil {
+					byCategory[categoryID] = &sales{}
+				}
+				sl = byCategory[categoryID]
+			}
+			sl.quantity += item.Quantity - item.ReturnedQty
+			sl.value += item.TaxableValue - returned[item.ID]
+		}
+	}
+
+	rows := make([]CategoryReportRow, len(categories))
+	for i, cat := range categories {
+		rows[i] = CategoryReportRow{CategoryID: cat.ID, Name: cat.Name, ParentID: cat.ParentID, Depth: strings.Count(cat.Path, "/") - 2}
+		if sl := byCategory[cat.ID]; sl != nil {
+			rows[i].Quantity, rows[i].TaxableValue = sl.quantity, pricing.Round2(sl.value)
+		}
+		// Paths sort each subtree right after its root
+		for _, other := range categories[i:] {
+			if !strings.HasPrefix(other.Path, cat.Path) {
+				break
+			}
+			if sl := byCategory[other.ID]; sl != nil {
+				rows[i].TotalQuantity += sl.quantity
+				rows[i].TotalValue += sl.value
+			}
+		}
+		rows[i].TotalValue = pricing.Round2(rows[i].TotalValue)
+	}
+
+	report.Categories = rows
+	report.Uncategorised = CategorySales{Quantity: uncategorised.quantity, TaxableValue: pricing.Round2(uncategorised.value)}
+	return report, nil
+}
+
+func (s *reportService) Customers() ([]CustomerWithStats, error) {
+	var customers []models.Customer
+	if err := s.db.Find(&customers).Error; err != nil {
+		return nil, failed("Failed to fetch customers")
+	}
+
+	// Calculate Total Spend for each customer
+	var customerStats []CustomerWithStats
+	for _, cust := range customers {
+		var total float64
+		s.db.Table("bills").Where("customer_id = ?", cust.ID).Select("COALESCE(SUM(net_payable - refunded_amount), 0)").Scan(&total)
+
+		customerStats = append(customerStats, CustomerWithStats{
+			Customer:   cust,
+			TotalSpend: total,
+		})
+	}
+
+	// Sort by Total Spend Descending
+	for i := range customerStats {
+		for j := i + 1; j < len(customerStats); j++ {
+			if customerStats[i].TotalSpend < customerStats[j].TotalSpend {
+				customerStats[i], customerStats[j] = customerStats[j], customerStats[i]
+			}
+		}
+	}
+
+	// Assign Ranks
+	for i := range customerStats {
+		customerStats[i].Rank = i + 1
+	}
+	return customerStats, nil
+}
+
+func (s *reportService) Dashboard() DashboardStats {
+	var m DashboardMetrics
+
+	// Today's Revenue (from Bills net of credit notes)
+	// Days are compared as ranges rather than DATE() so the queries work on every dialect
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	tomorrow := today.AddDate(0, 0, 1)
+	s.db.Model(&models.Bill{}).Where("bill_date >= ? AND bill_date < ?", today, tomorrow).Select("COALESCE(SUM(net_payable - refunded_amount), 0)").Scan(&m.TodayRevenue)
+
+	// Inventory Value
+	// SQLite/MySQL compatible query
+	var products []models.Product
+	s.db.Find(&products)
+	for _, p := range products {
+		m.InventoryValue += p.UnitPrice * float64(p.CurrentStock)
+	}
+
+	// Total Sales (All time for now, or this month)
+	s.db.Model(&models.Bill{}).Select("COALESCE(SUM(net_payable - refunded_amount), 0)").Scan(&m.TotalSales)
+
+	// Total Orders (Count of bills that were not cancelled)
+	s.db.Model(&models.Bill{}).Where("status <> ?", "CANCELLED").Count(&m.TotalOrders)
+
+	// Low Stock
+	s.db.Model(&models.Product{}).Where("current_stock < 20").Count(&m.LowStock) // Threshold 20 mostly used
+
+	// New Customers (Today)
+	s.db.Model(&models.Customer{}).Where("created_at >= ? AND created_at < ?", today, tomorrow).Count(&m.NewCustomers)
+
+	// Last 7 days sales
+	monthlyChart := ChartData{Labels: []string{}, Data: []float64{}}
+	for i := 6; i >= 0; i-- {
+		date := today.AddDate(0, 0, -i)
+		var dailySum float64
+		s.db.Model(&models.Bill{}).Where("bill_date >= ? AND bill_date < ?", date, date.AddDate(0, 0, 1)).Select("COALESCE(SUM(net_payable - refunded_amount), 0)").Scan(&dailySum)
+		monthlyChart.Labels = append(monthlyChart.Labels, date.Format("Jan 02"))
+		monthlyChart.Data = append(monthlyChart.Data, dailySum)
+	}
+
+	// Category Distribution
+	// Group by Brand for now as Category isn't strictly defined in Products.
+	categoryChart := ChartData{Labels: []string{}, Data: []float64{}}
+	rows, _ := s.db.Table("order_items").
+		Joins("JOIN products ON products.id = order_items.product_id").
+		Joins("JOIN brands ON brands.id = products.brand_id").
+		Select("brands.name, count(order_items.id)").
+		Group("brands.name").
+		Rows()
+	if rows != nil {
+		defer rows.Close()
+		for rows.Next() {
+			var brandName string
+			var count float64
+			rows.Scan(&brandName, &count)
+			categoryChart.Labels = append(categoryChart.Labels, brandName)
+			categoryChart.Data = append(categoryChart.Data, count)
+		}
+	}
+
+	// Biller-wise Sales Chart
+	billerChart := ChartData{Labels: []string{}, Data: []float64{}}
+	billerRows, _ := s.db.Table("bills").
+		Joins("JOIN users ON users.id = bills.user_id").
+		Select("users.username, COALESCE(SUM(bills.net_payable - bills.refunded_amount), 0)").
+		Group("users.username").
+		Rows()
+	if billerRows != nil {
+		defer billerRows.Close()
+		for billerRows.Next() {
+			var username string
+			var totalSales float64
+			billerRows.Scan(&username, &totalSales)
+			billerChart.Labels = append(billerChart.Labels, username)
+			billerChart.Data = append(billerChart.Data, totalSales)
+		}
+	}
+
+	return DashboardStats{
+		Metrics: m,
+		Charts: DashboardCharts{
+			Monthly:     monthlyChart,
+			Category:    categoryChart,
+			BillerSales: billerChart,
+			Traffic:     []int{10, 20, 15, 30, 25, 40, 35}, // Mock for now
+			Weekly:      []int{65, 59, 80, 81, 56, 55, 40}, // Mock for now
+		},
+	}
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"billing-app/internal/models"
+	"billing-app/internal/pricing"
+	"billing-app/internal/sequence"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errBillNotRefundable = errors.New("bill is cancelled or already fully returned")
+
+type CancelBillRequest struct {
+	Reason     string `json:"reason" binding:"required"`
+	RefundMode string `json:"refund_mode"`
+}
+
+type ReturnItemRequest struct {
+	BillItemID uint `json:"bill_item_id" binding:"required"`
+	Quantity   int  `json:"quantity" binding:"required,gt=0"`
+}
+
+type ReturnBillRequest struct {
+	Reason     string              `json:"reason" binding:"required"`
+	RefundMode string              `json:"refund_mode"`
+	Items      []ReturnItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type returnLine struct {
+	item     models.BillItem
+	quantity int
+}
+
+// share returns the part of amount attributable to qty more units on top of
+// already returned units out of total. Shares telescope, so returning a line
+// in several steps refunds exactly the original line amount.
+func share(amount float64, returned, qty, total int) float64 {
+	upTo := pricing.Round2(amount * float64(returned+qty) / float64(total))
+	before := pricing.Round2(amount * float64(returned) / float64(total))
+	return pricing.Round2(upTo - before)
+}
+
+// issueCreditNote books a credit note for lines, puts the quantities back into
+// stock and records the refund against the bill. It must run inside tx.
+func issueCreditNote(tx *gorm.DB, bill models.Bill, lines []returnLine, noteType, reason, refundMode string, userID uint) (models.CreditNote, error) {
+	now := time.Now()
+	noteNo, err := sequence.Next(tx, sequence.CreditNote(), now)
+	if err != nil {
+		return models.CreditNote{}, err
+	}
+
+	// Locked like a bill's shift, so the refund cannot miss a closing Z report
+	shiftID := OpenShiftID(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID)
+
+	note := models.CreditNote{
+		CreditNoteNo: noteNo,
+		BillID:       bill.ID,
+		Type:         noteType,
+		Reason:       reason,
+		UserID:       userID,
+		ShiftID:      shiftID,
+		RefundMode:   refundMode,
+		CreatedAt:    now,
+	}
+	if note.RefundMode == "" {
+		note.RefundMode = bill.PaymentMode
+		if note.RefundMode == "SPLIT" {
+			note.RefundMode = "CASH"
+		}
+	}
+
+	// Returns go back to the store that sold them
+	var storeID uint
+	if bill.StoreID != nil {
+		storeID = *bill.StoreID
+	}
+
+	for _, l := range lines {
+		item := l.item
+
+		// Conditional update guards against two returns racing on the same line
+		res := tx.Model(&models.BillItem{}).
+			Where("id = ? AND returned_qty + ? <= quantity", item.ID, l.quantity).
+			Update("returned_qty", gorm.Expr("returned_qty + ?", l.quantity))
+		if res.Error != nil {
+			return note, res.Error
+		}
+		if res.RowsAffected == 0 {
+			return note, fmt.Errorf("%w: bill item %d", errBillNotRefundable, item.ID)
+		}
+
+		noteItem := models.CreditNoteItem{
+			BillItemID:     item.ID,
+			ProductID:      item.ProductID,
+			Quantity:       l.quantity,
+			UnitPrice:      item.UnitPrice,
+			Total:          share(item.Total, item.ReturnedQty, l.quantity, item.Quantity),
+			HSNCode:        item.HSNCode,
+			DiscountAmount: share(item.DiscountAmount, item.ReturnedQty, l.quantity, item.Quantity),
+			TaxableValue:   share(item.TaxableValue, item.ReturnedQty, l.quantity, item.Quantity),
+			GSTRate:        item.GSTRate,
+			CGSTAmount:     share(item.CGSTAmount, item.ReturnedQty, l.quantity, item.Quantity),
+			SGSTAmount:     share(item.SGSTAmount, item.ReturnedQty, l.quantity, item.Quantity),
+			IGSTAmount:     share(item.IGSTAmount, item.ReturnedQty, l.quantity, item.Quantity),
+		}
+		note.Items = append(note.Items, noteItem)
+
+		note.TotalAmount += noteItem.Total
+		note.DiscountAmount += noteItem.DiscountAmount
+		note.TaxableValue += noteItem.TaxableValue
+		note.CGSTAmount += noteItem.CGSTAmount
+		note.SGSTAmount += noteItem.SGSTAmount
+		note.IGSTAmount += noteItem.IGSTAmount
+
+		// Restore Stock; the movement is linked to the credit note once it has
+		// an ID. Goods come back even if the product was deleted since the sale.
+		if err := restoreStock(tx, item, l.quantity, StockChange{
+			ProductID:      item.ProductID,
+			StoreID:        storeID,
+			Type:           models.MovementReturn,
+			RefType:        "CREDIT_NOTE",
+			RefNo:          noteNo,
+			Note:           reason,
+			UserID:         userID,
+			IncludeDeleted: true,
+		}); err != nil {
+			return note, err
+		}
+		entry := models.StockEntry{
+			ProductID:     item.ProductID,
+			QuantityAdded: l.quantity,
+			StoreID:       bill.StoreID,
+			Source:        "RETURN",
+			Reference:     noteNo,
+			AddedBy:       userID,
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return note, err
+		}
+	}
+
+	note.TotalAmount = pricing.Round2(note.TotalAmount)
+	note.DiscountAmount = pricing.Round2(note.DiscountAmount)
+	note.TaxableValue = pricing.Round2(note.TaxableValue)
+	note.CGSTAmount = pricing.Round2(note.CGSTAmount)
+	note.SGSTAmount = pricing.Round2(note.SGSTAmount)
+	note.IGSTAmount = pricing.Round2(note.IGSTAmount)
+	note.RefundAmount = pricing.Round2(note.TaxableValue + note.CGSTAmount + note.SGSTAmount + note.IGSTAmount)
+
+	updates := map[string]interface{}{}
+	if noteType == "CANCELLATION" {
+		// A cancellation refunds whatever is left, including the bill's round-off
+		note.RefundAmount = pricing.Round2(bill.NetPayable - bill.RefundedAmount)
+		updates["status"] = "CANCELLED"
+	}
+	updates["refunded_amount"] = gorm.Expr("refunded_amount + ?", note.RefundAmount)
+
+	res := tx.Model(&models.Bill{}).Where("id = ? AND status = ?", bill.ID, "PAID").Updates(updates)
+	if res.Error != nil {
+		return note, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return note, errBillNotRefundable
+	}
+
+	if err := tx.Create(&note).Error; err != nil {
+		return note, err
+	}
+	if err := tx.Model(&models.StockMovement{}).Where("ref_type = ? AND ref_no = ?", "CREDIT_NOTE", noteNo).Update("ref_id", note.ID).Error; err != nil {
+		return note, err
+	}
+	return note, nil
+}
+
+// restoreStock puts returned units back into the batches the line was sold
+// from, latest sold first; anything without a batch record is restored unbatched
+func restoreStock(tx *gorm.DB, item models.BillItem, qty int, change StockChange) error {
+	var sold []models.BillItemBatch
+	if err := tx.Where("bill_item_id = ? AND returned_qty < quantity", item.ID).Order("id desc").Find(&sold).Error; err != nil {
+		return err
+	}
+
+	for _, s := range sold {
+		if qty == 0 {
+			break
+		}
+		back := min(qty, s.Quantity-s.ReturnedQty)
+		res := tx.Model(&models.BillItemBatch{}).
+			Where("id = ? AND returned_qty + ? <= quantity", s.ID, back).
+			Update("returned_qty", gorm.Expr("returned_qty + ?", back))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("%w: bill item %d", errBillNotRefundable, item.ID)
+		}
+
+		batchChange := change
+		batchChange.Quantity = back
+		batchChange.BatchID = &s.BatchID
+		if _, err := MoveStock(tx, batchChange); err != nil {
+			return err
+		}
+		qty -= back
+	}
+
+	if qty > 0 {
+		change.Quantity = qty
+		if _, err := MoveStock(tx, change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRefundMode checks the requested refund tender; empty refunds the way the bill was paid
+func checkRefundMode(mode string) (string, error) {
+	mode = strings.ToUpper(mode)
+	if mode == "" {
+		return mode, nil
+	}
+	for _, t := range models.TenderTypes {
+		if mode == t {
+			return mode, nil
+		}
+	}
+	return "", invalid("Unsupported refund mode %q (use %s)", mode, strings.Join(models.TenderTypes, ", "))
+}
+
+func loadRefundableBill(tx *gorm.DB, id uint) (models.Bill, error) {
+	var bill models.Bill
+	if err := tx.Preload("Items").First(&bill, id).Error; err != nil {
+		return bill, err
+	}
+	if bill.Status != "PAID" {
+		return bill, errBillNotRefundable
+	}
+	return bill, nil
+}
+
+// refundError reports a failed cancellation or return
+func refundError(err error) error {
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return notFound("Bill not found")
+	case errors.Is(err, errBillNotRefundable):
+		return conflict("Bill is cancelled or the quantity has already been returned")
+	default:
+		return failed("Failed to issue credit note")
+	}
+}
+
+func (s *billingService) CancelBill(id uint, req CancelBillRequest, userID uint) (models.CreditNote, error) {
+	var note models.CreditNote
+	mode, err := checkRefundMode(req.RefundMode)
+	if err != nil {
+		return note, err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		bill, err := loadRefundableBill(tx, id)
+		if err != nil {
+			return err
+		}
+
+		var lines []returnLine
+		for _, item := range bill.Items {
+			if remaining := item.Quantity - item.ReturnedQty; remaining > 0 {
+				lines = append(lines, returnLine{item: item, quantity: remaining})
+			}
+		}
+		if len(lines) == 0 {
+			return errBillNotRefundable
+		}
+
+		note, err = issueCreditNote(tx, bill, lines, "CANCELLATION", req.Reason, mode, userID)
+		return err
+	})
+	if err != nil {
+		return note, refundError(err)
+	}
+	return note, nil
+}
+
+func (s *billingService) ReturnItems(id uint, req ReturnBillRequest, userID uint) (models.CreditNote, error) {
+	var note models.CreditNote
+	mode, err := checkRefundMode(req.RefundMode)
+	if err != nil {
+		return note, err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		bill, err := loadRefundableBill(tx, id)
+		if err != nil {
+			return err
+		}
+
+		items := map[uint]models.BillItem{}
+		for _, item := range bill.Items {
+			items[item.ID] = item
+		}
+
+		var lines []returnLine
+		for _, r := range req.Items {
+			item, ok := items[r.BillItemID]
+			if !ok {
+				return invalid("Bill item %d does not belong to this bill", r.BillItemID)
+			}
+			if item.ReturnedQty+r.Quantity > item.Quantity {
+				return invalid("Only %d unit(s) of bill item %d can be returned", item.Quantity-item.ReturnedQty, item.ID)
+			}
+			lines = append(lines, returnLine{item: item, quantity: r.Quantity})
+			// Later lines for the same item continue from this quantity
+			item.ReturnedQty += r.Quantity
+			items[r.BillItemID] = item
+		}
+
+		note, err = issueCreditNote(tx, bill, lines, "RETURN", req.Reason, mode, userID)
+		return err
+	})
+	if err != nil {
+		return note, refundError(err)
+	}
+	return note, nil
+}
+
+func (s *billingService) CreditNotes(billID uint) ([]models.CreditNote, error) {
+	var notes []models.CreditNote
+	err := s.db.Preload("User").Preload("Items").Preload("Items.Product").Where("bill_id = ?", billID).Order("created_at desc").Find(&notes).Error
+	return notes, err
+}
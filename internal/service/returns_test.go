@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"testing"
+
+	"billing-app/internal/models"
+	"billing-app/internal/service"
+	"billing-app/internal/service/servicetest"
+)
+
+func TestReturnRefundMode(t *testing.T) {
+	env, err := servicetest.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+
+	product, err := env.Product("Returnable", 100, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		mode string
+		want string
+		kind service.Kind
+	}{
+		{"", "CASH", 0},
+		{"UPI", "UPI", 0},
+		{"card", "CARD", 0},
+		{"CHEQUE", "", service.KindInvalid},
+		{"SPLIT", "", service.KindInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			bill, _, err := env.Billing.CreateBill(service.CreateBillRequest{
+				PaymentMode: "CASH",
+				Items:       []service.BillItemRequest{{ProductID: product.ID, Quantity: 1}},
+			}, env.Admin.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var item models.BillItem
+			env.DB.Where("bill_id = ?", bill.ID).First(&item)
+
+			note, err := env.Billing.ReturnItems(bill.ID, service.ReturnBillRequest{
+				Reason:     "Unwanted",
+				RefundMode: tt.mode,
+				Items:      []service.ReturnItemRequest{{BillItemID: item.ID, Quantity: 1}},
+			}, env.Admin.ID)
+			if tt.kind != 0 {
+				if errorKind(err) != tt.kind {
+					t.Errorf("ReturnItems: %v, want kind %d", err, tt.kind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if note.RefundMode != tt.want {
+				t.Errorf("refund mode %q, want %q", note.RefundMode, tt.want)
+			}
+		})
+	}
+}
+
+// Goods sold before their product was deleted still come back into stock
+func TestCancelBillOfDeletedProduct(t *testing.T) {
+	env, err := servicetest.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+
+	product, err := env.Product("Discontinued", 40, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bill, _, err := env.Billing.CreateBill(service.CreateBillRequest{
+		PaymentMode: "CASH",
+		Items:       []service.BillItemRequest{{ProductID: product.ID, Quantity: 2}},
+	}, env.Admin.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := env.Catalog.DeleteProduct(product.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := env.Billing.CancelBill(bill.ID, service.CancelBillRequest{Reason: "Wrong item"}, env.Admin.ID); err != nil {
+		t.Fatalf("CancelBill: %v", err)
+	}
+
+	var stored models.Product
+	env.DB.Unscoped().First(&stored, product.ID)
+	var atStore models.StoreStock
+	env.DB.Where("store_id = ? AND product_id = ?", env.Store.ID, product.ID).First(&atStore)
+	if stored.CurrentStock != 5 || atStore.Quantity != 5 {
+		t.Errorf("stock after cancel: product %d, store %d; want 5", stored.CurrentStock, atStore.Quantity)
+	}
+}
@@ -0,0 +1,130 @@
+// Package service holds the business rules behind the HTTP handlers: billing,
+// shifts, the catalogue, inventory, purchasing, stock takes, transfers, stores,
+// reports, customer orders and users. Handlers receive the service interfaces
+// through their constructors and never touch the database, so they can be
+// exercised against a throwaway database (see servicetest) or a hand-written
+// fake. The GORM-backed implementations here are built with New.
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Kind classifies an Error so handlers can pick a response status
+type Kind int
+
+const (
+	KindInvalid       Kind = iota + 1 // The request can't be processed as sent
+	KindNotFound                      // A referenced record doesn't exist
+	KindConflict                      // The current state forbids it, e.g. no stock
+	KindUnprocessable                 // Well-formed, but disagrees with the server (e.g. bill totals)
+	KindUnauthorized                  // Bad credentials
+	KindForbidden                     // Valid credentials, but not allowed
+	KindFailed                        // The server failed; the message is safe to show
+)
+
+// Error is a failure the caller can report to the user. Details are extra
+// fields for the response body, like the available quantity on a conflict.
+type Error struct {
+	Kind    Kind
+	Message string
+	Details map[string]interface{}
+}
+
+func (e *Error) Error() string { return e.Message }
+
+func newError(kind Kind, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+func invalid(format string, args ...interface{}) *Error {
+	return newError(KindInvalid, format, args...)
+}
+
+func notFound(format string, args ...interface{}) *Error {
+	return newError(KindNotFound, format, args...)
+}
+
+func conflict(format string, args ...interface{}) *Error {
+	return newError(KindConflict, format, args...)
+}
+
+func failed(format string, args ...interface{}) *Error {
+	return newError(KindFailed, format, args...)
+}
+
+// Actor is the signed-in user a request is made for
+type Actor struct {
+	UserID uint
+	Role   string
+}
+
+// IsManager reports whether the actor is an admin or manager, who may change
+// prices and work at any store
+func (a Actor) IsManager() bool {
+	return a.Role == "admin" || a.Role == "manager"
+}
+
+// with adds response fields to the error
+func (e *Error) with(details map[string]interface{}) *Error {
+	e.Details = details
+	return e
+}
+
+// stockError reports a failed stock movement: a conflict with the available
+// quantity when stock ran short, invalid for other rejected movements
+func stockError(err error) error {
+	var short *StockConflictError
+	if errors.As(err, &short) {
+		return conflict("%s", short.Error()).with(map[string]interface{}{"product_id": short.ProductID, "available": short.Available})
+	}
+	if errors.Is(err, ErrParentProduct) || errors.Is(err, ErrNoDefaultStore) {
+		return invalid("%s", err.Error())
+	}
+	return err
+}
+
+// movementError reports a stock movement the caller asked for and MoveStock
+// rejected: a conflict when stock ran short, otherwise the caller's mistake
+// (unknown product, store or batch)
+func movementError(err error) error {
+	var short *StockConflictError
+	if errors.As(err, &short) {
+		return stockError(err)
+	}
+	return invalid("%s", err.Error())
+}
+
+// Services bundles the GORM-backed services sharing one database
+type Services struct {
+	Billing    BillingService
+	Shifts     ShiftService
+	Catalog    CatalogService
+	Inventory  InventoryService
+	Purchases  PurchaseService
+	StockTakes StockTakeService
+	Transfers  TransferService
+	Stores     StoreService
+	Reports    ReportService
+	Orders     OrderService
+	Users      UserService
+}
+
+func New(db *gorm.DB) *Services {
+	return &Services{
+		Billing:    NewBillingService(db),
+		Shifts:     NewShiftService(db),
+		Catalog:    NewCatalogService(db),
+		Inventory:  NewInventoryService(db),
+		Purchases:  NewPurchaseService(db),
+		StockTakes: NewStockTakeService(db),
+		Transfers:  NewTransferService(db),
+		Stores:     NewStoreService(db),
+		Reports:    NewReportService(db),
+		Orders:     NewOrderService(db),
+		Users:      NewUserService(db),
+	}
+}
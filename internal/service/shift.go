@@ -0,0 +1,290 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"billing-app/internal/models"
+	"billing-app/internal/pricing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ShiftService runs till shifts: a biller opens one with a float, records
+// cash in and out, and closes it with the counted drawer; a manager approves
+// the variance
+type ShiftService interface {
+	OpenShift(userID uint, openingFloat float64) (models.Shift, error)
+	// CurrentShift is the user's open shift, with its cash movements, and its X report
+	CurrentShift(userID uint) (models.Shift, ShiftReport, error)
+	AddCashMovement(userID uint, req CashMovementRequest) (models.CashMovement, error)
+	// CloseShift takes the counted drawer cash and freezes the Z report
+	CloseShift(userID uint, req CloseShiftRequest) (ShiftReport, error)
+	// ListShifts returns the latest 100 shifts; zero values match all
+	ListShifts(status string, userID uint) ([]models.Shift, error)
+	GetShift(id uint) (models.Shift, ShiftReport, error)
+	// ApproveShift signs off a closed shift's variance
+	ApproveShift(id, approverID uint, note string) error
+}
+
+type CashMovementRequest struct {
+	Type   string  `json:"type" binding:"required,oneof=CASH_IN CASH_OUT"`
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+	Reason string  `json:"reason" binding:"required"`
+}
+
+type CloseShiftRequest struct {
+	CountedCash *float64 `json:"counted_cash" binding:"required,gte=0"`
+	Note        string   `json:"note"`
+}
+
+// ShiftReport is the X (mid-shift) or Z (closing) till report
+type ShiftReport struct {
+	Type         string             `json:"type"` // X or Z
+	ShiftID      uint               `json:"shift_id"`
+	OpenedAt     time.Time          `json:"opened_at"`
+	ClosedAt     *time.Time         `json:"closed_at"`
+	BillCount    int                `json:"bill_count"`
+	Sales        float64            `json:"sales"`
+	Tenders      map[string]float64 `json:"tenders"`
+	Refunds      map[string]float64 `json:"refunds"`
+	OpeningFloat float64            `json:"opening_float"`
+	CashSales    float64            `json:"cash_sales"`
+	CashRefunds  float64            `json:"cash_refunds"`
+	CashIn       float64            `json:"cash_in"`
+	CashOut      float64            `json:"cash_out"`
+	ExpectedCash float64            `json:"expected_cash"`
+	CountedCash  *float64           `json:"counted_cash"`
+	Variance     *float64           `json:"variance"`
+}
+
+type shiftService struct {
+	db *gorm.DB
+}
+
+func NewShiftService(db *gorm.DB) ShiftService {
+	return &shiftService{db: db}
+}
+
+// buildShiftReport totals the drawer from bills and credit notes stamped with
+// the shift. A closed shift keeps the expected cash frozen at close, which the
+// counted cash and variance were measured against.
+func buildShiftReport(db *gorm.DB, shift models.Shift) ShiftReport {
+	report := ShiftReport{
+		Type:         "X",
+		ShiftID:      shift.ID,
+		OpenedAt:     shift.OpenedAt,
+		ClosedAt:     shift.ClosedAt,
+		Tenders:      map[string]float64{},
+		Refunds:      map[string]float64{},
+		OpeningFloat: shift.OpeningFloat,
+	}
+
+	var bills []models.Bill
+	db.Preload("Payments").Where("shift_id = ?", shift.ID).Find(&bills)
+	for _, bill := range bills {
+		report.BillCount++
+		report.Sales += bill.NetPayable
+		if len(bill.Payments) == 0 {
+			report.Tenders[bill.PaymentMode] += bill.NetPayable
+		}
+		for _, p := range bill.Payments {
+			report.Tenders[p.TenderType] += p.Amount
+		}
+	}
+
+	var notes []models.CreditNote
+	db.Where("shift_id = ?", shift.ID).Find(&notes)
+	for _, note := range notes {
+		report.Refunds[note.RefundMode] += note.RefundAmount
+	}
+
+	var movements []models.CashMovement
+	db.Where("shift_id = ?", shift.ID).Find(&movements)
+	for _, m := range movements {
+		if m.Type == "CASH_IN" {
+			report.CashIn += m.Amount
+		} else {
+			report.CashOut += m.Amount
+		}
+	}
+
+	for k, v := range report.Tenders {
+		report.Tenders[k] = pricing.Round2(v)
+	}
+	for k, v := range report.Refunds {
+		report.Refunds[k] = pricing.Round2(v)
+	}
+	report.Sales = pricing.Round2(report.Sales)
+	report.CashSales = report.Tenders["CASH"]
+	report.CashRefunds = report.Refunds["CASH"]
+	report.CashIn = pricing.Round2(report.CashIn)
+	report.CashOut = pricing.Round2(report.CashOut)
+	report.ExpectedCash = pricing.Round2(report.OpeningFloat + report.CashSales - report.CashRefunds + report.CashIn - report.CashOut)
+
+	if shift.Status != "OPEN" {
+		report.Type = "Z"
+		report.ExpectedCash = shift.ExpectedCash
+		counted, variance := shift.CountedCash, shift.Variance
+		report.CountedCash = &counted
+		report.Variance = &variance
+	}
+	return report
+}
+
+// openShift loads the user's open shift
+func (s *shiftService) openShift(userID uint) (models.Shift, error) {
+	var shift models.Shift
+	if err := s.db.Where("user_id = ? AND status = ?", userID, "OPEN").Order("id desc").First(&shift).Error; err != nil {
+		return shift, notFound("No open shift")
+	}
+	return shift, nil
+}
+
+func (s *shiftService) OpenShift(userID uint, openingFloat float64) (models.Shift, error) {
+	shift := models.Shift{
+		UserID:       userID,
+		Status:       "OPEN",
+		OpenedAt:     time.Now(),
+		OpeningFloat: pricing.Round2(openingFloat),
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Locking the user serializes their opens, so two requests cannot both
+		// find no open shift and each open one
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, userID).Error; err != nil {
+			return notFound("User not found")
+		}
+		if OpenShiftID(tx, userID) != nil {
+			return conflict("A shift is already open; close it first")
+		}
+
+		// Tie the shift to the login session it was opened from
+		var login models.LoginHistory
+		if err := tx.Where("user_id = ?", userID).Order("login_time desc").First(&login).Error; err == nil {
+			shift.LoginHistoryID = &login.ID
+		}
+
+		if err := tx.Create(&shift).Error; err != nil {
+			return failed("Failed to open shift")
+		}
+		return nil
+	})
+	return shift, err
+}
+
+func (s *shiftService) CurrentShift(userID uint) (models.Shift, ShiftReport, error) {
+	shift, err := s.openShift(userID)
+	if err != nil {
+		return shift, ShiftReport{}, err
+	}
+	s.db.Preload("CashMovements").First(&shift, shift.ID)
+	return shift, buildShiftReport(s.db, shift), nil
+}
+
+func (s *shiftService) AddCashMovement(userID uint, req CashMovementRequest) (models.CashMovement, error) {
+	shift, err := s.openShift(userID)
+	if err != nil {
+		return models.CashMovement{}, err
+	}
+
+	movement := models.CashMovement{
+		ShiftID: shift.ID,
+		Type:    req.Type,
+		Amount:  pricing.Round2(req.Amount),
+		Reason:  req.Reason,
+		UserID:  userID,
+	}
+	if err := s.db.Create(&movement).Error; err != nil {
+		return movement, failed("Failed to record cash movement")
+	}
+	return movement, nil
+}
+
+func (s *shiftService) CloseShift(userID uint, req CloseShiftRequest) (ShiftReport, error) {
+	shift, err := s.openShift(userID)
+	if err != nil {
+		return ShiftReport{}, err
+	}
+
+	var report ShiftReport
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Billing and refunds lock the open shift while they write to it; once
+		// the lock is held here, the report below sees every bill on the shift
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND status = ?", shift.ID, "OPEN").First(&shift).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return conflict("Shift is already closed")
+			}
+			return failed("Failed to close shift")
+		}
+
+		report = buildShiftReport(tx, shift)
+		counted := pricing.Round2(*req.CountedCash)
+		if err := tx.Model(&models.Shift{}).Where("id = ?", shift.ID).Updates(map[string]interface{}{
+			"status":        "CLOSED",
+			"closed_at":     time.Now(),
+			"expected_cash": report.ExpectedCash,
+			"counted_cash":  counted,
+			"variance":      pricing.Round2(counted - report.ExpectedCash),
+			"closing_note":  req.Note,
+		}).Error; err != nil {
+			return failed("Failed to close shift")
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	s.db.First(&shift, shift.ID)
+	return buildShiftReport(s.db, shift), nil
+}
+
+func (s *shiftService) ListShifts(status string, userID uint) ([]models.Shift, error) {
+	var shifts []models.Shift
+	query := s.db.Preload("User").Preload("Approver").Order("opened_at desc").Limit(100)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	err := query.Find(&shifts).Error
+	return shifts, err
+}
+
+func (s *shiftService) GetShift(id uint) (models.Shift, ShiftReport, error) {
+	var shift models.Shift
+	if err := s.db.Preload("User").Preload("Approver").Preload("CashMovements").First(&shift, id).Error; err != nil {
+		return shift, ShiftReport{}, notFound("Shift not found")
+	}
+	return shift, buildShiftReport(s.db, shift), nil
+}
+
+func (s *shiftService) ApproveShift(id, approverID uint, note string) error {
+	var shift models.Shift
+	if err := s.db.Select("id").First(&shift, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return notFound("Shift not found")
+		}
+		return failed("Failed to fetch shift")
+	}
+
+	// Conditional, so a shift is approved once and never while still open
+	res := s.db.Model(&models.Shift{}).Where("id = ? AND status = ?", id, "CLOSED").Updates(map[string]interface{}{
+		"status":        "APPROVED",
+		"approved_by":   approverID,
+		"approved_at":   time.Now(),
+		"approval_note": note,
+	})
+	if res.Error != nil {
+		return failed("Failed to approve shift")
+	}
+	if res.RowsAffected == 0 {
+		return conflict("Only closed shifts can be approved")
+	}
+	return nil
+}
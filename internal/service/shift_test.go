@@ -0,0 +1,167 @@
+package service_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"billing-app/internal/models"
+	"billing-app/internal/service"
+	"billing-app/internal/service/servicetest"
+
+	"gorm.io/gorm"
+)
+
+// errorKind is the kind of a service error, 0 for no error
+func errorKind(err error) service.Kind {
+	var e *service.Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	if err != nil {
+		return -1
+	}
+	return 0
+}
+
+// Double-clicked "open shift" buttons must not leave a cashier with two open
+// shifts splitting their bills
+func TestOpenShiftConcurrently(t *testing.T) {
+	env, err := servicetest.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+
+	const attempts = 10
+	errs := make([]error, attempts)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = env.Shifts.OpenShift(env.Admin.ID, 500)
+		}(i)
+	}
+	wg.Wait()
+
+	opened := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			opened++
+		case errorKind(err) != service.KindConflict:
+			t.Errorf("OpenShift: %v, want a conflict", err)
+		}
+	}
+	if opened != 1 {
+		t.Errorf("%d shifts opened, want 1", opened)
+	}
+
+	var open int64
+	env.DB.Model(&models.Shift{}).Where("user_id = ? AND status = ?", env.Admin.ID, "OPEN").Count(&open)
+	if open != 1 {
+		t.Errorf("%d open shifts stored, want 1", open)
+	}
+}
+
+func TestApproveShift(t *testing.T) {
+	env, err := servicetest.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+
+	shift, err := env.Shifts.OpenShift(env.Admin.ID, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := env.Shifts.ApproveShift(shift.ID, env.Admin.ID, "early"); errorKind(err) != service.KindConflict {
+		t.Errorf("approving an open shift: %v, want a conflict", err)
+	}
+
+	counted := 480.0
+	if _, err := env.Shifts.CloseShift(env.Admin.ID, service.CloseShiftRequest{CountedCash: &counted}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A cash movement that raced the close lands on the closed shift; the Z
+	// report must still show the expected cash the variance was taken against
+	env.DB.Create(&models.CashMovement{ShiftID: shift.ID, Type: "CASH_IN", Amount: 100, Reason: "late", UserID: env.Admin.ID})
+
+	if err := env.Shifts.ApproveShift(shift.ID, env.Admin.ID, "ok"); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.Shifts.ApproveShift(shift.ID, env.Admin.ID, "again"); errorKind(err) != service.KindConflict {
+		t.Errorf("approving twice: %v, want a conflict", err)
+	}
+	if err := env.Shifts.ApproveShift(shift.ID+1, env.Admin.ID, "missing"); errorKind(err) != service.KindNotFound {
+		t.Errorf("approving a missing shift: %v, want not found", err)
+	}
+
+	_, report, err := env.Shifts.GetShift(shift.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Type != "Z" || report.ExpectedCash != 500 || *report.Variance != -20 {
+		t.Errorf("Z report: type %s, expected cash %.2f, variance %.2f; want Z, 500.00, -20.00", report.Type, report.ExpectedCash, *report.Variance)
+	}
+}
+
+// A shift closed after the biller's request found it open must not take the
+// bill: the frozen Z report would not count its cash
+func TestBillOnShiftClosedMeanwhile(t *testing.T) {
+	env, err := servicetest.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+
+	product, err := env.Product("Counter Item", 10, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shift, err := env.Shifts.OpenShift(env.Admin.ID, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Close the shift as soon as the bill has first looked it up
+	closed := false
+	err = env.DB.Callback().Query().After("gorm:query").Register("test:close_shift", func(db *gorm.DB) {
+		if closed || db.Statement.Table != "shifts" {
+			return
+		}
+		closed = true
+		counted := 500.0
+		if _, err := env.Shifts.CloseShift(env.Admin.ID, service.CloseShiftRequest{CountedCash: &counted}); err != nil {
+			t.Errorf("CloseShift: %v", err)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = env.Billing.CreateBill(service.CreateBillRequest{
+		PaymentMode: "CASH",
+		Items:       []service.BillItemRequest{{ProductID: product.ID, Quantity: 1}},
+	}, env.Admin.ID)
+	if !closed {
+		t.Fatal("the shift was never looked up")
+	}
+	if errorKind(err) != service.KindConflict {
+		t.Errorf("CreateBill: %v, want a conflict", err)
+	}
+
+	var bills int64
+	env.DB.Model(&models.Bill{}).Where("shift_id = ?", shift.ID).Count(&bills)
+	if bills != 0 {
+		t.Errorf("%d bills on the closed shift, want 0", bills)
+	}
+	if err := env.DB.First(&product, product.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if product.CurrentStock != 100 {
+		t.Errorf("current_stock = %d, want 100", product.CurrentStock)
+	}
+}
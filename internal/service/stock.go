@@ -0,0 +1,240 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"billing-app/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrParentProduct     = errors.New("stock is kept on variants, not on their parent product")
+)
+
+// StockConflictError reports a product that could not cover the requested quantity
+type StockConflictError struct {
+	ProductID uint
+	Name      string
+	Requested int
+	Available int
+}
+
+func (e *StockConflictError) Error() string {
+	return fmt.Sprintf("Insufficient stock for %s (requested %d, available %d)", e.Name, e.Requested, e.Available)
+}
+
+func (e *StockConflictError) Unwrap() error { return ErrInsufficientStock }
+
+// StockChange describes one ledger movement. Quantity is signed.
+type StockChange struct {
+	ProductID uint
+	Quantity  int
+	Type      string
+	RefType   string
+	RefID     *uint
+	RefNo     string
+	Note      string
+	UserID    uint
+	StoreID   uint // 0 means the default store
+
+	// Batch-tracked products only. Increases go to BatchID, else to the batch
+	// described by Batch (found or created by number), else to UNBATCHED.
+	// Decreases take from BatchID, else first-expiry-first-out.
+	BatchID *uint
+	Batch   *models.StockBatch
+
+	// IncludeDeleted moves stock of a soft-deleted product, for returns of
+	// products deleted since the sale
+	IncludeDeleted bool
+}
+
+// MoveStock is the only place Product.CurrentStock and StoreStock change. It
+// applies the change atomically inside tx and appends a StockMovement with the
+// running balance. Decreases are conditional, so concurrent callers cannot drive
+// stock negative at the store; the UPDATEs hold the row locks until commit,
+// always product row first. Parent products of variants never hold stock.
+func MoveStock(tx *gorm.DB, change StockChange) (models.StockMovement, error) {
+	storeID, err := ResolveStoreID(tx, change.StoreID)
+	if err != nil {
+		return models.StockMovement{}, err
+	}
+	change.StoreID = storeID
+
+	products := tx
+	if change.IncludeDeleted {
+		products = tx.Unscoped().Session(&gorm.Session{})
+	}
+
+	query := products.Model(&models.Product{}).Where("id = ? AND has_variants = ?", change.ProductID, false)
+	if change.Quantity < 0 {
+		query = query.Where("current_stock >= ?", -change.Quantity)
+	}
+	res := query.Update("current_stock", gorm.Expr("current_stock + ?", change.Quantity))
+	if res.Error != nil {
+		return models.StockMovement{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		var product models.Product
+		if err := products.Select("id", "name", "current_stock", "has_variants").First(&product, change.ProductID).Error; err != nil {
+			return models.StockMovement{}, fmt.Errorf("Product ID %d not found", change.ProductID)
+		}
+		if product.HasVariants {
+			return models.StockMovement{}, ErrParentProduct
+		}
+		return models.StockMovement{}, &StockConflictError{ProductID: change.ProductID, Name: product.Name, Requested: -change.Quantity, Available: product.CurrentStock}
+	}
+
+	var product models.Product
+	if err := products.Select("id", "name", "current_stock", "track_batches").First(&product, change.ProductID).Error; err != nil {
+		return models.StockMovement{}, err
+	}
+
+	if err := moveStoreStock(tx, product, change); err != nil {
+		return models.StockMovement{}, err
+	}
+
+	var allocations []models.BatchAllocation
+	if product.TrackBatches {
+		if allocations, err = moveBatches(tx, product, change); err != nil {
+			return models.StockMovement{}, err
+		}
+	}
+
+	movement := models.StockMovement{
+		ProductID:    change.ProductID,
+		Type:         change.Type,
+		Quantity:     change.Quantity,
+		BalanceAfter: product.CurrentStock,
+		RefType:      change.RefType,
+		RefID:        change.RefID,
+		RefNo:        change.RefNo,
+		Note:         change.Note,
+		UserID:       change.UserID,
+		StoreID:      &storeID,
+	}
+	if len(allocations) == 1 {
+		movement.BatchID = &allocations[0].BatchID
+	}
+	err = tx.Create(&movement).Error
+	movement.Batches = allocations
+	return movement, err
+}
+
+// moveStoreStock applies the change to the product's stock at change.StoreID
+func moveStoreStock(tx *gorm.DB, product models.Product, change StockChange) error {
+	if change.Quantity > 0 {
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "store_id"}, {Name: "product_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"quantity": gorm.Expr("store_stocks.quantity + ?", change.Quantity)}),
+		}).Create(&models.StoreStock{StoreID: change.StoreID, ProductID: product.ID, Quantity: change.Quantity}).Error
+	}
+
+	res := tx.Model(&models.StoreStock{}).
+		Where("store_id = ? AND product_id = ? AND quantity >= ?", change.StoreID, product.ID, -change.Quantity).
+		Update("quantity", gorm.Expr("quantity + ?", change.Quantity))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		var available int
+		tx.Model(&models.StoreStock{}).Select("quantity").Where("store_id = ? AND product_id = ?", change.StoreID, product.ID).Scan(&available)
+		return &StockConflictError{ProductID: product.ID, Name: product.Name, Requested: -change.Quantity, Available: available}
+	}
+	return nil
+}
+
+// moveBatches applies a movement of a batch-tracked product to its batches.
+// Sales skip expired batches; other decreases (write-offs, counts) take the
+// earliest expiry first, expired or not.
+func moveBatches(tx *gorm.DB, product models.Product, change StockChange) ([]models.BatchAllocation, error) {
+	if change.Quantity > 0 {
+		batch, err := resolveBatch(tx, product.ID, change)
+		if err != nil {
+			return nil, err
+		}
+		updates := map[string]interface{}{"quantity_remaining": gorm.Expr("quantity_remaining + ?", change.Quantity)}
+		if change.Type == models.MovementPurchase || change.Type == models.MovementOpening {
+			updates["quantity_received"] = gorm.Expr("quantity_received + ?", change.Quantity)
+		}
+		if err := tx.Model(&models.StockBatch{}).Where("id = ?", batch.ID).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+		return []models.BatchAllocation{{BatchID: batch.ID, BatchNo: batch.BatchNo, ExpiryDate: batch.ExpiryDate, Quantity: change.Quantity}}, nil
+	}
+
+	need := -change.Quantity
+	var batches []models.StockBatch
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("store_id = ? AND product_id = ? AND quantity_remaining > 0", change.StoreID, product.ID)
+	if change.BatchID != nil {
+		query = query.Where("id = ?", *change.BatchID)
+	} else if change.Type == models.MovementSale {
+		query = query.Where("expiry_date IS NULL OR expiry_date >= ?", time.Now().Format("2006-01-02"))
+	}
+	// Undated batches go last
+	if err := query.Order("CASE WHEN expiry_date IS NULL THEN 1 ELSE 0 END, expiry_date, id").Find(&batches).Error; err != nil {
+		return nil, err
+	}
+
+	available := 0
+	for _, b := range batches {
+		available += b.QuantityRemaining
+	}
+	if available < need {
+		return nil, &StockConflictError{ProductID: product.ID, Name: product.Name, Requested: need, Available: available}
+	}
+
+	var allocations []models.BatchAllocation
+	for _, b := range batches {
+		if need == 0 {
+			break
+		}
+		take := min(need, b.QuantityRemaining)
+		if err := tx.Model(&models.StockBatch{}).Where("id = ?", b.ID).
+			Update("quantity_remaining", gorm.Expr("quantity_remaining - ?", take)).Error; err != nil {
+			return nil, err
+		}
+		allocations = append(allocations, models.BatchAllocation{BatchID: b.ID, BatchNo: b.BatchNo, ExpiryDate: b.ExpiryDate, Quantity: -take})
+		need -= take
+	}
+	return allocations, nil
+}
+
+// resolveBatch finds the batch an increase goes into, creating it by number if needed
+func resolveBatch(tx *gorm.DB, productID uint, change StockChange) (models.StockBatch, error) {
+	var batch models.StockBatch
+	if change.BatchID != nil {
+		if err := tx.Where("id = ? AND store_id = ? AND product_id = ?", *change.BatchID, change.StoreID, productID).First(&batch).Error; err != nil {
+			return batch, fmt.Errorf("Batch %d not found for product %d at this store", *change.BatchID, productID)
+		}
+		return batch, nil
+	}
+
+	template := models.StockBatch{BatchNo: models.UnbatchedBatchNo}
+	if change.Batch != nil && change.Batch.BatchNo != "" {
+		template = *change.Batch
+	}
+
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.StockBatch{
+		StoreID:    change.StoreID,
+		ProductID:  productID,
+		BatchNo:    template.BatchNo,
+		MfgDate:    template.MfgDate,
+		ExpiryDate: template.ExpiryDate,
+		CostPrice:  template.CostPrice,
+	}).Error; err != nil {
+		return batch, err
+	}
+	if err := tx.Where("store_id = ? AND product_id = ? AND batch_no = ?", change.StoreID, productID, template.BatchNo).First(&batch).Error; err != nil {
+		return batch, err
+	}
+	if change.Batch != nil && change.Batch.ExpiryDate != nil && batch.ExpiryDate != nil &&
+		batch.ExpiryDate.Format("2006-01-02") != change.Batch.ExpiryDate.Format("2006-01-02") {
+		return batch, fmt.Errorf("Batch %s already exists with expiry %s", batch.BatchNo, batch.ExpiryDate.Format("2006-01-02"))
+	}
+	return batch, nil
+}
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"time"
+
+	"billing-app/internal/models"
+	"billing-app/internal/sequence"
+
+	"gorm.io/gorm"
+)
+
+// StockTakeService runs physical counts: a count sheet is opened for a store,
+// counted (by hand or by scanning), submitted and approved by a manager, which
+// posts the variances to the stock ledger
+type StockTakeService interface {
+	// CreateStockTake opens a count sheet with every active product in scope
+	CreateStockTake(req CreateStockTakeRequest, actor Actor) (models.StockTake, error)
+	// ListStockTakes filters by store and status; zero values match all
+	ListStockTakes(storeID uint, status string) ([]models.StockTake, error)
+	// GetStockTake returns the sheet with variances against the current stock for review
+	GetStockTake(id uint) (StockTakeSheet, error)
+	// RecordCounts enters counted quantities on an OPEN sheet, by product ID or barcode scan
+	RecordCounts(id uint, req RecordCountsRequest, userID uint) error
+	// SubmitStockTake closes counting and hands the sheet to a manager for review
+	SubmitStockTake(id uint) error
+	CancelStockTake(id uint) error
+	// ApproveStockTake posts the variance of every counted line, against the
+	// stock when it was counted, as an adjustment with its reason code and
+	// returns the number of products adjusted. Uncounted lines are left alone.
+	ApproveStockTake(id uint, req ApproveStockTakeRequest, userID uint) (int, error)
+}
+
+type CreateStockTakeRequest struct {
+	StoreID    *uint  `json:"store_id"`    // Defaults to the user's store
+	CategoryID *uint  `json:"category_id"` // Includes its subcategories
+	Location   string `json:"location"`
+	Notes      string `json:"notes"`
+}
+
+type CountEntry struct {
+	ProductID uint   `json:"product_id"`
+	Barcode   string `json:"barcode"`  // Alternative to product_id for scanners
+	Quantity  *int   `json:"quantity"` // Defaults to 1 per scan; a pack barcode counts whole packs
+	Mode      string `json:"mode"`     // "add" (default, for scanning) or "set"
+}
+
+type RecordCountsRequest struct {
+	Counts []CountEntry `json:"counts" binding:"required,min=1"`
+}
+
+type ApproveStockTakeRequest struct {
+	ReasonCode string          `json:"reason_code"` // Default for every variance
+	Reasons    map[uint]string `json:"reasons"`     // Per product overrides, keyed by product ID
+}
+
+// StockTakeLine is a count sheet line with the variance approval would post
+type StockTakeLine struct {
+	models.StockTakeItem
+	CurrentStock int  `json:"current_stock"`
+	Variance     *int `json:"variance"` // Counted - stock at count time; nil if not counted
+}
+
+// StockTakeSheet is a stock take under review; Take carries no Items, they are in Lines
+type StockTakeSheet struct {
+	Take         models.StockTake
+	Lines        []StockTakeLine
+	Counted      int
+	WithVariance int
+}
+
+type stockTakeService struct {
+	db *gorm.DB
+}
+
+func NewStockTakeService(db *gorm.DB) StockTakeService {
+	return &stockTakeService{db: db}
+}
+
+func (s *stockTakeService) CreateStockTake(req CreateStockTakeRequest, actor Actor) (models.StockTake, error) {
+	var take models.StockTake
+	var products []models.Product
+	query := s.db.Where("is_active = ? AND has_variants = ?", true, false)
+	if req.CategoryID != nil {
+		subtree, err := categorySubtree(s.db, *req.CategoryID)
+		if err != nil {
+			return take, err
+		}
+		query = query.Where("category_id IN (?)", subtree)
+	}
+	if err := query.Find(&products).Error; err != nil {
+		return take, failed("Failed to fetch products")
+	}
+	if len(products) == 0 {
+		return take, invalid("No active products in scope")
+	}
+
+	storeID, err := RequestStoreID(s.db, req.StoreID, actor)
+	if err != nil {
+		return take, storeError(err)
+	}
+	storeStock := storeQuantities(s.db, storeID)
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		takeNo, err := sequence.Next(tx, sequence.StockTake(), time.Now())
+		if err != nil {
+			return failed("Failed to assign stock take number")
+		}
+
+		take = models.StockTake{
+			TakeNo:     takeNo,
+			StoreID:    &storeID,
+			CategoryID: req.CategoryID,
+			Location:   req.Location,
+			Status:     "OPEN",
+			Notes:      req.Notes,
+			CreatedBy:  actor.UserID,
+		}
+		for _, p := range products {
+			take.Items = append(take.Items, models.StockTakeItem{ProductID: p.ID, SystemQty: storeStock[p.ID]})
+		}
+
+		if err := tx.Create(&take).Error; err != nil {
+			return failed("Failed to create stock take")
+		}
+		return nil
+	})
+	return take, err
+}
+
+func (s *stockTakeService) ListStockTakes(storeID uint, status string) ([]models.StockTake, error) {
+	takes := []models.StockTake{}
+	query := s.db.Preload("Store").Preload("Category").Preload("Creator").Order("created_at desc")
+	if storeID != 0 {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&takes).Error
+	return takes, err
+}
+
+func (s *stockTakeService) GetStockTake(id uint) (StockTakeSheet, error) {
+	var sheet StockTakeSheet
+	take := &sheet.Take
+	if err := s.db.Preload("Store").Preload("Category").Preload("Creator").Preload("Items").Preload("Items.Product").First(take, id).Error; err != nil {
+		return sheet, notFound("Stock take not found")
+	}
+
+	var storeID uint
+	if take.StoreID != nil {
+		storeID = *take.StoreID
+	} else if id, err := ResolveStoreID(s.db, 0); err == nil {
+		storeID = id
+	}
+	storeStock := storeQuantities(s.db, storeID)
+
+	sheet.Lines = make([]StockTakeLine, 0, len(take.Items))
+	for _, item := range take.Items {
+		current := storeStock[item.ProductID]
+		line := StockTakeLine{StockTakeItem: item, CurrentStock: current}
+		if item.CountedQty != nil {
+			sheet.Counted++
+			v := countVariance(item)
+			if take.Status == "APPROVED" {
+				v = item.Adjustment
+			}
+			line.Variance = &v
+			if v != 0 {
+				sheet.WithVariance++
+			}
+		}
+		sheet.Lines = append(sheet.Lines, line)
+	}
+	take.Items = nil
+	return sheet, nil
+}
+
+func (s *stockTakeService) RecordCounts(id uint, req RecordCountsRequest, userID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var take models.StockTake
+		if err := tx.Preload("Items").First(&take, id).Error; err != nil {
+			return notFound("Stock take not found")
+		}
+		if take.Status != "OPEN" {
+			return conflict("Counts can only be entered on an open stock take")
+		}
+
+		var storeID uint
+		if take.StoreID != nil {
+			storeID = *take.StoreID
+		} else if id, err := ResolveStoreID(tx, 0); err == nil {
+			storeID = id
+		}
+		storeStock := storeQuantities(tx, storeID)
+
+		now := time.Now()
+		for _, entry := range req.Counts {
+			// Scans resolve like they do at the till, so a case barcode counts its pack
+			productID, packQty := entry.ProductID, 1
+			if productID == 0 && entry.Barcode != "" {
+				product, qty, err := lookupBarcode(tx, entry.Barcode)
+				if err != nil {
+					return invalid("No product with barcode %q", entry.Barcode)
+				}
+				productID, packQty = product.ID, qty
+			}
+
+			var item *models.StockTakeItem
+			for i := range take.Items {
+				if productID != 0 && take.Items[i].ProductID == productID {
+					item = &take.Items[i]
+					break
+				}
+			}
+			if item == nil {
+				return invalid("Product %d / barcode %q is not on this count sheet", entry.ProductID, entry.Barcode)
+			}
+
+			qty := 1
+			if entry.Quantity != nil {
+				qty = *entry.Quantity
+			}
+			qty *= packQty
+			counted := qty
+			if entry.Mode != "set" && item.CountedQty != nil {
+				counted = *item.CountedQty + qty
+			}
+			if counted < 0 {
+				return invalid("Counted quantity cannot be negative")
+			}
+
+			system := storeStock[item.ProductID]
+			item.CountedQty = &counted
+			item.SystemQtyAtCount = &system
+			item.CountedBy = &userID
+			item.CountedAt = &now
+			if err := tx.Model(item).Updates(map[string]interface{}{
+				"counted_qty":         counted,
+				"system_qty_at_count": system,
+				"counted_by":          userID,
+				"counted_at":          now,
+			}).Error; err != nil {
+				return failed("Failed to record count")
+			}
+		}
+		return nil
+	})
+}
+
+func (s *stockTakeService) SubmitStockTake(id uint) error {
+	res := s.db.Model(&models.StockTake{}).Where("id = ? AND status = ?", id, "OPEN").Update("status", "SUBMITTED")
+	if res.Error != nil {
+		return failed("Failed to submit stock take")
+	}
+	if res.RowsAffected == 0 {
+		return conflict("Stock take not found or not open")
+	}
+	return nil
+}
+
+func (s *stockTakeService) CancelStockTake(id uint) error {
+	res := s.db.Model(&models.StockTake{}).Where("id = ? AND status IN ?", id, []string{"OPEN", "SUBMITTED"}).Update("status", "CANCELLED")
+	if res.Error != nil {
+		return failed("Failed to cancel stock take")
+	}
+	if res.RowsAffected == 0 {
+		return conflict("Stock take not found or already closed")
+	}
+	return nil
+}
+
+// countVariance is what a counted line changes stock by: the count against
+// the stock when it was counted. Lines counted before that was recorded fall
+// back to the stock when the sheet was created.
+func countVariance(item models.StockTakeItem) int {
+	system := item.SystemQty
+	if item.SystemQtyAtCount != nil {
+		system = *item.SystemQtyAtCount
+	}
+	return *item.CountedQty - system
+}
+
+// ApproveStockTake moves every counted product by its variance. The status
+// change is guarded in the UPDATE, so two managers approving at once post the
+// adjustments only once.
+func (s *stockTakeService) ApproveStockTake(id uint, req ApproveStockTakeRequest, userID uint) (int, error) {
+	if req.ReasonCode == "" {
+		req.ReasonCode = "COUNT_ERROR"
+	}
+
+	adjusted := 0
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var take models.StockTake
+		if err := tx.Preload("Items").First(&take, id).Error; err != nil {
+			return notFound("Stock take not found")
+		}
+		res := tx.Model(&models.StockTake{}).Where("id = ? AND status = ?", take.ID, "SUBMITTED").Updates(map[string]interface{}{
+			"status":      "APPROVED",
+			"approved_by": userID,
+			"approved_at": time.Now(),
+		})
+		if res.Error != nil {
+			return failed("Failed to approve stock take")
+		}
+		if res.RowsAffected == 0 {
+			return conflict("Only submitted stock takes can be approved")
+		}
+
+		var storeID uint
+		if take.StoreID != nil {
+			storeID = *take.StoreID
+		}
+
+		for _, item := range take.Items {
+			if item.CountedQty == nil {
+				continue
+			}
+			reason := req.ReasonCode
+			if r, ok := req.Reasons[item.ProductID]; ok {
+				reason = r
+			}
+			if !models.IsValidAdjustmentReason(reason) {
+				return invalid("Invalid reason code %q", reason).with(map[string]interface{}{"allowed": models.AdjustmentReasons})
+			}
+
+			variance := countVariance(item)
+			if variance != 0 {
+				if _, err := MoveStock(tx, StockChange{
+					ProductID: item.ProductID,
+					Quantity:  variance,
+					StoreID:   storeID,
+					Type:      models.MovementTypeForReason(reason),
+					RefType:   "STOCK_TAKE",
+					RefID:     &take.ID,
+					RefNo:     take.TakeNo,
+					Note:      reason,
+					UserID:    userID,
+				}); err != nil {
+					return stockError(err)
+				}
+				adjusted++
+			}
+			if err := tx.Model(&models.StockTakeItem{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
+				"adjustment":  variance,
+				"reason_code": reason,
+			}).Error; err != nil {
+				return failed("Failed to update stock take")
+			}
+		}
+		return nil
+	})
+	return adjusted, err
+}
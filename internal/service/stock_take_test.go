@@ -0,0 +1,128 @@
+package service_test
+
+import (
+	"testing"
+
+	"billing-app/internal/models"
+	"billing-app/internal/service"
+	"billing-app/internal/service/servicetest"
+)
+
+// A sale between counting and approval must survive approval: only the
+// variance found by the count is posted.
+func TestApproveStockTakePostsVarianceAtCountTime(t *testing.T) {
+	env, err := servicetest.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+
+	product, err := env.Product("Counted", 50, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := service.Actor{UserID: env.Admin.ID, Role: "admin"}
+
+	take, err := env.StockTakes.CreateStockTake(service.CreateStockTakeRequest{StoreID: &env.Store.ID}, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counted := 8 // Two missing
+	if err := env.StockTakes.RecordCounts(take.ID, service.RecordCountsRequest{
+		Counts: []service.CountEntry{{ProductID: product.ID, Quantity: &counted, Mode: "set"}},
+	}, env.Admin.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := env.Billing.CreateBill(service.CreateBillRequest{
+		PaymentMode: "CASH",
+		Items:       []service.BillItemRequest{{ProductID: product.ID, Quantity: 3}},
+	}, env.Admin.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.StockTakes.SubmitStockTake(take.ID); err != nil {
+		t.Fatal(err)
+	}
+	adjusted, err := env.StockTakes.ApproveStockTake(take.ID, service.ApproveStockTakeRequest{ReasonCode: "THEFT"}, env.Admin.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adjusted != 1 {
+		t.Errorf("adjusted %d products, want 1", adjusted)
+	}
+
+	if err := env.DB.First(&product, product.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if want := 10 - 3 - 2; product.CurrentStock != want {
+		t.Errorf("current_stock = %d, want %d", product.CurrentStock, want)
+	}
+
+	// A second approval is refused and posts nothing
+	if _, err := env.StockTakes.ApproveStockTake(take.ID, service.ApproveStockTakeRequest{}, env.Admin.ID); err == nil {
+		t.Error("second approval succeeded")
+	}
+	var movements int64
+	env.DB.Model(&models.StockMovement{}).Where("product_id = ? AND ref_type = ?", product.ID, "STOCK_TAKE").Count(&movements)
+	if movements != 1 {
+		t.Errorf("%d stock take movements, want 1", movements)
+	}
+}
+
+// Scanning counts like the till does: the main barcode is one unit, a case
+// barcode is its whole pack
+func TestRecordCountsByBarcode(t *testing.T) {
+	env, err := servicetest.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+
+	product, err := env.Product("Biscuits", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assigned, err := env.Catalog.GenerateBarcodes(service.GenerateBarcodesRequest{ProductIDs: []uint{product.ID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.Catalog.AddBarcode(product.ID, service.AddBarcodeRequest{Code: "CASE-BISCUITS", PackQty: 12}); err != nil {
+		t.Fatal(err)
+	}
+	admin := service.Actor{UserID: env.Admin.ID, Role: "admin"}
+	take, err := env.StockTakes.CreateStockTake(service.CreateStockTakeRequest{StoreID: &env.Store.ID}, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quantity := func(n int) *int { return &n }
+	tests := []struct {
+		name        string
+		entry       service.CountEntry
+		wantKind    service.Kind
+		wantCounted int
+	}{
+		{"unit scan", service.CountEntry{Barcode: assigned[product.ID]}, 0, 1},
+		{"case scan", service.CountEntry{Barcode: "CASE-BISCUITS"}, 0, 13},
+		{"two cases", service.CountEntry{Barcode: "CASE-BISCUITS", Quantity: quantity(2)}, 0, 37},
+		{"set in cases", service.CountEntry{Barcode: "CASE-BISCUITS", Quantity: quantity(3), Mode: "set"}, 0, 36},
+		{"set in units", service.CountEntry{ProductID: product.ID, Quantity: quantity(30), Mode: "set"}, 0, 30},
+		{"unknown barcode", service.CountEntry{Barcode: "NOT-A-CODE"}, service.KindInvalid, 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := env.StockTakes.RecordCounts(take.ID, service.RecordCountsRequest{Counts: []service.CountEntry{tt.entry}}, env.Admin.ID)
+			if errorKind(err) != tt.wantKind {
+				t.Fatalf("RecordCounts: %v, want kind %d", err, tt.wantKind)
+			}
+			var item models.StockTakeItem
+			if err := env.DB.Where("stock_take_id = ? AND product_id = ?", take.ID, product.ID).First(&item).Error; err != nil {
+				t.Fatal(err)
+			}
+			if item.CountedQty == nil || *item.CountedQty != tt.wantCounted {
+				t.Errorf("counted %v, want %d", item.CountedQty, tt.wantCounted)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"billing-app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNoDefaultStore = errors.New("no default store is configured")
+	ErrStoreInactive  = errors.New("your store is inactive; ask a manager to assign you to another")
+	ErrOtherStore     = errors.New("you can only work with your own store")
+)
+
+// storeError reports why the store for a request could not be resolved
+func storeError(err error) error {
+	switch {
+	case errors.Is(err, ErrOtherStore):
+		return newError(KindForbidden, "%s", err.Error())
+	case errors.Is(err, ErrStoreInactive):
+		return conflict("%s", err.Error())
+	}
+	return invalid("%s", err.Error())
+}
+
+// ResolveStoreID returns storeID if it names an active store, or the default store when it is 0
+func ResolveStoreID(db *gorm.DB, storeID uint) (uint, error) {
+	var store models.Store
+	if storeID == 0 {
+		if err := db.Select("id").Where("is_default = ?", true).First(&store).Error; err != nil {
+			return 0, ErrNoDefaultStore
+		}
+		return store.ID, nil
+	}
+	if err := db.Select("id").Where("id = ? AND is_active = ?", storeID, true).First(&store).Error; err != nil {
+		return 0, fmt.Errorf("Store ID %d not found", storeID)
+	}
+	return store.ID, nil
+}
+
+// UserStoreID is the store the user works at; users not assigned to one work
+// at the default store. A deactivated store is an error rather than a silent
+// switch to the default store, which would book their work to the wrong place.
+func UserStoreID(db *gorm.DB, userID uint) (uint, error) {
+	var user models.User
+	db.Select("id", "store_id").First(&user, userID)
+	if user.StoreID == nil {
+		return ResolveStoreID(db, 0)
+	}
+	id, err := ResolveStoreID(db, *user.StoreID)
+	if err != nil {
+		return 0, ErrStoreInactive
+	}
+	return id, nil
+}
+
+// RequestStoreID is the store named in a request, or the user's own store.
+// Only admins and managers may name a store other than their own.
+func RequestStoreID(db *gorm.DB, storeID *uint, actor Actor) (uint, error) {
+	own, ownErr := UserStoreID(db, actor.UserID)
+	if storeID == nil {
+		return own, ownErr
+	}
+	id, err := ResolveStoreID(db, *storeID)
+	if err != nil {
+		return 0, err
+	}
+	if !actor.IsManager() && (ownErr != nil || id != own) {
+		return 0, ErrOtherStore
+	}
+	return id, nil
+}
+
+// billingStore loads the store the user bills from
+func billingStore(db *gorm.DB, userID uint) (models.Store, error) {
+	var store models.Store
+	storeID, err := UserStoreID(db, userID)
+	if err != nil {
+		return store, err
+	}
+	err = db.First(&store, storeID).Error
+	return store, err
+}
+
+// OpenShiftID returns the user's open shift, if any
+func OpenShiftID(db *gorm.DB, userID uint) *uint {
+	var shift models.Shift
+	if err := db.Where("user_id = ? AND status = ?", userID, "OPEN").Order("id desc").First(&shift).Error; err != nil {
+		return nil
+	}
+	return &shift.ID
+}
+
+// storeQuantities maps product ID to its stock at the store
+func storeQuantities(db *gorm.DB, storeID uint) map[uint]int {
+	var rows []models.StoreStock
+	db.Select("product_id", "quantity").Where("store_id = ?", storeID).Find(&rows)
+	quantities := make(map[uint]int, len(rows))
+	for _, r := range rows {
+		quantities[r.ProductID] = r.Quantity
+	}
+	return quantities
+}
+
+// StoreService manages the stores and warehouses stock is held at
+type StoreService interface {
+	CreateStore(req StoreRequest) (models.Store, error)
+	UpdateStore(id uint, req StoreRequest) error
+	// ListStores returns active stores, default first; all includes inactive ones
+	ListStores(all bool) ([]models.Store, error)
+	// StoreStock lists stock per store; zero productID or storeID match all
+	StoreStock(productID, storeID uint, includeZero bool) ([]models.StoreStock, error)
+}
+
+type StoreRequest struct {
+	Code      string `json:"code" binding:"required"`
+	Name      string `json:"name" binding:"required"`
+	Type      string `json:"type" binding:"omitempty,oneof=STORE WAREHOUSE"`
+	Address   string `json:"address"`
+	Phone     string `json:"phone"`
+	GSTIN     string `json:"gstin"`
+	State     string `json:"state"`
+	IsDefault bool   `json:"is_default"`
+	IsActive  *bool  `json:"is_active"`
+}
+
+type storeService struct {
+	db *gorm.DB
+}
+
+func NewStoreService(db *gorm.DB) StoreService {
+	return &storeService{db: db}
+}
+
+func (s *storeService) CreateStore(req StoreRequest) (models.Store, error) {
+	if req.Type == "" {
+		req.Type = models.StoreTypeStore
+	}
+
+	store := models.Store{
+		Code:     strings.ToUpper(strings.TrimSpace(req.Code)),
+		Name:     req.Name,
+		Type:     req.Type,
+		Address:  req.Address,
+		Phone:    req.Phone,
+		GSTIN:    req.GSTIN,
+		State:    req.State,
+		IsActive: true,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&store).Error; err != nil {
+			return failed("Failed to create store (Code might be duplicate)")
+		}
+		if req.IsDefault {
+			if err := makeDefaultStore(tx, store.ID); err != nil {
+				return failed("Failed to set default store")
+			}
+			store.IsDefault = true
+		}
+		return nil
+	})
+	return store, err
+}
+
+func makeDefaultStore(tx *gorm.DB, storeID uint) error {
+	if err := tx.Model(&models.Store{}).Where("is_default = ? AND id <> ?", true, storeID).Update("is_default", false).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Store{}).Where("id = ?", storeID).Updates(map[string]interface{}{"is_default": true, "is_active": true}).Error
+}
+
+func (s *storeService) UpdateStore(id uint, req StoreRequest) error {
+	var store models.Store
+	if err := s.db.First(&store, id).Error; err != nil {
+		return notFound("Store not found")
+	}
+
+	updates := map[string]interface{}{
+		"code":    strings.ToUpper(strings.TrimSpace(req.Code)),
+		"name":    req.Name,
+		"address": req.Address,
+		"phone":   req.Phone,
+		"gstin":   req.GSTIN,
+		"state":   req.State,
+	}
+	if req.Type != "" {
+		updates["type"] = req.Type
+	}
+	if req.IsActive != nil {
+		if !*req.IsActive && store.IsDefault {
+			return invalid("The default store cannot be deactivated")
+		}
+		updates["is_active"] = *req.IsActive
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&store).Updates(updates).Error; err != nil {
+			return failed("Failed to update store")
+		}
+		if req.IsDefault && !store.IsDefault {
+			if err := makeDefaultStore(tx, store.ID); err != nil {
+				return failed("Failed to set default store")
+			}
+		}
+		return nil
+	})
+}
+
+func (s *storeService) ListStores(all bool) ([]models.Store, error) {
+	stores := []models.Store{}
+	query := s.db.Order("is_default desc, name")
+	if !all {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Find(&stores).Error
+	return stores, err
+}
+
+func (s *storeService) StoreStock(productID, storeID uint, includeZero bool) ([]models.StoreStock, error) {
+	rows := []models.StoreStock{}
+	query := s.db.Preload("Store").Preload("Product").Order("product_id, store_id")
+	if productID != 0 {
+		query = query.Where("product_id = ?", productID)
+	}
+	if storeID != 0 {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if !includeZero {
+		query = query.Where("quantity <> 0")
+	}
+	err := query.Find(&rows).Error
+	return rows, err
+}
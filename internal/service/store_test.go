@@ -0,0 +1,78 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"billing-app/internal/models"
+	"billing-app/internal/service"
+	"billing-app/internal/service/servicetest"
+)
+
+func TestRequestStoreID(t *testing.T) {
+	env, err := servicetest.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+
+	branch := models.Store{Code: "BR2", Name: "Branch", State: "Tamil Nadu", IsActive: true}
+	if err := env.DB.Create(&branch).Error; err != nil {
+		t.Fatal(err)
+	}
+	main := env.Store.ID
+	unassigned := models.User{EmployeeID: "B900", Username: "Floater", PasswordHash: "x", RoleID: env.Roles["biller"].ID}
+	if err := env.DB.Create(&unassigned).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		role    string
+		userID  uint
+		storeID *uint
+		want    uint
+		err     error
+	}{
+		{"biller defaults to own store", "biller", env.Admin.ID, nil, main, nil},
+		{"biller names own store", "biller", env.Admin.ID, &main, main, nil},
+		{"biller names another store", "biller", env.Admin.ID, &branch.ID, 0, service.ErrOtherStore},
+		{"inventory names another store", "inventory", env.Admin.ID, &branch.ID, 0, service.ErrOtherStore},
+		{"unassigned biller names the default store", "biller", unassigned.ID, &main, main, nil},
+		{"unassigned biller names another store", "biller", unassigned.ID, &branch.ID, 0, service.ErrOtherStore},
+		{"manager names another store", "manager", env.Admin.ID, &branch.ID, branch.ID, nil},
+		{"admin names another store", "admin", env.Admin.ID, &branch.ID, branch.ID, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.RequestStoreID(env.DB, tt.storeID, service.Actor{UserID: tt.userID, Role: tt.role})
+			if !errors.Is(err, tt.err) || got != tt.want {
+				t.Errorf("RequestStoreID = %d, %v; want %d, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+// A user whose store was closed must be reassigned, not quietly moved to the
+// default store
+func TestUserStoreIDInactiveStore(t *testing.T) {
+	env, err := servicetest.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+
+	closed := models.Store{Code: "OLD", Name: "Closed Branch", IsActive: true}
+	if err := env.DB.Create(&closed).Error; err != nil {
+		t.Fatal(err)
+	}
+	env.DB.Model(&closed).Update("is_active", false)
+	env.DB.Model(&models.User{}).Where("id = ?", env.Admin.ID).Update("store_id", closed.ID)
+
+	if _, err := service.UserStoreID(env.DB, env.Admin.ID); !errors.Is(err, service.ErrStoreInactive) {
+		t.Errorf("UserStoreID: %v, want ErrStoreInactive", err)
+	}
+	if _, _, err := env.Billing.Quote(service.CreateBillRequest{PaymentMode: "CASH"}, env.Admin.ID); errorKind(err) != service.KindConflict {
+		t.Errorf("Quote: %v, want a conflict", err)
+	}
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"billing-app/internal/models"
+	"billing-app/internal/sequence"
+
+	"gorm.io/gorm"
+)
+
+// TransferService moves stock between stores. Stock leaves the source on
+// dispatch and stays IN_TRANSIT until the destination receives it.
+type TransferService interface {
+	// CreateTransfer dispatches stock from one store to another
+	CreateTransfer(req CreateTransferRequest, actor Actor) (models.StockTransfer, error)
+	// ListTransfers filters by status and by a store at either end; zero values match all
+	ListTransfers(status string, storeID uint) ([]models.StockTransfer, error)
+	GetTransfer(id uint) (models.StockTransfer, error)
+	// ReceiveTransfer books the transfer into the destination store and
+	// returns the quantity received short. The shortfall stays recorded on
+	// the line as lost in transit. Only the destination's staff may receive.
+	ReceiveTransfer(id uint, req ReceiveTransferRequest, actor Actor) (int, error)
+	// CancelTransfer returns an in-transit transfer to its source store.
+	// Only the source's staff may cancel.
+	CancelTransfer(id uint, actor Actor) error
+}
+
+type TransferItemRequest struct {
+	ProductID uint  `json:"product_id" binding:"required"`
+	Quantity  int   `json:"quantity" binding:"required,gt=0"`
+	BatchID   *uint `json:"batch_id"` // Batch-tracked products; defaults to FEFO
+}
+
+type CreateTransferRequest struct {
+	FromStoreID *uint                 `json:"from_store_id"` // Defaults to the user's store
+	ToStoreID   uint                  `json:"to_store_id" binding:"required"`
+	Notes       string                `json:"notes"`
+	Items       []TransferItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type ReceiveTransferRequest struct {
+	Items []struct {
+		ItemID      uint `json:"item_id" binding:"required"`
+		ReceivedQty int  `json:"received_qty" binding:"gte=0"`
+	} `json:"items"` // Omitted lines are received in full
+}
+
+type transferService struct {
+	db *gorm.DB
+}
+
+func NewTransferService(db *gorm.DB) TransferService {
+	return &transferService{db: db}
+}
+
+func (s *transferService) CreateTransfer(req CreateTransferRequest, actor Actor) (models.StockTransfer, error) {
+	var transfer models.StockTransfer
+	fromID, err := RequestStoreID(s.db, req.FromStoreID, actor)
+	if err != nil {
+		return transfer, storeError(err)
+	}
+	toID, err := ResolveStoreID(s.db, req.ToStoreID)
+	if err != nil {
+		return transfer, invalid("%s", err.Error())
+	}
+	if fromID == toID {
+		return transfer, invalid("Source and destination stores must differ")
+	}
+
+	userID := actor.UserID
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		transferNo, err := sequence.Next(tx, sequence.Transfer(), now)
+		if err != nil {
+			return failed("Failed to assign transfer number")
+		}
+
+		transfer = models.StockTransfer{
+			TransferNo:   transferNo,
+			FromStoreID:  fromID,
+			ToStoreID:    toID,
+			Status:       models.TransferStatusInTransit,
+			Notes:        req.Notes,
+			CreatedBy:    userID,
+			DispatchedAt: now,
+		}
+		if err := tx.Create(&transfer).Error; err != nil {
+			return failed("Failed to create transfer")
+		}
+
+		// Product ID order keeps row locks consistent with billing
+		sort.Slice(req.Items, func(i, j int) bool { return req.Items[i].ProductID < req.Items[j].ProductID })
+
+		var items []models.StockTransferItem
+		for _, r := range req.Items {
+			movement, err := MoveStock(tx, StockChange{
+				ProductID: r.ProductID,
+				Quantity:  -r.Quantity,
+				Type:      models.MovementTransfer,
+				RefType:   "TRANSFER",
+				RefID:     &transfer.ID,
+				RefNo:     transferNo,
+				Note:      "Dispatched",
+				UserID:    userID,
+				StoreID:   fromID,
+				BatchID:   r.BatchID,
+			})
+			if err != nil {
+				return movementError(err)
+			}
+
+			if len(movement.Batches) == 0 {
+				items = append(items, models.StockTransferItem{StockTransferID: transfer.ID, ProductID: r.ProductID, Quantity: r.Quantity})
+				continue
+			}
+			// Batch-tracked stock travels as its batches, so expiry follows it to the destination
+			for _, a := range movement.Batches {
+				var batch models.StockBatch
+				tx.First(&batch, a.BatchID)
+				items = append(items, models.StockTransferItem{
+					StockTransferID: transfer.ID,
+					ProductID:       r.ProductID,
+					Quantity:        -a.Quantity,
+					BatchNo:         batch.BatchNo,
+					MfgDate:         batch.MfgDate,
+					ExpiryDate:      batch.ExpiryDate,
+					CostPrice:       batch.CostPrice,
+				})
+			}
+		}
+
+		if err := tx.Create(&items).Error; err != nil {
+			return failed("Failed to create transfer")
+		}
+		transfer.Items = items
+		return nil
+	})
+	return transfer, err
+}
+
+func (s *transferService) ListTransfers(status string, storeID uint) ([]models.StockTransfer, error) {
+	transfers := []models.StockTransfer{}
+	query := s.db.Preload("FromStore").Preload("ToStore").Preload("Creator").Order("dispatched_at desc")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if storeID != 0 {
+		query = query.Where("from_store_id = ? OR to_store_id = ?", storeID, storeID)
+	}
+	err := query.Find(&transfers).Error
+	return transfers, err
+}
+
+func (s *transferService) GetTransfer(id uint) (models.StockTransfer, error) {
+	var transfer models.StockTransfer
+	if err := s.db.Preload("FromStore").Preload("ToStore").Preload("Creator").Preload("Items").Preload("Items.Product").
+		First(&transfer, id).Error; err != nil {
+		return transfer, notFound("Transfer not found")
+	}
+	return transfer, nil
+}
+
+func (s *transferService) ReceiveTransfer(id uint, req ReceiveTransferRequest, actor Actor) (int, error) {
+	received := map[uint]int{}
+	for _, r := range req.Items {
+		received[r.ItemID] = r.ReceivedQty
+	}
+
+	short := 0
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		transfer, err := closeTransfer(tx, id, actor, func(t models.StockTransfer) uint { return t.ToStoreID }, map[string]interface{}{
+			"status":      models.TransferStatusReceived,
+			"received_by": actor.UserID,
+			"received_at": time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, item := range transfer.Items {
+			qty, ok := received[item.ID]
+			if !ok {
+				qty = item.Quantity
+			}
+			if qty > item.Quantity {
+				return invalid("Item %d: received %d exceeds the %d dispatched", item.ID, qty, item.Quantity)
+			}
+			short += item.Quantity - qty
+
+			if err := tx.Model(&models.StockTransferItem{}).Where("id = ?", item.ID).Update("received_qty", qty).Error; err != nil {
+				return failed("Failed to receive transfer")
+			}
+			if qty == 0 {
+				continue
+			}
+			if _, err := MoveStock(tx, transferLeg(transfer, item, qty, transfer.ToStoreID, "Received", actor.UserID)); err != nil {
+				return invalid("%s", err.Error())
+			}
+		}
+		return nil
+	})
+	return short, err
+}
+
+func (s *transferService) CancelTransfer(id uint, actor Actor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		transfer, err := closeTransfer(tx, id, actor, func(t models.StockTransfer) uint { return t.FromStoreID }, map[string]interface{}{
+			"status": models.TransferStatusCancelled,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, item := range transfer.Items {
+			if _, err := MoveStock(tx, transferLeg(transfer, item, item.Quantity, transfer.FromStoreID, "Cancelled, returned to source", actor.UserID)); err != nil {
+				return invalid("%s", err.Error())
+			}
+		}
+		return nil
+	})
+}
+
+// closeTransfer moves an IN_TRANSIT transfer to its final status on behalf of
+// the store end picks, whose staff alone may do it unless actor is a manager.
+// The conditional update stops a transfer being received or cancelled twice.
+func closeTransfer(tx *gorm.DB, id uint, actor Actor, end func(models.StockTransfer) uint, updates map[string]interface{}) (models.StockTransfer, error) {
+	var transfer models.StockTransfer
+	if err := tx.Preload("Items").First(&transfer, id).Error; err != nil {
+		return transfer, notFound("Transfer not found")
+	}
+	storeID := end(transfer)
+	if _, err := RequestStoreID(tx, &storeID, actor); err != nil {
+		return transfer, storeError(err)
+	}
+	res := tx.Model(&models.StockTransfer{}).Where("id = ? AND status = ?", transfer.ID, models.TransferStatusInTransit).Updates(updates)
+	if res.Error != nil {
+		return transfer, failed("Failed to update transfer")
+	}
+	if res.RowsAffected == 0 {
+		return transfer, conflict("transfer is no longer in transit")
+	}
+	return transfer, nil
+}
+
+// transferLeg is the stock change that puts a transfer line into storeID
+func transferLeg(transfer models.StockTransfer, item models.StockTransferItem, qty int, storeID uint, note string, userID uint) StockChange {
+	change := StockChange{
+		ProductID: item.ProductID,
+		Quantity:  qty,
+		Type:      models.MovementTransfer,
+		RefType:   "TRANSFER",
+		RefID:     &transfer.ID,
+		RefNo:     transfer.TransferNo,
+		Note:      note,
+		UserID:    userID,
+		StoreID:   storeID,
+	}
+	if item.BatchNo != "" {
+		change.Batch = &models.StockBatch{BatchNo: item.BatchNo, MfgDate: item.MfgDate, ExpiryDate: item.ExpiryDate, CostPrice: item.CostPrice}
+	}
+	return change
+}
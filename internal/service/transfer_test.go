@@ -0,0 +1,80 @@
+package service_test
+
+import (
+	"testing"
+
+	"billing-app/internal/models"
+	"billing-app/internal/service"
+	"billing-app/internal/service/servicetest"
+)
+
+// Only the receiving store's staff book a transfer in, and only the sending
+// store's staff call it back; managers and admins act for any store
+func TestCloseTransferStoreScope(t *testing.T) {
+	env, err := servicetest.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+
+	branch := models.Store{Code: "BR2", Name: "Branch", IsActive: true}
+	if err := env.DB.Create(&branch).Error; err != nil {
+		t.Fatal(err)
+	}
+	staff := func(employeeID string, storeID uint) uint {
+		user := models.User{EmployeeID: employeeID, Username: employeeID, PasswordHash: "x", RoleID: env.Roles["inventory"].ID, StoreID: &storeID, IsActive: true}
+		if err := env.DB.Create(&user).Error; err != nil {
+			t.Fatal(err)
+		}
+		return user.ID
+	}
+	mainStaff := staff("I901", env.Store.ID)
+	branchStaff := staff("I902", branch.ID)
+
+	product, err := env.Product("Rice", 60, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		cancel     bool
+		actor      service.Actor
+		wantKind   service.Kind
+		wantStatus string
+	}{
+		{"source staff receive", false, service.Actor{UserID: mainStaff, Role: "inventory"}, service.KindForbidden, models.TransferStatusInTransit},
+		{"destination staff receive", false, service.Actor{UserID: branchStaff, Role: "inventory"}, 0, models.TransferStatusReceived},
+		{"destination staff cancel", true, service.Actor{UserID: branchStaff, Role: "inventory"}, service.KindForbidden, models.TransferStatusInTransit},
+		{"source staff cancel", true, service.Actor{UserID: mainStaff, Role: "inventory"}, 0, models.TransferStatusCancelled},
+		{"manager from the source receives", false, service.Actor{UserID: mainStaff, Role: "manager"}, 0, models.TransferStatusReceived},
+		{"admin from the destination cancels", true, service.Actor{UserID: branchStaff, Role: "admin"}, 0, models.TransferStatusCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transfer, err := env.Transfers.CreateTransfer(service.CreateTransferRequest{
+				ToStoreID: branch.ID,
+				Items:     []service.TransferItemRequest{{ProductID: product.ID, Quantity: 5}},
+			}, service.Actor{UserID: mainStaff, Role: "inventory"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.cancel {
+				err = env.Transfers.CancelTransfer(transfer.ID, tt.actor)
+			} else {
+				_, err = env.Transfers.ReceiveTransfer(transfer.ID, service.ReceiveTransferRequest{}, tt.actor)
+			}
+			if errorKind(err) != tt.wantKind {
+				t.Errorf("got %v, want kind %d", err, tt.wantKind)
+			}
+
+			if err := env.DB.First(&transfer, transfer.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if transfer.Status != tt.wantStatus {
+				t.Errorf("status %s, want %s", transfer.Status, tt.wantStatus)
+			}
+		})
+	}
+}
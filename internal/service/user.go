@@ -0,0 +1,212 @@
+package service
+
+import (
+	"fmt"
+
+	"billing-app/config"
+	"billing-app/internal/models"
+	"billing-app/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// UserService signs employees in and manages their accounts
+type UserService interface {
+	// Login checks the credentials and returns the user with a signed token.
+	// The attempt is recorded in the login history from ip.
+	Login(employeeID, password, ip string) (models.User, string, error)
+	SetPassword(userID uint, password string) error
+
+	// CreateEmployee assigns the next employee ID for the role, e.g. BIL004
+	CreateEmployee(req CreateEmployeeRequest) (models.User, error)
+	ListEmployees() ([]models.User, error)
+	UpdateEmployee(id uint, req UpdateEmployeeRequest) error
+	UpdateRole(id, roleID uint) error
+	UpdateStatus(id uint, active bool, reason string) error
+	// UpdateStore assigns the store an employee bills and receives stock at;
+	// nil moves them to the default store
+	UpdateStore(id uint, storeID *uint) error
+
+	LoginHistory(limit int) ([]models.LoginHistory, error)
+	Stats() (UserStats, error)
+}
+
+type CreateEmployeeRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	RoleID   uint   `json:"role_id" binding:"required"`
+	Mobile   string `json:"mobile"`
+	StoreID  *uint  `json:"store_id"` // Nil works at the default store
+}
+
+type UpdateEmployeeRequest struct {
+	Username string `json:"username" binding:"required"`
+	Mobile   string `json:"mobile"`
+	RoleID   uint   `json:"role_id"`
+}
+
+// RoleCount is the number of employees holding a role
+type RoleCount struct {
+	Name  string
+	Count int
+}
+
+type UserStats struct {
+	TotalEmployees   int64       `json:"total_employees"`
+	ActiveUsers      int64       `json:"active_users"`
+	RoleDistribution []RoleCount `json:"role_distribution"`
+}
+
+type userService struct {
+	db *gorm.DB
+}
+
+func NewUserService(db *gorm.DB) UserService {
+	return &userService{db: db}
+}
+
+func (s *userService) Login(employeeID, password, ip string) (models.User, string, error) {
+	var user models.User
+	if err := s.db.Preload("Role").Where("employee_id = ?", employeeID).First(&user).Error; err != nil {
+		return user, "", newError(KindUnauthorized, "Invalid credentials")
+	}
+
+	if !user.IsActive {
+		return user, "", newError(KindForbidden, "User is inactive")
+	}
+
+	if !utils.CheckPasswordHash(password, user.PasswordHash) {
+		return user, "", newError(KindUnauthorized, "Invalid credentials")
+	}
+
+	token, err := utils.GenerateToken(user.ID, user.Role.Name)
+	if err != nil {
+		return user, "", failed("Failed to generate token")
+	}
+
+	// Update Login History (Async in production, but sync here for simplicity)
+	go func(uid uint, ip string) {
+		s.db.Create(&models.LoginHistory{
+			UserID:    uid,
+			IPAddress: ip,
+		})
+	}(user.ID, ip)
+
+	return user, token, nil
+}
+
+func (s *userService) SetPassword(userID uint, password string) error {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return failed("Failed to hash password")
+	}
+	return s.db.Model(&models.User{}).Where("id = ?", userID).Update("password_hash", hashedPassword).Error
+}
+
+func (s *userService) CreateEmployee(req CreateEmployeeRequest) (models.User, error) {
+	if req.StoreID != nil {
+		if _, err := ResolveStoreID(s.db, *req.StoreID); err != nil {
+			return models.User{}, invalid("%s", err.Error())
+		}
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return models.User{}, failed("Failed to hash password")
+	}
+
+	user := models.User{
+		Username:     req.Username,
+		PasswordHash: hashedPassword,
+		RoleID:       req.RoleID,
+		EmployeeID:   s.generateEmployeeID(req.RoleID),
+		Mobile:       req.Mobile,
+		StoreID:      req.StoreID,
+		IsActive:     true,
+	}
+
+	if err := s.db.Create(&user).Error; err != nil {
+		return user, failed("Failed to create user")
+	}
+	return user, nil
+}
+
+func (s *userService) ListEmployees() ([]models.User, error) {
+	var users []models.User
+	err := s.db.Preload("Role").Preload("Store").Find(&users).Error
+	return users, err
+}
+
+func (s *userService) UpdateEmployee(id uint, req UpdateEmployeeRequest) error {
+	return s.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"username": req.Username,
+		"mobile":   req.Mobile,
+		"role_id":  req.RoleID,
+	}).Error
+}
+
+func (s *userService) UpdateRole(id, roleID uint) error {
+	return s.db.Model(&models.User{}).Where("id = ?", id).Update("role_id", roleID).Error
+}
+
+func (s *userService) UpdateStatus(id uint, active bool, reason string) error {
+	return s.db.Model(&models.User{}).Where("id = ?", id).Updates(models.User{IsActive: active, InactiveReason: reason}).Error
+}
+
+func (s *userService) UpdateStore(id uint, storeID *uint) error {
+	if storeID != nil {
+		if _, err := ResolveStoreID(s.db, *storeID); err != nil {
+			return invalid("%s", err.Error())
+		}
+	}
+	return s.db.Model(&models.User{}).Where("id = ?", id).Update("store_id", storeID).Error
+}
+
+func (s *userService) LoginHistory(limit int) ([]models.LoginHistory, error) {
+	var history []models.LoginHistory
+	err := s.db.Preload("User").Preload("User.Role").Order("login_time desc").Limit(limit).Find(&history).Error
+	return history, err
+}
+
+func (s *userService) Stats() (UserStats, error) {
+	var stats UserStats
+	s.db.Model(&models.User{}).Count(&stats.TotalEmployees)
+	s.db.Model(&models.User{}).Where("is_active = ?", true).Count(&stats.ActiveUsers)
+
+	// Role Distribution
+	err := s.db.Model(&models.User{}).Select("roles.name, count(users.id) as count").Joins("left join roles on roles.id = users.role_id").Group("roles.name").Scan(&stats.RoleDistribution).Error
+	return stats, err
+}
+
+func (s *userService) generateEmployeeID(roleID uint) string {
+	var prefix string
+	switch roleID {
+	case 1:
+		prefix = config.AppConfig.Defaults.AdminEmployeeID // Fallback/Config logic needs review if this is a full ID
+		if len(prefix) > 3 {
+			prefix = prefix[:3]
+		} else {
+			prefix = "ADM"
+		}
+	case 2:
+		prefix = config.AppConfig.Defaults.ManagerPrefix
+	case 3:
+		prefix = config.AppConfig.Defaults.InventoryPrefix
+	case 4:
+		prefix = config.AppConfig.Defaults.BillerPrefix
+	default:
+		prefix = "EMP"
+	}
+
+	var lastUser models.User
+	// Find last user with this prefix to increment.
+	// Note: This is a simple implementation. In high concurrency, use a sequence or localized lock.
+	if err := s.db.Where("employee_id LIKE ?", prefix+"%").Order("id desc").First(&lastUser).Error; err != nil {
+		return fmt.Sprintf("%s001", prefix)
+	}
+
+	// Extract number
+	var lastID int
+	fmt.Sscanf(lastUser.EmployeeID, prefix+"%d", &lastID)
+	return fmt.Sprintf("%s%03d", prefix, lastID+1)
+}
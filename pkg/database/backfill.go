@@ -0,0 +1,205 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"billing-app/config"
+	"billing-app/internal/models"
+	"billing-app/internal/sequence"
+
+	"gorm.io/gorm"
+)
+
+// The backfills bring data from before a feature up to date. They run once,
+// as versioned migrations, and write through table names and column maps
+// rather than the models, so later model changes cannot break them.
+
+// backfillUserID is the seeded admin, who backfilled rows are attributed to;
+// 0 on a fresh database, which has nothing to backfill
+func backfillUserID(tx *gorm.DB) uint {
+	var id uint
+	tx.Table("users").Select("id").Where("employee_id = ?", config.AppConfig.Defaults.AdminEmployeeID).Limit(1).Scan(&id)
+	return id
+}
+
+// backfillStockLedger gives products that predate the stock ledger an OPENING
+// movement for their current stock, so the ledger sum matches CurrentStock
+func backfillStockLedger(tx *gorm.DB) error {
+	var products []struct {
+		ID           uint
+		CurrentStock int
+	}
+	if err := tx.Table("products").Select("id, current_stock").
+		Where("deleted_at IS NULL AND current_stock <> 0 AND NOT EXISTS (SELECT 1 FROM stock_movements WHERE stock_movements.product_id = products.id)").
+		Scan(&products).Error; err != nil {
+		return err
+	}
+
+	userID := backfillUserID(tx)
+	now := time.Now()
+	for _, p := range products {
+		if err := tx.Table("stock_movements").Create(map[string]interface{}{
+			"product_id":    p.ID,
+			"type":          models.MovementOpening,
+			"quantity":      p.CurrentStock,
+			"balance_after": p.CurrentStock,
+			"note":          "Ledger backfill",
+			"user_id":       userID,
+			"created_at":    now,
+		}).Error; err != nil {
+			return fmt.Errorf("backfill stock ledger for product %d: %w", p.ID, err)
+		}
+	}
+	if len(products) > 0 {
+		log.Printf("Backfilled stock ledger for %d products.", len(products))
+	}
+	return nil
+}
+
+// ensureDefaultStore creates the default store from the configured company
+// details unless there is one, and returns its ID
+func ensureDefaultStore(tx *gorm.DB) (uint, error) {
+	var id uint
+	if err := tx.Table("stores").Select("id").Where("is_default = ?", true).Limit(1).Scan(&id).Error; err != nil {
+		return 0, err
+	}
+	if id != 0 {
+		return id, nil
+	}
+
+	name := config.AppConfig.Defaults.CompanyName
+	if name == "" {
+		name = "Main Store"
+	}
+	now := time.Now()
+	if err := tx.Table("stores").Create(map[string]interface{}{
+		"code":       "MAIN",
+		"name":       name,
+		"type":       models.StoreTypeStore,
+		"address":    config.AppConfig.Defaults.CompanyAddress,
+		"phone":      config.AppConfig.Defaults.CompanyPhone,
+		"gstin":      config.AppConfig.Billing.GSTIN,
+		"state":      config.AppConfig.Billing.StoreState,
+		"is_default": true,
+		"is_active":  true,
+		"created_at": now,
+		"updated_at": now,
+	}).Error; err != nil {
+		return 0, fmt.Errorf("create default store: %w", err)
+	}
+	if err := tx.Table("stores").Select("id").Where("is_default = ?", true).Limit(1).Scan(&id).Error; err != nil {
+		return 0, err
+	}
+	log.Println("Default store created.")
+	return id, nil
+}
+
+// backfillStores creates the default store and moves stock and documents
+// from before stores existed into it
+func backfillStores(tx *gorm.DB) error {
+	storeID, err := ensureDefaultStore(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Table("stock_batches").Where("store_id = 0").Update("store_id", storeID).Error; err != nil {
+		return err
+	}
+	for _, table := range []string{"stock_movements", "stock_entries", "bills", "purchase_orders", "stock_takes", "customer_orders"} {
+		if err := tx.Table(table).Where("store_id IS NULL").Update("store_id", storeID).Error; err != nil {
+			return err
+		}
+	}
+
+	var products []struct {
+		ID           uint
+		CurrentStock int
+	}
+	if err := tx.Table("products").Select("id, current_stock").
+		Where("deleted_at IS NULL AND current_stock <> 0 AND NOT EXISTS (SELECT 1 FROM store_stocks WHERE store_stocks.product_id = products.id)").
+		Scan(&products).Error; err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, p := range products {
+		if err := tx.Table("store_stocks").Create(map[string]interface{}{
+			"store_id":   storeID,
+			"product_id": p.ID,
+			"quantity":   p.CurrentStock,
+			"updated_at": now,
+		}).Error; err != nil {
+			return fmt.Errorf("backfill store stock for product %d: %w", p.ID, err)
+		}
+	}
+	if len(products) > 0 {
+		log.Printf("Backfilled store stock for %d products.", len(products))
+	}
+	return nil
+}
+
+// backfillPriceHistory opens a price history row for products created before
+// prices were tracked, effective from the product's creation
+func backfillPriceHistory(tx *gorm.DB) error {
+	var products []struct {
+		ID        uint
+		UnitPrice float64
+		CreatedAt time.Time
+	}
+	if err := tx.Table("products").Select("id, unit_price, created_at").
+		Where("NOT EXISTS (SELECT 1 FROM product_price_histories WHERE product_price_histories.product_id = products.id)").
+		Scan(&products).Error; err != nil {
+		return err
+	}
+
+	userID := backfillUserID(tx)
+	now := time.Now()
+	for _, p := range products {
+		if err := tx.Table("product_price_histories").Create(map[string]interface{}{
+			"product_id":     p.ID,
+			"unit_price":     p.UnitPrice,
+			"effective_from": p.CreatedAt,
+			"reason":         "History backfill",
+			"changed_by":     userID,
+			"created_at":     now,
+		}).Error; err != nil {
+			return fmt.Errorf("backfill price history for product %d: %w", p.ID, err)
+		}
+	}
+	if len(products) > 0 {
+		log.Printf("Backfilled price history for %d products.", len(products))
+	}
+	return nil
+}
+
+// backfillCategoryPaths gives categories created before the category tree
+// their path; they were all top-level then
+func backfillCategoryPaths(tx *gorm.DB) error {
+	var ids []uint
+	if err := tx.Table("categories").Where("(path = '' OR path IS NULL) AND parent_id IS NULL").Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := tx.Table("categories").Where("id = ?", id).Update("path", fmt.Sprintf("/%d/", id)).Error; err != nil {
+			return fmt.Errorf("backfill path for category %d: %w", id, err)
+		}
+	}
+	if len(ids) > 0 {
+		log.Printf("Backfilled paths for %d categories.", len(ids))
+	}
+	return nil
+}
+
+// seedSequences starts the bill and order counters above the numbers issued
+// before them. Those were PREFIX-YYYYMMDD-<row ID>, the shape of the default
+// formats, so counting each day from 1 would repeat them.
+func seedSequences(tx *gorm.DB) error {
+	if err := sequence.Seed(tx, sequence.Bill(), "bills", "bill_no"); err != nil {
+		return fmt.Errorf("seed bill numbers: %w", err)
+	}
+	if err := sequence.Seed(tx, sequence.Order(), "customer_orders", "order_no"); err != nil {
+		return fmt.Errorf("seed order numbers: %w", err)
+	}
+	return nil
+}
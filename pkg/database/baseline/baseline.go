@@ -0,0 +1,535 @@
+// Package baseline is the schema migration 1 creates: copies of the models as
+// they stood when versioned migrations were introduced, keeping only their
+// gorm tags. It is frozen. A later model change gets its own migration and is
+// never made here, so the baseline builds the same tables whatever the models
+// look like today.
+package baseline
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tables lists every baseline table, parents before the tables that reference them
+func Tables() []interface{} {
+	return []interface{}{
+		&Role{},
+		&User{},
+		&LoginHistory{},
+		&Brand{},
+		&Category{},
+		&Product{},
+		&ProductPriceHistory{},
+		&ProductBarcode{},
+		&StockEntry{},
+		&StockMovement{},
+		&StockBatch{},
+		&Store{},
+		&StoreStock{},
+		&StockTransfer{},
+		&StockTransferItem{},
+		&StockTake{},
+		&StockTakeItem{},
+		&Supplier{},
+		&PurchaseOrder{},
+		&PurchaseOrderItem{},
+		&Customer{},
+		&CustomerOrder{},
+		&OrderItem{},
+		&Discount{},
+		&DiscountRule{},
+		&Bill{},
+		&BillItem{},
+		&BillItemBatch{},
+		&BillPayment{},
+		&Sequence{},
+		&CreditNote{},
+		&CreditNoteItem{},
+		&Shift{},
+		&CashMovement{},
+	}
+}
+
+type Role struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"size:50;unique;not null"`
+	CreatedAt time.Time
+	Users     []User
+}
+
+type User struct {
+	ID             uint   `gorm:"primaryKey"`
+	EmployeeID     string `gorm:"size:20;unique;not null"`
+	Username       string `gorm:"size:50;not null"`
+	Mobile         string `gorm:"size:15"`
+	PasswordHash   string `gorm:"size:255;not null"`
+	RoleID         uint
+	Role           Role `gorm:"foreignKey:RoleID"`
+	StoreID        *uint
+	Store          *Store `gorm:"foreignKey:StoreID"`
+	IsActive       bool   `gorm:"default:true"`
+	InactiveReason string `gorm:"type:text"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+}
+
+type LoginHistory struct {
+	ID         uint `gorm:"primaryKey"`
+	UserID     uint
+	User       User      `gorm:"foreignKey:UserID"`
+	LoginTime  time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	LogoutTime *time.Time
+	IPAddress  string `gorm:"size:45"`
+}
+
+type Brand struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"size:100;unique;not null"`
+	CreatedAt time.Time
+	Products  []Product
+}
+
+type Category struct {
+	ID          uint       `gorm:"primaryKey"`
+	Name        string     `gorm:"size:100;unique;not null"`
+	ParentID    *uint      `gorm:"index"`
+	Parent      *Category  `gorm:"foreignKey:ParentID"`
+	Children    []Category `gorm:"foreignKey:ParentID"`
+	Path        string     `gorm:"size:255;index"`
+	Description string     `gorm:"type:text"`
+	HSNCode     string     `gorm:"size:10"`
+	GSTRate     *float64   `gorm:"type:decimal(5,2)"`
+	CreatedAt   time.Time
+	Products    []Product
+}
+
+type Product struct {
+	ID                uint      `gorm:"primaryKey"`
+	Name              string    `gorm:"size:150;not null"`
+	ParentID          *uint     `gorm:"index"`
+	Parent            *Product  `gorm:"foreignKey:ParentID"`
+	Variants          []Product `gorm:"foreignKey:ParentID"`
+	HasVariants       bool      `gorm:"default:false"`
+	TrackBatches      bool      `gorm:"default:false"`
+	SKU               string    `gorm:"size:64;index"`
+	Size              string    `gorm:"size:30"`
+	Colour            string    `gorm:"size:30"`
+	BrandID           uint
+	Brand             Brand `gorm:"foreignKey:BrandID"`
+	CategoryID        *uint
+	Category          *Category `gorm:"foreignKey:CategoryID"`
+	SupplierID        *uint     `gorm:"index"`
+	Supplier          *Supplier `gorm:"foreignKey:SupplierID"`
+	Description       string    `gorm:"type:text"`
+	UnitPrice         float64   `gorm:"type:decimal(10,2);not null"`
+	CurrentStock      int       `gorm:"default:0"`
+	LowStockThreshold int       `gorm:"default:10"`
+	Barcode           string    `gorm:"size:50;index"`
+	HSNCode           string    `gorm:"size:10"`
+	GSTRate           *float64  `gorm:"type:decimal(5,2)"`
+	Barcodes          []ProductBarcode
+	IsActive          bool `gorm:"default:true"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	DeletedAt         gorm.DeletedAt `gorm:"index"`
+}
+
+type ProductPriceHistory struct {
+	ID            uint      `gorm:"primaryKey"`
+	ProductID     uint      `gorm:"index"`
+	UnitPrice     float64   `gorm:"type:decimal(10,2);not null"`
+	EffectiveFrom time.Time `gorm:"index"`
+	EffectiveTo   *time.Time
+	Reason        string `gorm:"size:255"`
+	ChangedBy     uint
+	User          User `gorm:"foreignKey:ChangedBy"`
+	CreatedAt     time.Time
+}
+
+type ProductBarcode struct {
+	ID        uint   `gorm:"primaryKey"`
+	ProductID uint   `gorm:"index"`
+	Code      string `gorm:"size:50;uniqueIndex"`
+	PackQty   int    `gorm:"default:1"`
+	Label     string `gorm:"size:50"`
+	CreatedAt time.Time
+}
+
+type StockEntry struct {
+	ID              uint `gorm:"primaryKey"`
+	ProductID       uint
+	Product         Product `gorm:"foreignKey:ProductID"`
+	QuantityAdded   int
+	Source          string `gorm:"size:20;default:'PURCHASE'"`
+	Reference       string `gorm:"size:50"`
+	StoreID         *uint  `gorm:"index"`
+	PurchaseOrderID *uint  `gorm:"index"`
+	BatchID         *uint
+	SupplierID      *uint
+	Supplier        *Supplier `gorm:"foreignKey:SupplierID"`
+	CostPrice       float64   `gorm:"type:decimal(10,2);default:0.00"`
+	AddedBy         uint
+	User            User      `gorm:"foreignKey:AddedBy"`
+	EntryDate       time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type StockMovement struct {
+	ID           uint    `gorm:"primaryKey"`
+	ProductID    uint    `gorm:"index:idx_movement_product_time"`
+	Product      Product `gorm:"foreignKey:ProductID"`
+	Type         string  `gorm:"size:20;not null;index"`
+	Quantity     int     `gorm:"not null"`
+	BalanceAfter int     `gorm:"not null"`
+	RefType      string  `gorm:"size:30"`
+	RefID        *uint
+	RefNo        string `gorm:"size:50"`
+	BatchID      *uint  `gorm:"index"`
+	StoreID      *uint  `gorm:"index"`
+	Note         string `gorm:"type:text"`
+	UserID       uint
+	User         User      `gorm:"foreignKey:UserID"`
+	CreatedAt    time.Time `gorm:"index:idx_movement_product_time"`
+}
+
+type StockBatch struct {
+	ID                uint       `gorm:"primaryKey"`
+	StoreID           uint       `gorm:"uniqueIndex:idx_batch_store_product_no"`
+	Store             Store      `gorm:"foreignKey:StoreID"`
+	ProductID         uint       `gorm:"uniqueIndex:idx_batch_store_product_no;index:idx_batch_product_expiry"`
+	Product           Product    `gorm:"foreignKey:ProductID"`
+	BatchNo           string     `gorm:"size:50;not null;uniqueIndex:idx_batch_store_product_no"`
+	MfgDate           *time.Time `gorm:"type:date"`
+	ExpiryDate        *time.Time `gorm:"type:date;index:idx_batch_product_expiry"`
+	CostPrice         float64    `gorm:"type:decimal(10,2);default:0.00"`
+	QuantityReceived  int        `gorm:"default:0"`
+	QuantityRemaining int        `gorm:"default:0"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+type Store struct {
+	ID        uint   `gorm:"primaryKey"`
+	Code      string `gorm:"size:20;unique;not null"`
+	Name      string `gorm:"size:100;not null"`
+	Type      string `gorm:"size:20;default:'STORE'"`
+	Address   string `gorm:"type:text"`
+	Phone     string `gorm:"size:20"`
+	GSTIN     string `gorm:"size:15"`
+	State     string `gorm:"size:50"`
+	IsDefault bool   `gorm:"default:false"`
+	IsActive  bool   `gorm:"default:true"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type StoreStock struct {
+	ID        uint    `gorm:"primaryKey"`
+	StoreID   uint    `gorm:"uniqueIndex:idx_store_product"`
+	Store     Store   `gorm:"foreignKey:StoreID"`
+	ProductID uint    `gorm:"uniqueIndex:idx_store_product;index"`
+	Product   Product `gorm:"foreignKey:ProductID"`
+	Quantity  int     `gorm:"default:0"`
+	UpdatedAt time.Time
+}
+
+type StockTransfer struct {
+	ID           uint   `gorm:"primaryKey"`
+	TransferNo   string `gorm:"size:50;unique;not null"`
+	FromStoreID  uint   `gorm:"index"`
+	FromStore    Store  `gorm:"foreignKey:FromStoreID"`
+	ToStoreID    uint   `gorm:"index"`
+	ToStore      Store  `gorm:"foreignKey:ToStoreID"`
+	Status       string `gorm:"size:20;not null;index"`
+	Notes        string `gorm:"type:text"`
+	CreatedBy    uint
+	Creator      User `gorm:"foreignKey:CreatedBy"`
+	DispatchedAt time.Time
+	ReceivedBy   *uint
+	ReceivedAt   *time.Time
+	Items        []StockTransferItem
+}
+
+type StockTransferItem struct {
+	ID              uint `gorm:"primaryKey"`
+	StockTransferID uint `gorm:"index"`
+	ProductID       uint
+	Product         Product `gorm:"foreignKey:ProductID"`
+	Quantity        int
+	ReceivedQty     int        `gorm:"default:0"`
+	BatchNo         string     `gorm:"size:50"`
+	MfgDate         *time.Time `gorm:"type:date"`
+	ExpiryDate      *time.Time `gorm:"type:date"`
+	CostPrice       float64    `gorm:"type:decimal(10,2);default:0.00"`
+}
+
+type StockTake struct {
+	ID         uint   `gorm:"primaryKey"`
+	TakeNo     string `gorm:"size:50;unique;not null"`
+	CategoryID *uint
+	Category   *Category `gorm:"foreignKey:CategoryID"`
+	StoreID    *uint     `gorm:"index"`
+	Store      *Store    `gorm:"foreignKey:StoreID"`
+	Location   string    `gorm:"size:100"`
+	Status     string    `gorm:"size:20;not null;default:'OPEN'"`
+	Notes      string    `gorm:"type:text"`
+	CreatedBy  uint
+	Creator    User `gorm:"foreignKey:CreatedBy"`
+	ApprovedBy *uint
+	ApprovedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Items      []StockTakeItem `gorm:"foreignKey:StockTakeID"`
+}
+
+type StockTakeItem struct {
+	ID          uint `gorm:"primaryKey"`
+	StockTakeID uint `gorm:"index"`
+	ProductID   uint
+	Product     Product `gorm:"foreignKey:ProductID"`
+	SystemQty   int
+	CountedQty  *int
+	ReasonCode  string `gorm:"size:20"`
+	Adjustment  int    `gorm:"default:0"`
+	CountedBy   *uint
+	CountedAt   *time.Time
+}
+
+type Supplier struct {
+	ID            uint   `gorm:"primaryKey"`
+	Name          string `gorm:"size:150;unique;not null"`
+	ContactPerson string `gorm:"size:100"`
+	Mobile        string `gorm:"size:15"`
+	Email         string `gorm:"size:100"`
+	Address       string `gorm:"type:text"`
+	GSTIN         string `gorm:"size:15"`
+	State         string `gorm:"size:50"`
+	LeadTimeDays  int    `gorm:"default:0"`
+	IsActive      bool   `gorm:"default:true"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type PurchaseOrder struct {
+	ID           uint     `gorm:"primaryKey"`
+	PONo         string   `gorm:"size:50;unique;not null"`
+	SupplierID   uint     `gorm:"index"`
+	Supplier     Supplier `gorm:"foreignKey:SupplierID"`
+	StoreID      *uint    `gorm:"index"`
+	Status       string   `gorm:"size:20;not null;default:'DRAFT'"`
+	OrderedAt    *time.Time
+	ExpectedDate *time.Time
+	Notes        string  `gorm:"type:text"`
+	TotalCost    float64 `gorm:"type:decimal(12,2);default:0.00"`
+	CreatedBy    uint
+	Creator      User `gorm:"foreignKey:CreatedBy"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Items        []PurchaseOrderItem `gorm:"foreignKey:PurchaseOrderID"`
+}
+
+type PurchaseOrderItem struct {
+	ID              uint `gorm:"primaryKey"`
+	PurchaseOrderID uint `gorm:"index"`
+	ProductID       uint
+	Product         Product `gorm:"foreignKey:ProductID"`
+	Quantity        int     `gorm:"not null"`
+	ReceivedQty     int     `gorm:"default:0"`
+	CostPrice       float64 `gorm:"type:decimal(10,2);not null"`
+	Total           float64 `gorm:"type:decimal(12,2);not null"`
+}
+
+type Customer struct {
+	ID              uint    `gorm:"primaryKey"`
+	Name            string  `gorm:"size:100;not null"`
+	Mobile          string  `gorm:"size:15;unique;not null"`
+	Address         string  `gorm:"type:text"`
+	State           string  `gorm:"size:50"`
+	WhatsappOptIn   bool    `gorm:"default:false"`
+	DiscountPercent float64 `gorm:"type:decimal(5,2);default:0.00"`
+	CreatedAt       time.Time
+}
+
+type CustomerOrder struct {
+	ID             uint   `gorm:"primaryKey"`
+	OrderNo        string `gorm:"size:50;unique;not null"`
+	CustomerID     uint
+	Customer       Customer    `gorm:"foreignKey:CustomerID"`
+	StoreID        *uint       `gorm:"index"`
+	OrderDate      time.Time   `gorm:"default:CURRENT_TIMESTAMP"`
+	Status         string      `gorm:"size:20;not null;default:'PENDING'"`
+	TotalEstimated float64     `gorm:"type:decimal(10,2)"`
+	Items          []OrderItem `gorm:"foreignKey:OrderID"`
+}
+
+type OrderItem struct {
+	ID        uint `gorm:"primaryKey"`
+	OrderID   uint
+	ProductID uint
+	Product   Product `gorm:"foreignKey:ProductID"`
+	Quantity  int
+}
+
+type Discount struct {
+	ID         uint    `gorm:"primaryKey"`
+	Name       string  `gorm:"size:50"`
+	Percentage float64 `gorm:"type:decimal(5,2);not null"`
+	IsActive   bool    `gorm:"default:true"`
+	CreatedAt  time.Time
+}
+
+type DiscountRule struct {
+	ID         uint `gorm:"primaryKey"`
+	MinAmount  float64
+	MaxAmount  float64
+	Percentage float64
+	IsActive   bool `gorm:"default:true"`
+}
+
+type Bill struct {
+	ID             uint      `gorm:"primaryKey"`
+	BillNo         string    `gorm:"size:50;unique;not null"`
+	OrderNo        string    `gorm:"size:50"`
+	BillDate       time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	CustomerID     *uint
+	Customer       *Customer `gorm:"foreignKey:CustomerID"`
+	UserID         uint
+	User           User          `gorm:"foreignKey:UserID"`
+	ShiftID        *uint         `gorm:"index"`
+	StoreID        *uint         `gorm:"index"`
+	Store          *Store        `gorm:"foreignKey:StoreID"`
+	TotalAmount    float64       `gorm:"type:decimal(10,2);not null"`
+	DiscountAmount float64       `gorm:"type:decimal(10,2);default:0.00"`
+	GSTAmount      float64       `gorm:"type:decimal(10,2);default:0.00"`
+	CGSTAmount     float64       `gorm:"type:decimal(10,2);default:0.00"`
+	SGSTAmount     float64       `gorm:"type:decimal(10,2);default:0.00"`
+	IGSTAmount     float64       `gorm:"type:decimal(10,2);default:0.00"`
+	PlaceOfSupply  string        `gorm:"size:50"`
+	InterState     bool          `gorm:"default:false"`
+	RoundOff       float64       `gorm:"type:decimal(10,2);default:0.00"`
+	NetPayable     float64       `gorm:"type:decimal(10,2);not null"`
+	RefundedAmount float64       `gorm:"type:decimal(10,2);default:0.00"`
+	PaymentMode    string        `gorm:"size:20;not null;default:'CASH'"`
+	Status         string        `gorm:"size:20;not null;default:'PAID'"`
+	Items          []BillItem    `gorm:"foreignKey:BillID"`
+	Payments       []BillPayment `gorm:"foreignKey:BillID"`
+	CreditNotes    []CreditNote  `gorm:"foreignKey:BillID"`
+}
+
+type BillItem struct {
+	ID             uint `gorm:"primaryKey"`
+	BillID         uint
+	ProductID      uint
+	Product        Product `gorm:"foreignKey:ProductID"`
+	Quantity       int
+	UnitPrice      float64 `gorm:"type:decimal(10,2);not null"`
+	Total          float64 `gorm:"type:decimal(10,2);not null"`
+	ReturnedQty    int     `gorm:"default:0"`
+	HSNCode        string  `gorm:"size:10"`
+	DiscountAmount float64 `gorm:"type:decimal(10,2);default:0.00"`
+	TaxableValue   float64 `gorm:"type:decimal(10,2);default:0.00"`
+	GSTRate        float64 `gorm:"type:decimal(5,2);default:0.00"`
+	CGSTAmount     float64 `gorm:"type:decimal(10,2);default:0.00"`
+	SGSTAmount     float64 `gorm:"type:decimal(10,2);default:0.00"`
+	IGSTAmount     float64 `gorm:"type:decimal(10,2);default:0.00"`
+	Batches        []BillItemBatch
+}
+
+type BillItemBatch struct {
+	ID          uint `gorm:"primaryKey"`
+	BillItemID  uint `gorm:"index"`
+	BatchID     uint
+	Batch       StockBatch `gorm:"foreignKey:BatchID"`
+	Quantity    int
+	ReturnedQty int `gorm:"default:0"`
+}
+
+type BillPayment struct {
+	ID             uint    `gorm:"primaryKey"`
+	BillID         uint    `gorm:"index"`
+	TenderType     string  `gorm:"size:20;not null"`
+	Amount         float64 `gorm:"type:decimal(10,2);not null"`
+	Tendered       float64 `gorm:"type:decimal(10,2);not null"`
+	ChangeReturned float64 `gorm:"type:decimal(10,2);default:0.00"`
+	ReferenceNo    string  `gorm:"size:100"`
+	CardLast4      string  `gorm:"size:4"`
+	CreatedAt      time.Time
+}
+
+type Sequence struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"size:50;not null;uniqueIndex:idx_sequence_name_period"`
+	Period    string `gorm:"size:20;not null;uniqueIndex:idx_sequence_name_period"`
+	LastValue int    `gorm:"not null;default:0"`
+	UpdatedAt time.Time
+}
+
+type CreditNote struct {
+	ID             uint   `gorm:"primaryKey"`
+	CreditNoteNo   string `gorm:"size:50;unique;not null"`
+	BillID         uint   `gorm:"index"`
+	Type           string `gorm:"size:20;not null"`
+	Reason         string `gorm:"type:text;not null"`
+	UserID         uint
+	ShiftID        *uint   `gorm:"index"`
+	User           User    `gorm:"foreignKey:UserID"`
+	TotalAmount    float64 `gorm:"type:decimal(10,2);not null"`
+	DiscountAmount float64 `gorm:"type:decimal(10,2);default:0.00"`
+	TaxableValue   float64 `gorm:"type:decimal(10,2);default:0.00"`
+	CGSTAmount     float64 `gorm:"type:decimal(10,2);default:0.00"`
+	SGSTAmount     float64 `gorm:"type:decimal(10,2);default:0.00"`
+	IGSTAmount     float64 `gorm:"type:decimal(10,2);default:0.00"`
+	RefundAmount   float64 `gorm:"type:decimal(10,2);not null"`
+	RefundMode     string  `gorm:"size:20"`
+	CreatedAt      time.Time
+	Items          []CreditNoteItem `gorm:"foreignKey:CreditNoteID"`
+}
+
+type CreditNoteItem struct {
+	ID             uint `gorm:"primaryKey"`
+	CreditNoteID   uint
+	BillItemID     uint
+	ProductID      uint
+	Product        Product `gorm:"foreignKey:ProductID"`
+	Quantity       int
+	UnitPrice      float64 `gorm:"type:decimal(10,2);not null"`
+	Total          float64 `gorm:"type:decimal(10,2);not null"`
+	HSNCode        string  `gorm:"size:10"`
+	DiscountAmount float64 `gorm:"type:decimal(10,2);default:0.00"`
+	TaxableValue   float64 `gorm:"type:decimal(10,2);default:0.00"`
+	GSTRate        float64 `gorm:"type:decimal(5,2);default:0.00"`
+	CGSTAmount     float64 `gorm:"type:decimal(10,2);default:0.00"`
+	SGSTAmount     float64 `gorm:"type:decimal(10,2);default:0.00"`
+	IGSTAmount     float64 `gorm:"type:decimal(10,2);default:0.00"`
+}
+
+type Shift struct {
+	ID             uint `gorm:"primaryKey"`
+	UserID         uint `gorm:"index"`
+	User           User `gorm:"foreignKey:UserID"`
+	LoginHistoryID *uint
+	Status         string `gorm:"size:20;not null;default:'OPEN'"`
+	OpenedAt       time.Time
+	ClosedAt       *time.Time
+	OpeningFloat   float64 `gorm:"type:decimal(10,2);not null"`
+	ExpectedCash   float64 `gorm:"type:decimal(10,2);default:0.00"`
+	CountedCash    float64 `gorm:"type:decimal(10,2);default:0.00"`
+	Variance       float64 `gorm:"type:decimal(10,2);default:0.00"`
+	ClosingNote    string  `gorm:"type:text"`
+	ApprovedBy     *uint
+	Approver       *User `gorm:"foreignKey:ApprovedBy"`
+	ApprovedAt     *time.Time
+	ApprovalNote   string         `gorm:"type:text"`
+	CashMovements  []CashMovement `gorm:"foreignKey:ShiftID"`
+}
+
+type CashMovement struct {
+	ID        uint    `gorm:"primaryKey"`
+	ShiftID   uint    `gorm:"index"`
+	Type      string  `gorm:"size:10;not null"`
+	Amount    float64 `gorm:"type:decimal(10,2);not null"`
+	Reason    string  `gorm:"type:text;not null"`
+	UserID    uint
+	CreatedAt time.Time
+}
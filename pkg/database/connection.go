@@ -3,11 +3,15 @@ package database
 import (
 	"fmt"
 	"log"
+	"net/url"
+	"strings"
 	"time"
 
 	"billing-app/config"
 
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
@@ -15,18 +19,15 @@ import (
 var DB *gorm.DB
 
 func Connect() {
+	dialector, err := Dialector(config.AppConfig.Database)
+	if err != nil {
+		log.Fatalf("Invalid database configuration: %v", err)
+	}
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local&tls=true",
-		config.AppConfig.Database.User,
-		config.AppConfig.Database.Password,
-		config.AppConfig.Database.Host,
-		config.AppConfig.Database.Port,
-		config.AppConfig.Database.Name,
-	)
-
-	var err error
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
+	DB, err = gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
+		// Unique violations surface as gorm.ErrDuplicatedKey on every driver
+		TranslateError: true,
 	})
 
 	if err != nil {
@@ -45,5 +46,79 @@ func Connect() {
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
-	log.Println("Database connection established successfully")
+	log.Printf("Database connection established successfully (%s)", DB.Dialector.Name())
+}
+
+// Dialector picks the gorm driver for the configuration. DATABASE_URL wins
+// over DB_DRIVER and the DB_* settings when set.
+func Dialector(cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	if cfg.URL != "" {
+		return dialectorFromURL(cfg.URL)
+	}
+
+	switch strings.ToLower(cfg.Driver) {
+	case "", "mysql":
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local&tls=%t",
+			cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Name, cfg.TLS)
+		return mysql.Open(dsn), nil
+	case "postgres", "postgresql":
+		sslMode := "disable"
+		if cfg.TLS {
+			sslMode = "require"
+		}
+		dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+			cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, sslMode)
+		return postgres.Open(dsn), nil
+	case "sqlite", "sqlite3":
+		path := cfg.Name
+		if path == "" {
+			path = "billing.db"
+		}
+		return sqlite.Open(sqliteDSN(path)), nil
+	}
+	return nil, fmt.Errorf("unsupported DB_DRIVER %q (use mysql, postgres or sqlite)", cfg.Driver)
+}
+
+func dialectorFromURL(raw string) (gorm.Dialector, error) {
+	scheme, rest, ok := strings.Cut(raw, "://")
+	if !ok {
+		if path, isFile := strings.CutPrefix(raw, "file:"); isFile {
+			return sqlite.Open(sqliteDSN(path)), nil
+		}
+		return nil, fmt.Errorf("DATABASE_URL has no scheme")
+	}
+
+	switch strings.ToLower(scheme) {
+	case "postgres", "postgresql":
+		// The pgx driver accepts the URL as is
+		return postgres.Open(raw), nil
+	case "sqlite", "sqlite3", "file":
+		return sqlite.Open(sqliteDSN(rest)), nil
+	case "mysql":
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DATABASE_URL: %w", err)
+		}
+		password, _ := u.User.Password()
+		params := u.Query()
+		for key, value := range map[string]string{"charset": "utf8mb4", "parseTime": "True", "loc": "Local"} {
+			if !params.Has(key) {
+				params.Set(key, value)
+			}
+		}
+		dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s?%s",
+			u.User.Username(), password, u.Host, strings.TrimPrefix(u.Path, "/"), params.Encode())
+		return mysql.Open(dsn), nil
+	}
+	return nil, fmt.Errorf("unsupported DATABASE_URL scheme %q", scheme)
+}
+
+// sqliteDSN turns on foreign keys and WAL, and has writers wait for the lock
+// instead of failing with "database is locked"
+func sqliteDSN(path string) string {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + "_foreign_keys=1&_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate"
 }
@@ -0,0 +1,185 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"billing-app/internal/models"
+	"billing-app/pkg/database/baseline"
+	"billing-app/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+// Models lists every table, parents before the tables that reference them
+func Models() []interface{} {
+	return []interface{}{
+		&models.Role{},
+		&models.User{},
+		&models.LoginHistory{},
+		&models.Brand{},
+		&models.Category{},
+		&models.Product{},
+		&models.ProductPriceHistory{},
+		&models.ProductBarcode{},
+		&models.StockEntry{},
+		&models.StockMovement{},
+		&models.StockBatch{},
+		&models.Store{},
+		&models.StoreStock{},
+		&models.StockTransfer{},
+		&models.StockTransferItem{},
+		&models.StockTake{},
+		&models.StockTakeItem{},
+		&models.Supplier{},
+		&models.PurchaseOrder{},
+		&models.PurchaseOrderItem{},
+		&models.Customer{},
+		&models.CustomerOrder{},
+		&models.OrderItem{},
+		&models.Discount{},
+		&models.DiscountRule{},
+		&models.Bill{},
+		&models.BillItem{},
+		&models.BillItemBatch{},
+		&models.BillPayment{},
+		&models.Sequence{},
+		&models.CreditNote{},
+		&models.CreditNoteItem{},
+		&models.Shift{},
+		&models.CashMovement{},
+	}
+}
+
+// AutoMigrate syncs the tables with the models. It only adds, so it is for
+// development; deployments use the versioned Migrations.
+func AutoMigrate() error {
+	return DB.AutoMigrate(Models()...)
+}
+
+// Migrations are the versioned schema changes, applied in version order.
+//
+// The baseline creates the schema frozen in package baseline, and on databases
+// built by the old boot-time AutoMigrate it only fills in what is missing.
+// Every later change to a model needs its own migration here: use explicit
+// Migrator calls or SQL, not AutoMigrate, so each one does the same thing
+// wherever it runs. Data backfills are migrations too, so each runs once.
+var Migrations = []migrate.Migration{
+	{
+		Version: 1,
+		Name:    "baseline",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(baseline.Tables()...)
+		},
+		Down: func(tx *gorm.DB) error {
+			tables := baseline.Tables()
+			for i := len(tables) - 1; i >= 0; i-- {
+				if err := tx.Migrator().DropTable(tables[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		// Batch numbers are unique per store rather than per product since
+		// stores were added. The old index can't come back once two stores
+		// hold the same batch, so this can't be rolled back.
+		Version: 2,
+		Name:    "drop_batch_product_no_index",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasIndex(&models.StockBatch{}, "idx_batch_product_no") {
+				return tx.Migrator().DropIndex(&models.StockBatch{}, "idx_batch_product_no")
+			}
+			return nil
+		},
+	},
+	{
+		// Stock takes post the variance against the stock at count time
+		// rather than overwriting stock with the count
+		Version: 3,
+		Name:    "add_stock_take_item_system_qty_at_count",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&models.StockTakeItem{}, "SystemQtyAtCount") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&models.StockTakeItem{}, "SystemQtyAtCount")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.StockTakeItem{}, "SystemQtyAtCount")
+		},
+	},
+	{
+		Version: 4,
+		Name:    "backfill_stock_ledger",
+		Up:      backfillStockLedger,
+		Down:    keepData,
+	},
+	{
+		Version: 5,
+		Name:    "backfill_stores",
+		Up:      backfillStores,
+		Down:    keepData,
+	},
+	{
+		Version: 6,
+		Name:    "backfill_price_history",
+		Up:      backfillPriceHistory,
+		Down:    keepData,
+	},
+	{
+		Version: 7,
+		Name:    "backfill_category_paths",
+		Up:      backfillCategoryPaths,
+		Down:    keepData,
+	},
+	{
+		Version: 8,
+		Name:    "seed_sequences",
+		Up:      seedSequences,
+		Down:    keepData,
+	},
+	{
+		// Two products can no longer end up with the same barcode when their
+		// saves race past the application check
+		Version: 9,
+		Name:    "unique_product_barcode",
+		Up:      uniqueProductBarcode,
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropIndex(&models.Product{}, "idx_products_barcode_unique")
+		},
+	},
+}
+
+// keepData rolls back a backfill: the rows it wrote are ordinary data now and stay
+func keepData(*gorm.DB) error {
+	return nil
+}
+
+// uniqueProductBarcode indexes the barcodes of live products. Empty barcodes
+// and deleted products are left out, the same products CheckBarcode ignores.
+// MySQL has no partial indexes, so there an expression index (8.0.13+) maps
+// them to NULL, which a unique index allows any number of.
+func uniqueProductBarcode(tx *gorm.DB) error {
+	var duplicates []struct {
+		Barcode string
+		Count   int
+	}
+	if err := tx.Table("products").Select("barcode, COUNT(*) AS count").
+		Where("barcode <> '' AND deleted_at IS NULL").Group("barcode").Having("COUNT(*) > 1").
+		Scan(&duplicates).Error; err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		codes := make([]string, len(duplicates))
+		for i, d := range duplicates {
+			codes[i] = fmt.Sprintf("%s (%d products)", d.Barcode, d.Count)
+		}
+		return fmt.Errorf("barcodes shared by several products, reassign them first: %s", strings.Join(codes, ", "))
+	}
+
+	if tx.Dialector.Name() == "mysql" {
+		return tx.Exec("CREATE UNIQUE INDEX idx_products_barcode_unique ON products ((CASE WHEN barcode <> '' AND deleted_at IS NULL THEN barcode END))").Error
+	}
+	return tx.Exec("CREATE UNIQUE INDEX idx_products_barcode_unique ON products (barcode) WHERE barcode <> '' AND deleted_at IS NULL").Error
+}
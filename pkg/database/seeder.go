@@ -43,3 +43,11 @@ func SeedRolesAndAdmin() {
 		}
 	}
 }
+
+// SeedDefaultStore creates the default store on a database whose schema was
+// only AutoMigrated (dev); migration 5 creates it everywhere else
+func SeedDefaultStore() {
+	if _, err := ensureDefaultStore(DB); err != nil {
+		log.Printf("Failed to create default store: %v", err)
+	}
+}
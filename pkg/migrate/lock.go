@@ -0,0 +1,57 @@
+package migrate
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	lockName    = "billing_app_schema_migrations"
+	lockKey     = 7262146912 // pg_advisory_lock takes a bigint key
+	lockTimeout = 60 * time.Second
+)
+
+// acquireLock takes the dialect's session-level advisory lock on conn, waiting
+// up to lockTimeout for another process to finish. SQLite has none; its
+// immediate transactions already serialise writers.
+func acquireLock(conn *gorm.DB) (func(), error) {
+	switch conn.Dialector.Name() {
+	case "mysql":
+		var got *int
+		if err := conn.Raw("SELECT GET_LOCK(?, ?)", lockName, int(lockTimeout.Seconds())).Scan(&got).Error; err != nil {
+			return nil, err
+		}
+		if got == nil || *got != 1 {
+			return nil, ErrLocked
+		}
+		return func() {
+			if err := conn.Exec("SELECT RELEASE_LOCK(?)", lockName).Error; err != nil {
+				log.Printf("Failed to release migration lock: %v", err)
+			}
+		}, nil
+
+	case "postgres":
+		deadline := time.Now().Add(lockTimeout)
+		for {
+			var got bool
+			if err := conn.Raw("SELECT pg_try_advisory_lock(?)", lockKey).Scan(&got).Error; err != nil {
+				return nil, err
+			}
+			if got {
+				break
+			}
+			if time.Now().After(deadline) {
+				return nil, ErrLocked
+			}
+			time.Sleep(500 * time.Millisecond)
+		}
+		return func() {
+			if err := conn.Exec("SELECT pg_advisory_unlock(?)", lockKey).Error; err != nil {
+				log.Printf("Failed to release migration lock: %v", err)
+			}
+		}, nil
+	}
+	return func() {}, nil
+}
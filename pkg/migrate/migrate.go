@@ -0,0 +1,280 @@
+// Package migrate applies versioned schema migrations and records them in the
+// schema_migrations table. Runs hold a database advisory lock so replicas
+// starting together apply each migration once.
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one schema change. Up and Down run in a transaction with the
+// schema_migrations update, though MySQL commits DDL statements implicitly.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error // nil when the change can't be undone
+}
+
+// SQL is a migration step that runs the statements in order
+func SQL(statements ...string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		for _, stmt := range statements {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// SchemaMigration records an applied migration
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false" json:"version"`
+	Name      string    `gorm:"size:255;not null" json:"name"`
+	AppliedAt time.Time `gorm:"not null" json:"applied_at"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status is a migration and whether it has been applied. Unknown marks a
+// version recorded in the database that this build doesn't have.
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+	Unknown   bool       `json:"unknown,omitempty"`
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New returns a migrator for the migrations, which may be in any order
+func New(db *gorm.DB, migrations []Migration) (*Migrator, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i, m := range sorted {
+		if m.Version <= 0 || m.Up == nil {
+			return nil, fmt.Errorf("migration %d (%s) needs a positive version and an Up step", m.Version, m.Name)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("duplicate migration version %d", m.Version)
+		}
+	}
+	return &Migrator{db: db, migrations: sorted}, nil
+}
+
+// Latest is the highest known version, or 0 with no migrations
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status lists every known migration in order, followed by any applied
+// versions this build doesn't know
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied(m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		status := Status{Version: mig.Version, Name: mig.Name}
+		if row, ok := applied[mig.Version]; ok {
+			status.AppliedAt = &row.AppliedAt
+			delete(applied, mig.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range applied {
+		appliedAt := row.AppliedAt
+		statuses = append(statuses, Status{Version: row.Version, Name: row.Name, AppliedAt: &appliedAt, Unknown: true})
+	}
+	sort.SliceStable(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Pending counts the known migrations not yet applied
+func (m *Migrator) Pending() (int, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return 0, err
+	}
+	pending := 0
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration
+func (m *Migrator) Up() ([]Migration, error) {
+	return m.To(m.Latest())
+}
+
+// Down rolls back the most recently applied migration
+func (m *Migrator) Down() ([]Migration, error) {
+	var done []Migration
+	err := m.locked(func(conn *gorm.DB) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+		current := 0
+		for version := range applied {
+			if version > current {
+				current = version
+			}
+		}
+		if current == 0 {
+			return nil
+		}
+		mig, ok := m.find(current)
+		if !ok {
+			return fmt.Errorf("version %d is applied but not known to this build", current)
+		}
+		if err := m.rollback(conn, mig); err != nil {
+			return err
+		}
+		done = append(done, mig)
+		return nil
+	})
+	return done, err
+}
+
+// To migrates up or down until exactly the migrations up to version are
+// applied, including any older ones still pending
+func (m *Migrator) To(version int) ([]Migration, error) {
+	if version != 0 {
+		if _, ok := m.find(version); !ok {
+			return nil, fmt.Errorf("unknown migration version %d", version)
+		}
+	}
+
+	var done []Migration
+	err := m.locked(func(conn *gorm.DB) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+
+		for v := range applied {
+			if _, ok := m.find(v); !ok && v > version {
+				return fmt.Errorf("version %d is applied but not known to this build", v)
+			}
+		}
+
+		// Roll back newest first, then apply oldest first
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok || mig.Version <= version {
+				continue
+			}
+			if err := m.rollback(conn, mig); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok || mig.Version > version {
+				continue
+			}
+			if err := m.apply(conn, mig); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+func (m *Migrator) apply(conn *gorm.DB, mig Migration) error {
+	err := conn.Transaction(func(tx *gorm.DB) error {
+		if err := mig.Up(tx); err != nil {
+			return err
+		}
+		return tx.Create(&SchemaMigration{Version: mig.Version, Name: mig.Name, AppliedAt: time.Now()}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d (%s) failed: %w", mig.Version, mig.Name, err)
+	}
+	return nil
+}
+
+func (m *Migrator) rollback(conn *gorm.DB, mig Migration) error {
+	if mig.Down == nil {
+		return fmt.Errorf("migration %d (%s) cannot be rolled back", mig.Version, mig.Name)
+	}
+	err := conn.Transaction(func(tx *gorm.DB) error {
+		if err := mig.Down(tx); err != nil {
+			return err
+		}
+		return tx.Delete(&SchemaMigration{}, mig.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("rollback of migration %d (%s) failed: %w", mig.Version, mig.Name, err)
+	}
+	return nil
+}
+
+func (m *Migrator) find(version int) (Migration, bool) {
+	i := sort.Search(len(m.migrations), func(i int) bool { return m.migrations[i].Version >= version })
+	if i < len(m.migrations) && m.migrations[i].Version == version {
+		return m.migrations[i], true
+	}
+	return Migration{}, false
+}
+
+// applied returns the recorded migrations by version; none before the
+// schema_migrations table exists
+func (m *Migrator) applied(db *gorm.DB) (map[int]SchemaMigration, error) {
+	applied := map[int]SchemaMigration{}
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return applied, nil
+	}
+	var rows []SchemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// locked runs fn on a single connection holding the migration lock, after
+// making sure the schema_migrations table exists
+func (m *Migrator) locked(fn func(conn *gorm.DB) error) error {
+	return m.db.Connection(func(conn *gorm.DB) error {
+		// A new session so statements on the connection don't share state
+		conn = conn.Session(&gorm.Session{})
+		unlock, err := acquireLock(conn)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		if err := conn.AutoMigrate(&SchemaMigration{}); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+var ErrLocked = errors.New("another process is running migrations")
@@ -0,0 +1,225 @@
+package migrate_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"billing-app/config"
+	"billing-app/internal/models"
+	"billing-app/pkg/database"
+	"billing-app/pkg/migrate"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// open returns a migrator for the app's migrations on an empty SQLite database
+func open(t *testing.T) (*migrate.Migrator, *gorm.DB) {
+	t.Helper()
+	if config.AppConfig == nil {
+		config.AppConfig = &config.Config{}
+	}
+
+	dialector, err := database.Dialector(config.DatabaseConfig{Driver: "sqlite", Name: filepath.Join(t.TempDir(), "migrate.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent), TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	m, err := migrate.New(db, database.Migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, db
+}
+
+// recorded lists the versions in schema_migrations, oldest first
+func recorded(t *testing.T, db *gorm.DB) []int {
+	t.Helper()
+	var versions []int
+	if !db.Migrator().HasTable(&migrate.SchemaMigration{}) {
+		return versions
+	}
+	if err := db.Model(&migrate.SchemaMigration{}).Order("version").Pluck("version", &versions).Error; err != nil {
+		t.Fatal(err)
+	}
+	return versions
+}
+
+func upTo(version int) []int {
+	versions := []int{}
+	for v := 1; v <= version; v++ {
+		versions = append(versions, v)
+	}
+	return versions
+}
+
+func sameVersions(got, want []int) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestUpIsIdempotent(t *testing.T) {
+	m, db := open(t)
+
+	done, err := m.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != m.Latest() {
+		t.Errorf("first run applied %d migrations, want %d", len(done), m.Latest())
+	}
+
+	done, err = m.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 0 {
+		t.Errorf("second run applied %d migrations, want none", len(done))
+	}
+	if pending, err := m.Pending(); err != nil || pending != 0 {
+		t.Errorf("pending %d, %v; want 0", pending, err)
+	}
+	if got := recorded(t, db); !sameVersions(got, upTo(m.Latest())) {
+		t.Errorf("recorded %v, want %v", got, upTo(m.Latest()))
+	}
+
+	// The backfills ran once: a single default store
+	var stores int64
+	db.Model(&models.Store{}).Where("is_default = ?", true).Count(&stores)
+	if stores != 1 {
+		t.Errorf("%d default stores, want 1", stores)
+	}
+}
+
+func TestTo(t *testing.T) {
+	m, db := open(t)
+
+	tests := []struct {
+		name      string
+		version   int
+		wantDone  []int
+		wantIndex bool // Migration 9's unique barcode index
+	}{
+		{"up part way", 3, []int{1, 2, 3}, false},
+		{"up to the latest", 9, []int{4, 5, 6, 7, 8, 9}, true},
+		{"down newest first", 4, []int{9, 8, 7, 6, 5}, false},
+		{"same version", 4, nil, false},
+		{"back up", 9, []int{5, 6, 7, 8, 9}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done, err := m.To(tt.version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int
+			for _, mig := range done {
+				got = append(got, mig.Version)
+			}
+			if !sameVersions(got, tt.wantDone) {
+				t.Errorf("ran %v, want %v", got, tt.wantDone)
+			}
+			if got := recorded(t, db); !sameVersions(got, upTo(tt.version)) {
+				t.Errorf("recorded %v, want %v", got, upTo(tt.version))
+			}
+			if has := db.Migrator().HasIndex(&models.Product{}, "idx_products_barcode_unique"); has != tt.wantIndex {
+				t.Errorf("barcode index present %v, want %v", has, tt.wantIndex)
+			}
+		})
+	}
+}
+
+func TestToUnknownVersion(t *testing.T) {
+	m, db := open(t)
+
+	for _, version := range []int{-1, 42} {
+		done, err := m.To(version)
+		if err == nil || !strings.Contains(err.Error(), "unknown migration version") {
+			t.Errorf("To(%d): %v, want an unknown version error", version, err)
+		}
+		if len(done) != 0 {
+			t.Errorf("To(%d) ran %d migrations, want none", version, len(done))
+		}
+	}
+	if got := recorded(t, db); len(got) != 0 {
+		t.Errorf("recorded %v, want nothing", got)
+	}
+}
+
+// Migration 2 has no down step: rolling it back fails and leaves it recorded
+func TestDownIrreversible(t *testing.T) {
+	m, db := open(t)
+	if _, err := m.To(3); err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := m.Down()
+	if err != nil {
+		t.Fatalf("rolling back 3: %v", err)
+	}
+	if len(done) != 1 || done[0].Version != 3 {
+		t.Errorf("rolled back %v, want migration 3", done)
+	}
+
+	done, err = m.Down()
+	if err == nil || !strings.Contains(err.Error(), "cannot be rolled back") {
+		t.Errorf("rolling back 2: %v, want a cannot be rolled back error", err)
+	}
+	if len(done) != 0 {
+		t.Errorf("rolled back %v, want nothing", done)
+	}
+	if got := recorded(t, db); !sameVersions(got, []int{1, 2}) {
+		t.Errorf("recorded %v, want [1 2]", got)
+	}
+
+	// Going below it by version stops at the same place
+	if _, err := m.To(0); err == nil {
+		t.Error("To(0) rolled back past migration 2")
+	}
+	if got := recorded(t, db); !sameVersions(got, []int{1, 2}) {
+		t.Errorf("after To(0) recorded %v, want [1 2]", got)
+	}
+}
+
+// A failing step rolls back with its schema_migrations row; the ones before it stay
+func TestFailedStepIsNotRecorded(t *testing.T) {
+	_, db := open(t)
+	m, err := migrate.New(db, []migrate.Migration{
+		{Version: 2, Name: "broken", Up: migrate.SQL("CREATE TABLE half (id INTEGER)", "INSERT INTO missing VALUES (1)")},
+		{Version: 1, Name: "widgets", Up: migrate.SQL("CREATE TABLE widgets (id INTEGER)")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := m.Up()
+	if err == nil || !strings.Contains(err.Error(), "migration 2 (broken) failed") {
+		t.Errorf("Up: %v, want migration 2 to fail", err)
+	}
+	if len(done) != 1 || done[0].Version != 1 {
+		t.Errorf("applied %v, want migration 1", done)
+	}
+	if got := recorded(t, db); !sameVersions(got, []int{1}) {
+		t.Errorf("recorded %v, want [1]", got)
+	}
+	if db.Migrator().HasTable("half") {
+		t.Error("the failed migration's table was left behind")
+	}
+}